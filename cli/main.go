@@ -12,10 +12,14 @@ import (
 	"google.golang.org/grpc/credentials"
 	"io"
 	"log"
+	"math"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 )
 
 const (
@@ -28,10 +32,54 @@ const (
 	commandFlagIoBytesPerSecond  = "io"
 	commandFlagCommand           = "c"
 	commandFlagId                = "id"
+	commandFlagGracePeriod       = "grace-period"
+	commandFlagIoRead            = "io-read"
+	commandFlagIoWrite           = "io-write"
+	commandFlagIoReadOps         = "io-read-iops"
+	commandFlagIoWriteOps        = "io-write-iops"
+	commandFlagIoDevice          = "io-device"
+	commandFlagMemoryMax         = "memory-max"
+	commandFlagSwapMax           = "swap-max"
+	commandFlagCpuWeight         = "cpu-weight"
+	commandFlagCpusetCpus        = "cpuset-cpus"
+	commandFlagCpusetMems        = "cpuset-mems"
+	commandFlagMaxProcesses      = "max-processes"
+	commandFlagInterval          = "interval"
+	commandFlagPressureTrigger   = "pressure-trigger"
+	commandFlagRootFS            = "rootfs"
+	commandFlagOverlay           = "overlay"
+	commandFlagKeepChanges       = "keep-changes"
+	commandFlagOutput            = "output"
+	commandFlagVolume            = "volume"
+	commandFlagImage             = "image"
+	commandFlagEnv               = "env"
+	commandFlagEnvFile           = "env-file"
+	commandFlagWorkdir           = "workdir"
+	commandFlagUid               = "uid"
+	commandFlagGid               = "gid"
+	commandFlagGroup             = "group"
+	commandFlagSeccompProfile    = "seccomp-profile"
+	commandFlagCapAdd            = "cap-add"
+	commandFlagCapDrop           = "cap-drop"
+	commandFlagNoNewPrivs        = "no-new-privs"
+	commandFlagRlimit            = "rlimit"
+	commandFlagNetwork           = "network"
+	commandFlagPublish           = "publish"
+	commandFlagNetworkIngress    = "network-ingress"
+	commandFlagNetworkEgress     = "network-egress"
+	commandFlagHostname          = "hostname"
+	commandFlagDomainName        = "domainname"
+	commandFlagShmSize           = "shm-size"
+	commandFlagDelegateCGroup    = "delegate-cgroup"
 )
 
 var (
-	ErrNoAbleToCreateClient = errors.New("not able to create client")
+	ErrNoAbleToCreateClient   = errors.New("not able to create client")
+	ErrInvalidPressureTrigger = errors.New("pressure trigger must be in format <cpu|memory|io>:<some|full>:<threshold>:<window>, such as memory:some:150ms:1s")
+	ErrInvalidVolume          = errors.New("volume must be in format bind:<source>:<target>[:ro], tmpfs:<target>[:<size bytes>] or ro:<target>")
+	ErrInvalidEnv             = errors.New("environment variable must be in format <name>=<value>")
+	ErrInvalidPortMapping     = errors.New("port mapping must be in format <host port>:<container port>[/<tcp|udp>], such as 8080:80")
+	ErrInvalidRlimit          = errors.New("rlimit must be in format <name>=<soft>[:<hard>], such as nofile=1024:4096 or cpu=60, unlimited for no limit")
 )
 
 func main() {
@@ -69,9 +117,21 @@ func main() {
 					&cli.StringFlag{
 						Name:     commandFlagCpu,
 						Value:    "0.5",
-						Usage:    "approximate number of CPU cores to limit the job",
+						Usage:    "number of CPU cores to limit the job, such as 0.5 for half a CPU core, at most the CPUs of the server",
 						Required: true,
 					},
+					&cli.Uint64Flag{
+						Name:  commandFlagCpuWeight,
+						Usage: "relative CPU share between 1 and 10000 when CPUs are contended",
+					},
+					&cli.StringFlag{
+						Name:  commandFlagCpusetCpus,
+						Usage: "CPU cores the job is pinned to, such as 0-3,6",
+					},
+					&cli.StringFlag{
+						Name:  commandFlagCpusetMems,
+						Usage: "memory nodes the job is pinned to, such as 0",
+					},
 					// TODO: in future add format support for - e.g. 100MB, 1GB and etc
 					&cli.StringFlag{
 						Name:     commandFlagMemory,
@@ -79,17 +139,153 @@ func main() {
 						Usage:    "maximum amount of memory used by the job",
 						Required: true,
 					},
+					&cli.Int64Flag{
+						Name:  commandFlagMemoryMax,
+						Usage: "hard limit of memory, the job is killed by OOM killer above it",
+					},
+					&cli.Int64Flag{
+						Name:  commandFlagSwapMax,
+						Usage: "maximum amount of swap used by the job, -1 disables swap",
+					},
+					&cli.Int64Flag{
+						Name:  commandFlagShmSize,
+						Usage: "size of the job's /dev/shm in bytes, counted against its memory (64 MB or --memory if lower if not set)",
+					},
+					&cli.BoolFlag{
+						Name:  commandFlagDelegateCGroup,
+						Usage: "allow the job to create child cgroups below /sys/fs/cgroup (requires cgroup2 mounted with nsdelegate)",
+					},
+					&cli.Int64Flag{
+						Name:  commandFlagMaxProcesses,
+						Usage: "maximum number of processes and threads the job can run at once, at least 16",
+					},
 					&cli.StringFlag{
 						Name:     commandFlagIoBytesPerSecond,
 						Value:    "1000000",
 						Usage:    "maximum read and write on the device mounted / is mounted on",
 						Required: true,
 					},
+					&cli.Int64Flag{
+						Name:  commandFlagIoRead,
+						Usage: "maximum bytes per second read, overrides --io for reads",
+					},
+					&cli.Int64Flag{
+						Name:  commandFlagIoWrite,
+						Usage: "maximum bytes per second written, overrides --io for writes",
+					},
+					&cli.Int64Flag{
+						Name:  commandFlagIoReadOps,
+						Usage: "maximum read operations per second",
+					},
+					&cli.Int64Flag{
+						Name:  commandFlagIoWriteOps,
+						Usage: "maximum write operations per second",
+					},
 					&cli.StringFlag{
-						Name:     commandFlagCommand,
-						Aliases:  []string{"command"},
-						Usage:    "command to execute",
-						Required: true,
+						Name:  commandFlagIoDevice,
+						Usage: "path on the block device IO limits are applied to (server uses the job's root filesystem if not set)",
+					},
+					&cli.StringFlag{
+						Name:    commandFlagCommand,
+						Aliases: []string{"command"},
+						Usage:   "command to execute, the image's entrypoint if not set",
+					},
+					&cli.StringFlag{
+						Name:  commandFlagRootFS,
+						Usage: "directory or tarball on the server to run the job in as its root filesystem",
+					},
+					&cli.StringFlag{
+						Name:  commandFlagImage,
+						Usage: "OCI image layout or docker save tarball in the server's image directory, such as alpine:3.20",
+					},
+					&cli.BoolFlag{
+						Name:  commandFlagOverlay,
+						Usage: "unpack a --rootfs tarball once and share it between jobs on writable overlays instead of unpacking it per job",
+					},
+					&cli.BoolFlag{
+						Name:  commandFlagKeepChanges,
+						Usage: "keep the changes the job made on the overlay for export once the job has completed",
+					},
+					&cli.DurationFlag{
+						Name:  commandFlagGracePeriod,
+						Usage: "time between SIGTERM and SIGKILL when the job is stopped, e.g. 30s (server default if not set)",
+					},
+					&cli.StringSliceFlag{
+						Name:  commandFlagEnv,
+						Usage: "set an environment variable of the command, such as MODE=test (can be repeated)",
+					},
+					&cli.StringFlag{
+						Name:  commandFlagEnvFile,
+						Usage: "file of environment variables in format <name>=<value> per line, overridden by --env",
+					},
+					&cli.StringFlag{
+						Name:  commandFlagWorkdir,
+						Usage: "absolute working directory of the command (image's working directory if not set)",
+					},
+					&cli.UintFlag{
+						Name:  commandFlagUid,
+						Usage: "UID the command runs as in the job's user namespace (first UID the server allows if not set)",
+					},
+					&cli.UintFlag{
+						Name:  commandFlagGid,
+						Usage: "GID the command runs as in the job's user namespace (first GID the server allows if not set)",
+					},
+					&cli.UintSliceFlag{
+						Name:  commandFlagGroup,
+						Usage: "supplementary group ID of the command (can be repeated)",
+					},
+					&cli.StringFlag{
+						Name:  commandFlagSeccompProfile,
+						Usage: "syscall filter: default, permissive or the name of a Docker JSON profile on the server (default if not set)",
+					},
+					&cli.StringSliceFlag{
+						Name:  commandFlagCapAdd,
+						Usage: "capability the command runs with, such as NET_BIND_SERVICE or ALL (can be repeated)",
+					},
+					&cli.StringSliceFlag{
+						Name:  commandFlagCapDrop,
+						Usage: "capability removed from --cap-add, such as SYS_ADMIN after ALL (can be repeated)",
+					},
+					&cli.BoolFlag{
+						Name:  commandFlagNoNewPrivs,
+						Value: true,
+						Usage: "prevent setuid binaries from raising privileges, --no-new-privs=false must be allowed by the server",
+					},
+					&cli.StringSliceFlag{
+						Name:  commandFlagRlimit,
+						Usage: "resource limit of the command, such as nofile=1024:4096, core=0 or cpu=60 in seconds (can be repeated)",
+					},
+					&cli.StringFlag{
+						Name:  commandFlagNetwork,
+						Usage: "network of the job: none, loopback or bridge to connect it to the server's bridge (none if not set)",
+					},
+					&cli.StringSliceFlag{
+						Name:  commandFlagPublish,
+						Usage: "forward a port of the server to the job with --network bridge, such as 8080:80 or 5353:53/udp (can be repeated)",
+					},
+					&cli.Int64Flag{
+						Name:  commandFlagNetworkIngress,
+						Usage: "maximum bytes per second received by the job with --network bridge",
+					},
+					&cli.Int64Flag{
+						Name:  commandFlagNetworkEgress,
+						Usage: "maximum bytes per second sent by the job with --network bridge",
+					},
+					&cli.StringFlag{
+						Name:  commandFlagHostname,
+						Usage: "hostname of the job (a short form of the job's id if not set)",
+					},
+					&cli.StringFlag{
+						Name:  commandFlagDomainName,
+						Usage: "NIS domain name of the job, such as example.com",
+					},
+					&cli.StringSliceFlag{
+						Name:  commandFlagVolume,
+						Usage: "mount into the job, such as bind:/data:/data:ro, tmpfs:/scratch:100000000 or ro:/usr (can be repeated)",
+					},
+					&cli.StringSliceFlag{
+						Name:  commandFlagPressureTrigger,
+						Usage: "report in job output when the job is stalled on a resource, such as memory:some:150ms:1s (can be repeated)",
 					},
 				},
 				Action: func(cCtx *cli.Context) error {
@@ -99,13 +295,93 @@ func main() {
 					}
 					defer conn.Close()
 
-					command := cCtx.String(commandFlagCommand)
-					args := cCtx.Args().Slice()
-					cpu := cCtx.Float64(commandFlagCpu)
-					memory := cCtx.Int64(commandFlagMemory)
-					ioBytesPerSecond := cCtx.Int64(commandFlagIoBytesPerSecond)
+					request := &proto.JobCreateRequest{
+						CPU:                 cCtx.Float64(commandFlagCpu),
+						MemBytes:            cCtx.Int64(commandFlagMemory),
+						IoBytesPerSecond:    cCtx.Int64(commandFlagIoBytesPerSecond),
+						Command:             cCtx.String(commandFlagCommand),
+						Args:                cCtx.Args().Slice(),
+						StopGracePeriodMs:   cCtx.Duration(commandFlagGracePeriod).Milliseconds(),
+						ReadBytesPerSecond:  cCtx.Int64(commandFlagIoRead),
+						WriteBytesPerSecond: cCtx.Int64(commandFlagIoWrite),
+						ReadIoPerSecond:     cCtx.Int64(commandFlagIoReadOps),
+						WriteIoPerSecond:    cCtx.Int64(commandFlagIoWriteOps),
+						IoDevicePath:        cCtx.String(commandFlagIoDevice),
+						MemMaxBytes:         cCtx.Int64(commandFlagMemoryMax),
+						SwapMaxBytes:        cCtx.Int64(commandFlagSwapMax),
+						CpuWeight:           cCtx.Uint64(commandFlagCpuWeight),
+						CpusetCpus:          cCtx.String(commandFlagCpusetCpus),
+						CpusetMems:          cCtx.String(commandFlagCpusetMems),
+						MaxProcesses:        cCtx.Int64(commandFlagMaxProcesses),
+						RootFS:              cCtx.String(commandFlagRootFS),
+						RootFSOverlay:       cCtx.Bool(commandFlagOverlay),
+						KeepRootFSChanges:   cCtx.Bool(commandFlagKeepChanges),
+						Image:               cCtx.String(commandFlagImage),
+						WorkingDir:          cCtx.String(commandFlagWorkdir),
+						SeccompProfile:      cCtx.String(commandFlagSeccompProfile),
+						NetworkMode:         cCtx.String(commandFlagNetwork),
+						AddCapabilities:     cCtx.StringSlice(commandFlagCapAdd),
+						DropCapabilities:    cCtx.StringSlice(commandFlagCapDrop),
+
+						NetworkIngressBytesPerSecond: cCtx.Int64(commandFlagNetworkIngress),
+						NetworkEgressBytesPerSecond:  cCtx.Int64(commandFlagNetworkEgress),
+						Hostname:                     cCtx.String(commandFlagHostname),
+						DomainName:                   cCtx.String(commandFlagDomainName),
+						ShmSizeBytes:                 cCtx.Int64(commandFlagShmSize),
+						DelegateCGroup:               cCtx.Bool(commandFlagDelegateCGroup),
+					}
+
+					if cCtx.IsSet(commandFlagNoNewPrivs) {
+						noNewPrivs := cCtx.Bool(commandFlagNoNewPrivs)
+						request.NoNewPrivs = &noNewPrivs
+					}
+					if cCtx.IsSet(commandFlagUid) {
+						uid := uint32(cCtx.Uint(commandFlagUid))
+						request.Uid = &uid
+					}
+					if cCtx.IsSet(commandFlagGid) {
+						gid := uint32(cCtx.Uint(commandFlagGid))
+						request.Gid = &gid
+					}
+					for _, group := range cCtx.UintSlice(commandFlagGroup) {
+						request.Groups = append(request.Groups, uint32(group))
+					}
+
+					request.Env, err = parseEnv(cCtx.String(commandFlagEnvFile), cCtx.StringSlice(commandFlagEnv))
+					if err != nil {
+						return err
+					}
+
+					request.Rlimits, err = parseRlimits(cCtx.StringSlice(commandFlagRlimit))
+					if err != nil {
+						return err
+					}
+
+					for _, value := range cCtx.StringSlice(commandFlagPublish) {
+						mapping, err := parsePortMapping(value)
+						if err != nil {
+							return err
+						}
+						request.PortMappings = append(request.PortMappings, mapping)
+					}
 
-					return start(client, command, args, cpu, memory, ioBytesPerSecond)
+					for _, trigger := range cCtx.StringSlice(commandFlagPressureTrigger) {
+						pressureTrigger, err := parsePressureTrigger(trigger)
+						if err != nil {
+							return err
+						}
+						request.PressureTriggers = append(request.PressureTriggers, pressureTrigger)
+					}
+
+					for _, volume := range cCtx.StringSlice(commandFlagVolume) {
+						jobVolume, err := parseVolume(volume)
+						if err != nil {
+							return err
+						}
+						request.Volumes = append(request.Volumes, jobVolume)
+					}
+
+					return start(client, request)
 				},
 			},
 			{
@@ -156,6 +432,79 @@ func main() {
 					return stream(client, jobId)
 				},
 			},
+			{
+				Name:  "export",
+				Usage: "export the changes a completed job made to its root filesystem as a tar layer",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     commandFlagId,
+						Usage:    "job id",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     commandFlagOutput,
+						Aliases:  []string{"o"},
+						Usage:    "file to write the tar layer to",
+						Required: true,
+					},
+				},
+				Action: func(cCtx *cli.Context) error {
+					client, conn, err := createClient(cCtx)
+					if err != nil {
+						return ErrNoAbleToCreateClient
+					}
+					defer conn.Close()
+
+					return export(client, cCtx.String(commandFlagId), cCtx.String(commandFlagOutput))
+				},
+			},
+			{
+				Name:  "remove-changes",
+				Usage: "remove the changes a completed job kept for export, before they expire",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     commandFlagId,
+						Usage:    "job id",
+						Required: true,
+					},
+				},
+				Action: func(cCtx *cli.Context) error {
+					client, conn, err := createClient(cCtx)
+					if err != nil {
+						return ErrNoAbleToCreateClient
+					}
+					defer conn.Close()
+
+					return removeChanges(client, cCtx.String(commandFlagId))
+				},
+			},
+			{
+				Name:  "top",
+				Usage: "show job's live resource usage",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     commandFlagId,
+						Usage:    "job id",
+						Required: true,
+					},
+					&cli.DurationFlag{
+						Name:  commandFlagInterval,
+						Value: time.Second,
+						Usage: "refresh interval",
+					},
+				},
+				Action: func(cCtx *cli.Context) error {
+					client, conn, err := createClient(cCtx)
+					if err != nil {
+						return ErrNoAbleToCreateClient
+					}
+					defer conn.Close()
+
+					jobId := cCtx.String(commandFlagId)
+
+					return top(client, jobId, cCtx.Duration(commandFlagInterval))
+				},
+			},
 			{
 				Name:  "stop",
 				Usage: "stop job execution",
@@ -165,6 +514,10 @@ func main() {
 						Usage:    "job id",
 						Required: true,
 					},
+					&cli.DurationFlag{
+						Name:  commandFlagGracePeriod,
+						Usage: "time between SIGTERM and SIGKILL, e.g. 30s (job's grace period if not set)",
+					},
 				},
 				Action: func(cCtx *cli.Context) error {
 					client, conn, err := createClient(cCtx)
@@ -176,7 +529,7 @@ func main() {
 					jobId := cCtx.String(commandFlagId)
 					fmt.Printf("stopping job id: %s\n", jobId)
 
-					return stop(client, jobId)
+					return stop(client, jobId, cCtx.Duration(commandFlagGracePeriod))
 				},
 			},
 		},
@@ -187,19 +540,152 @@ func main() {
 	}
 }
 
-func start(client proto.JobWorkerClient, command string, args []string, cpu float64, memBytes int64, ioBytesPerSecond int64) error {
+// parsePressureTrigger parses pressure trigger in format <cpu|memory|io>:<some|full>:<threshold>:<window>
+func parsePressureTrigger(value string) (*proto.PressureTrigger, error) {
+	fields := strings.Split(value, ":")
+	if len(fields) != 4 || (fields[1] != "some" && fields[1] != "full") {
+		return nil, ErrInvalidPressureTrigger
+	}
+
+	threshold, err := time.ParseDuration(fields[2])
+	if err != nil {
+		return nil, ErrInvalidPressureTrigger
+	}
+	window, err := time.ParseDuration(fields[3])
+	if err != nil {
+		return nil, ErrInvalidPressureTrigger
+	}
+
+	return &proto.PressureTrigger{
+		Resource:    fields[0],
+		Full:        fields[1] == "full",
+		ThresholdMs: threshold.Milliseconds(),
+		WindowMs:    window.Milliseconds(),
+	}, nil
+}
+
+// parseVolume parses volume in format bind:<source>:<target>[:ro], tmpfs:<target>[:<size bytes>] or ro:<target>
+func parseVolume(value string) (*proto.Volume, error) {
+	fields := strings.Split(value, ":")
+
+	switch {
+	case fields[0] == "bind" && (len(fields) == 3 || (len(fields) == 4 && fields[3] == "ro")):
+		return &proto.Volume{
+			Type:     fields[0],
+			Source:   fields[1],
+			Target:   fields[2],
+			ReadOnly: len(fields) == 4,
+		}, nil
+	case fields[0] == "tmpfs" && (len(fields) == 2 || len(fields) == 3):
+		volume := &proto.Volume{
+			Type:   fields[0],
+			Target: fields[1],
+		}
+		if len(fields) == 3 {
+			size, err := strconv.ParseInt(fields[2], 10, 64)
+			if err != nil {
+				return nil, ErrInvalidVolume
+			}
+			volume.SizeBytes = size
+		}
+		return volume, nil
+	case fields[0] == "ro" && len(fields) == 2:
+		return &proto.Volume{
+			Type:   fields[0],
+			Target: fields[1],
+		}, nil
+	}
+	return nil, ErrInvalidVolume
+}
+
+// parsePortMapping parses port mapping in format <host port>:<container port>[/<tcp|udp>]
+func parsePortMapping(value string) (*proto.PortMapping, error) {
+	ports, protocol, _ := strings.Cut(value, "/")
+	hostPort, containerPort, ok := strings.Cut(ports, ":")
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPortMapping, value)
+	}
+
+	mapping := &proto.PortMapping{Protocol: protocol}
+	for _, port := range []struct {
+		value  string
+		result *uint32
+	}{{hostPort, &mapping.HostPort}, {containerPort, &mapping.ContainerPort}} {
+		number, err := strconv.ParseUint(port.value, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPortMapping, value)
+		}
+		*port.result = uint32(number)
+	}
+	return mapping, nil
+}
+
+// parseEnv reads environment variables in format <name>=<value> from lines of file, if set, and from values,
+// which override the file's ones. Empty lines and lines starting with # are skipped.
+func parseEnv(file string, values []string) (map[string]string, error) {
+	var variables []string
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading env file: %w", err)
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "#") {
+				variables = append(variables, line)
+			}
+		}
+	}
+
+	env := map[string]string{}
+	for _, variable := range append(variables, values...) {
+		name, value, ok := strings.Cut(variable, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidEnv, variable)
+		}
+		env[name] = value
+	}
+	return env, nil
+}
+
+// parseRlimits parses resource limits in format <name>=<soft>[:<hard>], the hard limit is the soft one if not set
+func parseRlimits(values []string) (map[string]*proto.Rlimit, error) {
+	rlimits := map[string]*proto.Rlimit{}
+	for _, value := range values {
+		name, limits, ok := strings.Cut(value, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRlimit, value)
+		}
+		soft, hard, ok := strings.Cut(limits, ":")
+		if !ok {
+			hard = soft
+		}
+
+		rlimit := &proto.Rlimit{}
+		for _, limit := range []struct {
+			value  string
+			result *uint64
+		}{{soft, &rlimit.Soft}, {hard, &rlimit.Hard}} {
+			if limit.value == "unlimited" {
+				*limit.result = math.MaxUint64
+				continue
+			}
+			number, err := strconv.ParseUint(limit.value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidRlimit, value)
+			}
+			*limit.result = number
+		}
+		rlimits[name] = rlimit
+	}
+	return rlimits, nil
+}
+
+func start(client proto.JobWorkerClient, request *proto.JobCreateRequest) error {
 	// Initiate the stream with a context that supports cancellation.
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	request := &proto.JobCreateRequest{
-		CPU:              cpu,
-		MemBytes:         memBytes,
-		IoBytesPerSecond: ioBytesPerSecond,
-		Command:          command,
-		Args:             args,
-	}
-
 	response, err := client.Start(ctx, request)
 
 	if err != nil {
@@ -221,11 +707,19 @@ func status(client proto.JobWorkerClient, jobId string) error {
 		return fmt.Errorf("failed to get status: %w", err)
 	}
 
-	fmt.Printf("Jod:%s has status: %s. exitCode:%d, exitReason:%s\n",
+	fmt.Printf("Jod:%s has status: %s. exitCode:%d, exitReason:%s, oomKilled:%t, cpuLimitExceeded:%t, processes:%d, processesPeak:%d, processesLimitReached:%t, cpuTime:%s, memoryPeak:%d, ipAddress:%s\n",
 		jobId,
 		response.GetStatus(),
 		response.GetExitCode(),
-		response.GetExitReason())
+		response.GetExitReason(),
+		response.GetOomKilled(),
+		response.GetCpuLimitExceeded(),
+		response.GetPidsCurrent(),
+		response.GetPidsPeak(),
+		response.GetPidsLimitReached(),
+		time.Duration(response.GetCpuUsageUsec())*time.Microsecond,
+		response.GetMemoryPeakBytes(),
+		response.GetIpAddress())
 
 	return nil
 }
@@ -274,22 +768,173 @@ func stream(client proto.JobWorkerClient, jobId string) error {
 	return nil
 }
 
-func stop(client proto.JobWorkerClient, jobId string) error {
-	job := &proto.JobRequest{
+// export writes the rootfs changes of a job into a file
+func export(client proto.JobWorkerClient, jobId string, output string) error {
+	request := &proto.JobRequest{
 		Id: jobId,
 	}
+	response, err := client.ExportRootFSChanges(context.Background(), request)
+	if err != nil {
+		return fmt.Errorf("error creating export stream: %v", err)
+	}
 
-	response, err := client.Status(context.Background(), job)
+	file, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", output, err)
+	}
+	defer file.Close()
+
+	for {
+		content, err := response.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			_ = os.Remove(output)
+			return fmt.Errorf("failed to receive rootfs changes: %w", err)
+		}
+
+		if _, err = file.Write(content.GetContent()); err != nil {
+			return fmt.Errorf("error writing %s: %w", output, err)
+		}
+	}
+
+	fmt.Printf("rootfs changes of job %s written to %s\n", jobId, output)
+	return file.Close()
+}
+
+// removeChanges removes the rootfs changes a job has kept for export
+func removeChanges(client proto.JobWorkerClient, jobId string) error {
+	request := &proto.JobRequest{
+		Id: jobId,
+	}
+	if _, err := client.RemoveRootFSChanges(context.Background(), request); err != nil {
+		return fmt.Errorf("error removing rootfs changes: %v", err)
+	}
+
+	fmt.Printf("rootfs changes of job %s removed\n", jobId)
+	return nil
+}
+
+// top periodically prints job's resource usage until the job has exited or the user interrupts it
+func top(client proto.JobWorkerClient, jobId string, interval time.Duration) error {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	request := &proto.JobRequest{
+		Id: jobId,
+	}
+
+	var previous *proto.UsageResponse
+	for {
+		response, err := client.Usage(context.Background(), request)
+		if err != nil {
+			return fmt.Errorf("failed to get usage: %w", err)
+		}
+
+		printUsage(jobId, response, previous, interval)
+		previous = response
+
+		if response.GetStatus() == proto.Status_COMPLETED || response.GetStatus() == proto.Status_TERMINATED {
+			return nil
+		}
+
+		select {
+		case <-sigCh:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func printUsage(jobId string, response *proto.UsageResponse, previous *proto.UsageResponse, interval time.Duration) {
+	// CPU usage in percent of a single core since the previous refresh
+	cpuPercent := 0.0
+	if previous != nil {
+		cpuPercent = float64(response.GetCpu().GetUsageUsec()-previous.GetCpu().GetUsageUsec()) /
+			float64(interval.Microseconds()) * 100
+	}
+
+	// clear the terminal and move the cursor to the top left corner
+	fmt.Print("\033[H\033[2J")
+	fmt.Printf("Jod:%s has status: %s\n", jobId, response.GetStatus())
+	fmt.Printf("CPU:       %.1f%%, total:%s, user:%s, system:%s, throttled:%d/%d periods (%s)\n",
+		cpuPercent,
+		time.Duration(response.GetCpu().GetUsageUsec())*time.Microsecond,
+		time.Duration(response.GetCpu().GetUserUsec())*time.Microsecond,
+		time.Duration(response.GetCpu().GetSystemUsec())*time.Microsecond,
+		response.GetCpu().GetNrThrottled(),
+		response.GetCpu().GetNrPeriods(),
+		time.Duration(response.GetCpu().GetThrottledUsec())*time.Microsecond)
+	fmt.Printf("Memory:    current:%d, peak:%d, anon:%d, file:%d\n",
+		response.GetMemory().GetCurrentBytes(),
+		response.GetMemory().GetPeakBytes(),
+		response.GetMemory().GetStat()["anon"],
+		response.GetMemory().GetStat()["file"])
+	for _, ioUsage := range response.GetIo() {
+		fmt.Printf("IO(%s): read:%d (%d ops), write:%d (%d ops)\n",
+			ioUsage.GetDevice(),
+			ioUsage.GetReadBytes(),
+			ioUsage.GetReadIos(),
+			ioUsage.GetWriteBytes(),
+			ioUsage.GetWriteIos())
+	}
+	fmt.Printf("Processes: current:%d, peak:%d\n", response.GetPidsCurrent(), response.GetPidsPeak())
+	fmt.Printf("Network:   rx:%d (%d packets, %d dropped), tx:%d (%d packets, %d dropped)\n",
+		response.GetNetwork().GetRxBytes(),
+		response.GetNetwork().GetRxPackets(),
+		response.GetNetwork().GetRxDropped(),
+		response.GetNetwork().GetTxBytes(),
+		response.GetNetwork().GetTxPackets(),
+		response.GetNetwork().GetTxDropped())
+	for _, pressure := range []struct {
+		resource string
+		pressure *proto.Pressure
+	}{
+		{resource: "CPU", pressure: response.GetCpuPressure()},
+		{resource: "Memory", pressure: response.GetMemoryPressure()},
+		{resource: "IO", pressure: response.GetIoPressure()},
+	} {
+		fmt.Printf("%s pressure: some avg10:%.2f avg60:%.2f avg300:%.2f, full avg10:%.2f avg60:%.2f avg300:%.2f\n",
+			pressure.resource,
+			pressure.pressure.GetSome().GetAvg10(),
+			pressure.pressure.GetSome().GetAvg60(),
+			pressure.pressure.GetSome().GetAvg300(),
+			pressure.pressure.GetFull().GetAvg10(),
+			pressure.pressure.GetFull().GetAvg60(),
+			pressure.pressure.GetFull().GetAvg300())
+	}
+}
+
+func stop(client proto.JobWorkerClient, jobId string, gracePeriod time.Duration) error {
+	job := &proto.StopRequest{
+		Id:            jobId,
+		GracePeriodMs: gracePeriod.Milliseconds(),
+	}
+
+	response, err := client.Stop(context.Background(), job)
 
 	if err != nil {
-		return fmt.Errorf("failed to get status: %w", err)
+		return fmt.Errorf("failed to stop job: %w", err)
 	}
 
-	fmt.Printf("Jod:%s has status: %s, exitCode:%d, exitReason:%s\n",
+	fmt.Printf("Jod:%s has status: %s, exitCode:%d, exitReason:%s, oomKilled:%t, cpuLimitExceeded:%t, processes:%d, processesPeak:%d, processesLimitReached:%t, cpuTime:%s, memoryPeak:%d, ipAddress:%s\n",
 		jobId,
 		response.GetStatus(),
 		response.GetExitCode(),
-		response.GetExitReason())
+		response.GetExitReason(),
+		response.GetOomKilled(),
+		response.GetCpuLimitExceeded(),
+		response.GetPidsCurrent(),
+		response.GetPidsPeak(),
+		response.GetPidsLimitReached(),
+		time.Duration(response.GetCpuUsageUsec())*time.Microsecond,
+		response.GetMemoryPeakBytes(),
+		response.GetIpAddress())
 
 	return nil
 }
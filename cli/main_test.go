@@ -76,7 +76,13 @@ func Test_Client_Should_start_simple_job_and_return_correct_status(t *testing.T)
 	io := int64(10000000)
 
 	testFunction := func() error {
-		err = start(client, command, args, cpu, memory, io)
+		err = start(client, &proto.JobCreateRequest{
+			CPU:              cpu,
+			MemBytes:         memory,
+			IoBytesPerSecond: io,
+			Command:          command,
+			Args:             args,
+		})
 		if err != nil {
 			t.Error(ErrNoAbleToCreateClient)
 		}
@@ -0,0 +1,49 @@
+package jobWorker
+
+import (
+	"errors"
+	ns "github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker/namespaces"
+	"strconv"
+)
+
+var (
+	ErrInvalidCGroupLimit = errors.New("CGroupCPU, CGroupMemMaxBytes and CGroupMaxProcesses must not be negative")
+)
+
+var (
+	// CGroupParent is the cgroup all job cgroups are created in, created at SetupCGroupParent if it doesn't exist
+	CGroupParent = "/sys/fs/cgroup/jobworker.slice"
+	// CGroupCPU is the number of CPU cores all jobs can use together, unlimited if 0
+	CGroupCPU float64
+	// CGroupMemMaxBytes is the hard limit of memory of all jobs together, unlimited if 0
+	CGroupMemMaxBytes int64
+	// CGroupMaxProcesses is the maximum number of processes and threads of all jobs together, unlimited if 0
+	CGroupMaxProcesses int64
+)
+
+// SetupCGroupParent creates or adopts CGroupParent with the controllers jobs are limited by enabled and applies
+// CGroupCPU, CGroupMemMaxBytes and CGroupMaxProcesses to all jobs together. It must be called before jobs are started,
+// so that a missing controller is reported once instead of failing every job.
+func SetupCGroupParent() error {
+	if CGroupCPU < 0 || CGroupMemMaxBytes < 0 || CGroupMaxProcesses < 0 {
+		return ErrInvalidCGroupLimit
+	}
+
+	// limits are reset to "max" if not set, so that an adopted parent doesn't keep limits of a previous run
+	limits := map[string]string{
+		ns.CpuMaxFile:    "max " + strconv.Itoa(cpuMaxPeriod),
+		ns.MemoryMaxFile: "max",
+		ns.PidsMaxFile:   "max",
+	}
+	if CGroupCPU > 0 {
+		limits[ns.CpuMaxFile] = getCPUMax(CGroupCPU)
+	}
+	if CGroupMemMaxBytes > 0 {
+		limits[ns.MemoryMaxFile] = strconv.FormatInt(CGroupMemMaxBytes, 10)
+	}
+	if CGroupMaxProcesses > 0 {
+		limits[ns.PidsMaxFile] = strconv.FormatInt(CGroupMaxProcesses, 10)
+	}
+
+	return ns.SetupParentCGroup(CGroupParent, limits)
+}
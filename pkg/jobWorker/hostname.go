@@ -0,0 +1,79 @@
+package jobWorker
+
+import (
+	"errors"
+	"fmt"
+	ns "github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker/namespaces"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+var (
+	ErrInvalidHostname   = errors.New("Hostname must be up to 63 letters, digits and hyphens not starting or ending with a hyphen")
+	ErrInvalidDomainName = errors.New("DomainName must be up to 64 characters of dot separated labels, such as example.com")
+)
+
+const (
+	// maxDomainNameLength is the longest domain name accepted by setdomainname
+	maxDomainNameLength = 64
+	// hostsFile is the path of the hosts file in the job's root filesystem
+	hostsFile = "/etc/hosts"
+	// localHostAddress is the address of the job's hostname in its hosts file unless the job is connected to the bridge,
+	// same as Debian's
+	localHostAddress = "127.0.1.1"
+)
+
+// hostnameRegexp matches a single label of a hostname as of RFC 1123
+var hostnameRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// domainNameRegexp matches dot separated labels, such as "example.com"
+var domainNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// isValidHostname returns ErrInvalidHostname or ErrInvalidDomainName, if Hostname or DomainName are set and invalid
+func (jobConfig *JobConfig) isValidHostname() error {
+	if jobConfig.Hostname != "" && !hostnameRegexp.MatchString(jobConfig.Hostname) {
+		return ErrInvalidHostname
+	}
+
+	if jobConfig.DomainName != "" &&
+		(len(jobConfig.DomainName) > maxDomainNameLength || !domainNameRegexp.MatchString(jobConfig.DomainName)) {
+		return ErrInvalidDomainName
+	}
+	return nil
+}
+
+// getHosts returns the content of the job's hosts file, the job's hostname resolves to address
+func (job *Job) getHosts(address string) string {
+	names := job.getHostname()
+	if job.config.DomainName != "" {
+		names = job.getHostname() + "." + job.config.DomainName + " " + names
+	}
+
+	return fmt.Sprintf("127.0.0.1\tlocalhost\n::1\tlocalhost ip6-localhost ip6-loopback\n%s\t%s\n", address, names)
+}
+
+// addHostsVolume writes the job's hosts file into the job's state directory and bind mounts it at hostsFile before
+// any other volume, if the job has its own root filesystem. The job's hostname resolves to its address on the bridge
+// or localHostAddress.
+func (job *Job) addHostsVolume(config *initConfig) error {
+	if config.RootFS == "" {
+		return nil
+	}
+
+	address := localHostAddress
+	if job.network != nil {
+		address = job.network.address.IP.String()
+	}
+
+	if err := os.MkdirAll(job.getStateDir(), 0o755); err != nil {
+		return fmt.Errorf("error creating job state: %w", err)
+	}
+	path := filepath.Join(job.getStateDir(), "hosts")
+	if err := os.WriteFile(path, []byte(job.getHosts(address)), 0o644); err != nil {
+		return fmt.Errorf("error writing hosts file: %w", err)
+	}
+
+	config.Volumes = append([]ns.Volume{{Type: ns.VolumeBind, Source: path, Target: hostsFile}}, config.Volumes...)
+	return nil
+}
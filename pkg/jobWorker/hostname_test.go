@@ -0,0 +1,57 @@
+package jobWorker
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_JobConfig_isValid_hostname(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		hostname    string
+		domainName  string
+		expectedErr error
+	}{
+		{},
+		{hostname: "web-1"},
+		{hostname: "web", domainName: "example.com"},
+		{hostname: "-web", expectedErr: ErrInvalidHostname},
+		{hostname: "web.example.com", expectedErr: ErrInvalidHostname},
+		{hostname: "web_1", expectedErr: ErrInvalidHostname},
+		{hostname: "a234567890123456789012345678901234567890123456789012345678901234", expectedErr: ErrInvalidHostname},
+		{domainName: "example..com", expectedErr: ErrInvalidDomainName},
+		{domainName: "example.com.", expectedErr: ErrInvalidDomainName},
+		{domainName: "a23456789012345678901234567890.a23456789012345678901234567890.com", expectedErr: ErrInvalidDomainName},
+	}
+
+	for _, testCase := range testCases {
+		config := JobConfig{
+			Command:          "ls",
+			CPU:              0.5,
+			MemBytes:         1_000_000,
+			IOBytesPerSecond: 1_000_000,
+			Hostname:         testCase.hostname,
+			DomainName:       testCase.domainName,
+		}
+		if err := config.isValid(); !errors.Is(err, testCase.expectedErr) {
+			t.Errorf("hostname:%s domainName:%s, expected error %v, got %v", testCase.hostname, testCase.domainName, testCase.expectedErr, err)
+		}
+	}
+}
+
+func Test_Job_getHosts(t *testing.T) {
+	t.Parallel()
+
+	job := NewJob(&JobConfig{Hostname: "web", DomainName: "example.com"})
+	expected := "127.0.0.1\tlocalhost\n::1\tlocalhost ip6-localhost ip6-loopback\n10.88.0.2\tweb.example.com web\n"
+	if hosts := job.getHosts("10.88.0.2"); hosts != expected {
+		t.Errorf("expected hosts %q, got %q", expected, hosts)
+	}
+
+	job = NewJob(&JobConfig{})
+	expected = "127.0.0.1\tlocalhost\n::1\tlocalhost ip6-localhost ip6-loopback\n127.0.1.1\t" + job.getShortID() + "\n"
+	if hosts := job.getHosts(localHostAddress); hosts != expected {
+		t.Errorf("expected hosts %q, got %q", expected, hosts)
+	}
+}
@@ -0,0 +1,422 @@
+package jobWorker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidImage     = errors.New("Image must be the name of an image in ImageDir optionally followed by :tag and can't be used with RootFS")
+	ErrImageNotFound    = errors.New("image not found")
+	ErrInvalidImageBlob = errors.New("image blob doesn't match its digest")
+)
+
+const (
+	ociIndexFile        = "index.json"
+	dockerManifestFile  = "manifest.json"
+	ociRefNameLabel     = "org.opencontainers.image.ref.name"
+	ociImageIndexType   = "application/vnd.oci.image.index.v1+json"
+	dockerManifestList  = "application/vnd.docker.distribution.manifest.list.v2+json"
+	defaultImageTagName = "latest"
+)
+
+// ImageDir is the directory of OCI image layouts and `docker save` tarballs jobs can run in, see JobConfig.Image
+var ImageDir = "/var/lib/jobworker/images"
+
+// ImageConfig is the part of an OCI image configuration used to run jobs
+type ImageConfig struct {
+	Entrypoint []string
+	Cmd        []string
+	Env        []string
+	WorkingDir string
+}
+
+// getCommand returns the image's Entrypoint followed by arguments, or Cmd if there are no arguments
+func (imageConfig *ImageConfig) getCommand(arguments []string) []string {
+	if len(arguments) == 0 {
+		arguments = imageConfig.Cmd
+	}
+	return append(append([]string{}, imageConfig.Entrypoint...), arguments...)
+}
+
+// image is an image unpacked into the content addressed cache
+type image struct {
+	// rootfs is the directory all layers of the image have been applied to, named after the image's config digest.
+	rootfs string
+	config ImageConfig
+}
+
+// ociDescriptor is a reference to a blob of an OCI image layout
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+	Platform    *struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+	} `json:"platform"`
+}
+
+// ociIndex is index.json of an OCI image layout, or an image index blob referencing a manifest per platform
+type ociIndex struct {
+	MediaType string          `json:"mediaType"`
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+type ociManifest struct {
+	Config ociDescriptor   `json:"config"`
+	Layers []ociDescriptor `json:"layers"`
+}
+
+// dockerManifest is an entry of manifest.json of a `docker save` tarball, paths are relative to the tarball
+type dockerManifest struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+type ociImageConfig struct {
+	Config ImageConfig `json:"config"`
+}
+
+// imageLayer is a layer blob within an image directory, digest is empty if the layer can't be verified
+type imageLayer struct {
+	path   string
+	digest string
+}
+
+// archiveCache remembers the directories `docker save` tarballs have been unpacked into, so that tarballs are
+// hashed only once while they don't change
+var archiveCache = struct {
+	sync.Mutex
+	archives map[string]unpackedArchive
+}{archives: map[string]unpackedArchive{}}
+
+type unpackedArchive struct {
+	size    int64
+	modTime time.Time
+	dir     string
+}
+
+// parseImageReference splits an image reference such as "alpine.tar:3.19" into the name in ImageDir and the tag
+func parseImageReference(reference string) (string, string, error) {
+	name, tag := reference, ""
+	if index := strings.LastIndex(reference, ":"); index > strings.LastIndex(reference, "/") {
+		name, tag = reference[:index], reference[index+1:]
+	}
+
+	if !filepath.IsLocal(name) || (tag == "" && strings.HasSuffix(reference, ":")) {
+		return "", "", ErrInvalidImage
+	}
+	return name, tag, nil
+}
+
+// loadImage unpacks an image of ImageDir into the content addressed cache in StateDir/unpacked, unless it has been unpacked
+// already, and returns it. The rootfs of the image must be released with releaseLayer.
+func loadImage(reference string) (*image, error) {
+	name, tag, err := parseImageReference(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(ImageDir, name)
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, reference)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading image: %w", err)
+	}
+
+	imageDir := path
+	if !info.IsDir() {
+		if imageDir, err = unpackImageArchive(path, info); err != nil {
+			return nil, err
+		}
+		// the unpacked archive is only read until the image is unpacked
+		defer releaseLayer(imageDir)
+	}
+
+	configPath, layers, err := readImageManifest(imageDir, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	configContent, err := readImageBlob(configPath)
+	if err != nil {
+		return nil, err
+	}
+	imageConfig := &ociImageConfig{}
+	if err = json.Unmarshal(configContent, imageConfig); err != nil {
+		return nil, fmt.Errorf("error parsing image config: %w", err)
+	}
+
+	// the config references all layers by digest, so its digest identifies the unpacked image
+	digest := sha256.Sum256(configContent)
+	rootfs := filepath.Join(StateDir, "unpacked", hex.EncodeToString(digest[:]))
+	err = unpackOnce(rootfs, func(unpackDir string) error {
+		for _, layer := range layers {
+			if err := unpackImageLayer(layer, unpackDir); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error unpacking image %s: %w", reference, err)
+	}
+
+	return &image{
+		rootfs: rootfs,
+		config: imageConfig.Config,
+	}, nil
+}
+
+// unpackImageArchive unpacks an image tarball such as `docker save` output into a directory named after the
+// tarball's digest and returns the directory, which must be released with releaseLayer
+func unpackImageArchive(path string, info os.FileInfo) (string, error) {
+	archiveCache.Lock()
+	defer archiveCache.Unlock()
+
+	archive, ok := archiveCache.archives[path]
+	if ok && archive.size == info.Size() && archive.modTime.Equal(info.ModTime()) {
+		acquireLayer(archive.dir)
+		if _, err := os.Stat(archive.dir); err == nil {
+			return archive.dir, nil
+		}
+		// the unpacked archive has been removed as unused layer
+		releaseLayer(archive.dir)
+	}
+
+	digest, err := getFileDigest(path)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(StateDir, "archives", digest)
+	err = unpackOnce(dir, func(unpackDir string) error {
+		return unpackRootFS(path, unpackDir)
+	})
+	if err != nil {
+		return "", fmt.Errorf("error unpacking image archive: %w", err)
+	}
+
+	archiveCache.archives[path] = unpackedArchive{size: info.Size(), modTime: info.ModTime(), dir: dir}
+	return dir, nil
+}
+
+// readImageManifest returns the config and the layers, from the lowest to the top most, of the image with the tag
+// in an OCI image layout or an unpacked `docker save` tarball. The only image, or else "latest", is used if tag is empty.
+func readImageManifest(imageDir string, tag string) (string, []imageLayer, error) {
+	// docker save writes manifest.json, newer versions write an OCI image layout as well
+	content, err := os.ReadFile(filepath.Join(imageDir, dockerManifestFile))
+	if err == nil {
+		return readDockerManifest(imageDir, content, tag)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", nil, fmt.Errorf("error reading image manifest: %w", err)
+	}
+
+	content, err = os.ReadFile(filepath.Join(imageDir, ociIndexFile))
+	if err != nil {
+		return "", nil, fmt.Errorf("error reading image index: %w", err)
+	}
+	index := &ociIndex{}
+	if err = json.Unmarshal(content, index); err != nil {
+		return "", nil, fmt.Errorf("error parsing image index: %w", err)
+	}
+
+	descriptor, err := selectImageDescriptor(index.Manifests, tag)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// follow image indexes down to the manifest of the current platform
+	for descriptor.MediaType == ociImageIndexType || descriptor.MediaType == dockerManifestList {
+		if content, err = readOCIBlob(imageDir, descriptor.Digest); err != nil {
+			return "", nil, err
+		}
+		platformIndex := &ociIndex{}
+		if err = json.Unmarshal(content, platformIndex); err != nil {
+			return "", nil, fmt.Errorf("error parsing image index: %w", err)
+		}
+		if descriptor, err = selectPlatformDescriptor(platformIndex.Manifests); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if content, err = readOCIBlob(imageDir, descriptor.Digest); err != nil {
+		return "", nil, err
+	}
+	manifest := &ociManifest{}
+	if err = json.Unmarshal(content, manifest); err != nil {
+		return "", nil, fmt.Errorf("error parsing image manifest: %w", err)
+	}
+
+	configPath, err := getOCIBlobPath(imageDir, manifest.Config.Digest)
+	if err != nil {
+		return "", nil, err
+	}
+	var layers []imageLayer
+	for _, layer := range manifest.Layers {
+		layerPath, err := getOCIBlobPath(imageDir, layer.Digest)
+		if err != nil {
+			return "", nil, err
+		}
+		layers = append(layers, imageLayer{path: layerPath, digest: layer.Digest})
+	}
+	return configPath, layers, nil
+}
+
+func readDockerManifest(imageDir string, content []byte, tag string) (string, []imageLayer, error) {
+	var manifests []dockerManifest
+	if err := json.Unmarshal(content, &manifests); err != nil {
+		return "", nil, fmt.Errorf("error parsing image manifest: %w", err)
+	}
+
+	var selected *dockerManifest
+	if tag == "" && len(manifests) == 1 {
+		selected = &manifests[0]
+	}
+	for i := 0; i < len(manifests) && selected == nil; i++ {
+		for _, repoTag := range manifests[i].RepoTags {
+			if matchesImageTag(repoTag, tag) {
+				selected = &manifests[i]
+				break
+			}
+		}
+	}
+	if selected == nil {
+		return "", nil, fmt.Errorf("%w: tag %q", ErrImageNotFound, tag)
+	}
+
+	configPath, err := getImageFilePath(imageDir, selected.Config)
+	if err != nil {
+		return "", nil, err
+	}
+	var layers []imageLayer
+	for _, layer := range selected.Layers {
+		layerPath, err := getImageFilePath(imageDir, layer)
+		if err != nil {
+			return "", nil, err
+		}
+		layers = append(layers, imageLayer{path: layerPath})
+	}
+	return configPath, layers, nil
+}
+
+// selectImageDescriptor returns the manifest of index.json with the tag, or the only manifest if tag is empty
+func selectImageDescriptor(descriptors []ociDescriptor, tag string) (*ociDescriptor, error) {
+	if tag == "" && len(descriptors) == 1 {
+		return &descriptors[0], nil
+	}
+
+	for i, descriptor := range descriptors {
+		if matchesImageTag(descriptor.Annotations[ociRefNameLabel], tag) {
+			return &descriptors[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: tag %q", ErrImageNotFound, tag)
+}
+
+// matchesImageTag returns true if a reference such as "alpine:3.19" or a bare tag such as "3.19" has the tag,
+// an empty tag matches "latest"
+func matchesImageTag(reference string, tag string) bool {
+	if tag == "" {
+		tag = defaultImageTagName
+	}
+	return reference == tag || strings.HasSuffix(reference, ":"+tag)
+}
+
+// selectPlatformDescriptor returns the manifest of an image index for the current OS and architecture
+func selectPlatformDescriptor(descriptors []ociDescriptor) (*ociDescriptor, error) {
+	for i, descriptor := range descriptors {
+		if descriptor.Platform != nil && descriptor.Platform.OS == runtime.GOOS &&
+			descriptor.Platform.Architecture == runtime.GOARCH {
+			return &descriptors[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: platform %s/%s", ErrImageNotFound, runtime.GOOS, runtime.GOARCH)
+}
+
+// getOCIBlobPath returns the path of a blob such as "sha256:<hex>" in an OCI image layout
+func getOCIBlobPath(imageDir string, digest string) (string, error) {
+	algorithm, hash, found := strings.Cut(digest, ":")
+	if !found || algorithm != "sha256" {
+		return "", fmt.Errorf("%w: unsupported digest %q", ErrInvalidImageBlob, digest)
+	}
+	return getImageFilePath(imageDir, filepath.Join("blobs", algorithm, hash))
+}
+
+// getImageFilePath returns the path of a file referenced by image metadata, which must not leave imageDir
+func getImageFilePath(imageDir string, name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidImage, name)
+	}
+	return filepath.Join(imageDir, name), nil
+}
+
+func readOCIBlob(imageDir string, digest string) ([]byte, error) {
+	path, err := getOCIBlobPath(imageDir, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := readImageBlob(path)
+	if err != nil {
+		return nil, err
+	}
+	if hash := sha256.Sum256(content); "sha256:"+hex.EncodeToString(hash[:]) != digest {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidImageBlob, digest)
+	}
+	return content, nil
+}
+
+func readImageBlob(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading image blob: %w", err)
+	}
+	return content, nil
+}
+
+// unpackImageLayer applies a layer on top of the layers unpacked into rootfs before, verifying its digest if known
+func unpackImageLayer(layer imageLayer, rootfs string) error {
+	file, err := os.Open(layer.path)
+	if err != nil {
+		return fmt.Errorf("error opening image layer: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	stream, err := newTarStream(io.TeeReader(file, hash))
+	if err != nil {
+		return fmt.Errorf("error reading image layer %s: %w", layer.path, err)
+	}
+	defer stream.Close()
+
+	if err = unpackTar(stream, rootfs, true); err != nil {
+		return fmt.Errorf("error unpacking image layer %s: %w", layer.path, err)
+	}
+
+	if layer.digest != "" {
+		// read the padding after the end of the tar, so that the digest covers the whole blob
+		if _, err = io.Copy(hash, file); err != nil {
+			return fmt.Errorf("error reading image layer %s: %w", layer.path, err)
+		}
+		if "sha256:"+hex.EncodeToString(hash.Sum(nil)) != layer.digest {
+			return fmt.Errorf("%w: %s", ErrInvalidImageBlob, layer.digest)
+		}
+	}
+	return nil
+}
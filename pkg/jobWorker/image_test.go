@@ -0,0 +1,207 @@
+package jobWorker
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeBlob writes content into an OCI image layout and returns its digest
+func writeBlob(t *testing.T, imageDir string, content []byte) string {
+	t.Helper()
+
+	hash := sha256.Sum256(content)
+	digest := hex.EncodeToString(hash[:])
+	if err := os.MkdirAll(filepath.Join(imageDir, "blobs/sha256"), 0o755); err != nil {
+		t.Fatalf("could not create blobs: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(imageDir, "blobs/sha256", digest), content, 0o644); err != nil {
+		t.Fatalf("could not write blob: %v", err)
+	}
+	return "sha256:" + digest
+}
+
+func writeJSON(t *testing.T, path string, value any) []byte {
+	t.Helper()
+
+	content, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("could not marshal %s: %v", path, err)
+	}
+	if path != "" {
+		if err = os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatalf("could not write %s: %v", path, err)
+		}
+	}
+	return content
+}
+
+// testImageLayers are the layers of test images, the second layer deletes and hides files of the first one
+func testImageLayers(t *testing.T) [][]byte {
+	return [][]byte{
+		newTarball(t, []tarEntry{
+			{header: tar.Header{Typeflag: tar.TypeReg, Name: "bin/app", Mode: 0o755}, content: "app"},
+			{header: tar.Header{Typeflag: tar.TypeReg, Name: "etc/motd", Mode: 0o644}, content: "welcome"},
+			{header: tar.Header{Typeflag: tar.TypeReg, Name: "var/cache/old", Mode: 0o644}, content: "old"},
+		}),
+		newTarball(t, []tarEntry{
+			{header: tar.Header{Typeflag: tar.TypeReg, Name: "etc/.wh.motd", Mode: 0o644}},
+			{header: tar.Header{Typeflag: tar.TypeDir, Name: "var/cache/", Mode: 0o755}},
+			{header: tar.Header{Typeflag: tar.TypeReg, Name: "var/cache/.wh..wh..opq", Mode: 0o644}},
+			{header: tar.Header{Typeflag: tar.TypeReg, Name: "var/cache/new", Mode: 0o644}, content: "new"},
+		}),
+	}
+}
+
+var testImageConfig = ImageConfig{
+	Entrypoint: []string{"/bin/app"},
+	Cmd:        []string{"--serve"},
+	Env:        []string{"PATH=/bin", "MODE=test"},
+	WorkingDir: "/srv",
+}
+
+func writeOCIImage(t *testing.T, imageDir string) {
+	t.Helper()
+
+	configDigest := writeBlob(t, imageDir, writeJSON(t, "", map[string]any{"config": testImageConfig}))
+	var layers []map[string]string
+	for _, layer := range testImageLayers(t) {
+		layers = append(layers, map[string]string{
+			"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip",
+			"digest":    writeBlob(t, imageDir, layer),
+		})
+	}
+	manifestDigest := writeBlob(t, imageDir, writeJSON(t, "", map[string]any{
+		"config": map[string]string{"digest": configDigest},
+		"layers": layers,
+	}))
+
+	writeJSON(t, filepath.Join(imageDir, "oci-layout"), map[string]string{"imageLayoutVersion": "1.0.0"})
+	writeJSON(t, filepath.Join(imageDir, ociIndexFile), map[string]any{
+		"manifests": []map[string]any{{
+			"mediaType":   "application/vnd.oci.image.manifest.v1+json",
+			"digest":      manifestDigest,
+			"annotations": map[string]string{ociRefNameLabel: "1.0"},
+		}},
+	})
+}
+
+func writeDockerImage(t *testing.T, path string) {
+	t.Helper()
+
+	entries := []tarEntry{
+		{header: tar.Header{Typeflag: tar.TypeReg, Name: "config.json", Mode: 0o644},
+			content: string(writeJSON(t, "", map[string]any{"config": testImageConfig}))},
+	}
+	var layers []string
+	for i, layer := range testImageLayers(t) {
+		name := filepath.Join("layer"+string(rune('0'+i)), "layer.tar")
+		entries = append(entries, tarEntry{header: tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0o644}, content: string(layer)})
+		layers = append(layers, name)
+	}
+	entries = append(entries, tarEntry{
+		header: tar.Header{Typeflag: tar.TypeReg, Name: dockerManifestFile, Mode: 0o644},
+		content: string(writeJSON(t, "", []dockerManifest{
+			{Config: "config.json", RepoTags: []string{"app:1.0"}, Layers: layers},
+		})),
+	})
+	writeTarball(t, path, entries)
+}
+
+func Test_loadImage_expected_layers_applied_with_whiteouts(t *testing.T) {
+	// not parallel, because the test replaces StateDir and ImageDir
+	defer func(stateDir, imageDir string) { StateDir, ImageDir = stateDir, imageDir }(StateDir, ImageDir)
+	StateDir, ImageDir = t.TempDir(), t.TempDir()
+
+	writeOCIImage(t, filepath.Join(ImageDir, "app"))
+	writeDockerImage(t, filepath.Join(ImageDir, "app.tar"))
+
+	for _, reference := range []string{"app", "app:1.0", "app.tar", "app.tar:1.0"} {
+		image, err := loadImage(reference)
+		if err != nil {
+			t.Fatalf("image:%s, could not load image: %v", reference, err)
+		}
+
+		if !reflect.DeepEqual(image.config, testImageConfig) {
+			t.Errorf("image:%s, expected config %+v, got %+v", reference, testImageConfig, image.config)
+		}
+
+		var files []string
+		err = filepath.WalkDir(image.rootfs, func(path string, entry os.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() {
+				name, _ := filepath.Rel(image.rootfs, path)
+				files = append(files, name)
+			}
+			return err
+		})
+		expectedFiles := []string{"bin/app", "var/cache/new"}
+		if err != nil || !reflect.DeepEqual(files, expectedFiles) {
+			t.Errorf("image:%s, expected files %v, got %v, error: %v", reference, expectedFiles, files, err)
+		}
+	}
+
+	// both images have the same config, so they share the unpacked rootfs
+	entries, err := os.ReadDir(filepath.Join(StateDir, "unpacked"))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("expected one unpacked image, got %v, error: %v", entries, err)
+	}
+
+	for _, reference := range []string{"app:2.0", "missing", "../app"} {
+		if _, err = loadImage(reference); !errors.Is(err, ErrImageNotFound) && !errors.Is(err, ErrInvalidImage) {
+			t.Errorf("image:%s, expected error(ErrImageNotFound) or error(ErrInvalidImage), got %v", reference, err)
+		}
+	}
+}
+
+func Test_loadImage_modified_layer_expected_ErrInvalidImageBlob(t *testing.T) {
+	// not parallel, because the test replaces StateDir and ImageDir
+	defer func(stateDir, imageDir string) { StateDir, ImageDir = stateDir, imageDir }(StateDir, ImageDir)
+	StateDir, ImageDir = t.TempDir(), t.TempDir()
+
+	imageDir := filepath.Join(ImageDir, "app")
+	writeOCIImage(t, imageDir)
+
+	layer := testImageLayers(t)[0]
+	hash := sha256.Sum256(layer)
+	layerPath := filepath.Join(imageDir, "blobs/sha256", hex.EncodeToString(hash[:]))
+	if err := os.WriteFile(layerPath, testImageLayers(t)[1], 0o644); err != nil {
+		t.Fatalf("could not modify layer: %v", err)
+	}
+
+	if _, err := loadImage("app"); !errors.Is(err, ErrInvalidImageBlob) {
+		t.Errorf("expected error(ErrInvalidImageBlob), got %v", err)
+	}
+}
+
+func Test_Job_getInitConfig_expected_image_defaults(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		config            JobConfig
+		expectedCommand   string
+		expectedArguments []string
+	}{
+		{config: JobConfig{Image: "app"}, expectedCommand: "/bin/app", expectedArguments: []string{"--serve"}},
+		{config: JobConfig{Image: "app", Arguments: []string{"--check"}}, expectedCommand: "/bin/app", expectedArguments: []string{"--check"}},
+		{config: JobConfig{Image: "app", Command: "/bin/sh", Arguments: []string{"-c", "env"}}, expectedCommand: "/bin/sh", expectedArguments: []string{"-c", "env"}},
+	}
+
+	for _, testCase := range testCases {
+		job := NewJob(&testCase.config)
+		job.imageConfig = &testImageConfig
+
+		config := job.getInitConfig("", nil, nil)
+		if config.Command != testCase.expectedCommand || !reflect.DeepEqual(config.Arguments, testCase.expectedArguments) {
+			t.Errorf("expected %s %v, got %s %v", testCase.expectedCommand, testCase.expectedArguments, config.Command, config.Arguments)
+		}
+		if !reflect.DeepEqual(config.Env, testImageConfig.Env) || config.WorkingDir != testImageConfig.WorkingDir {
+			t.Errorf("expected env %v in %s, got %v in %s", testImageConfig.Env, testImageConfig.WorkingDir, config.Env, config.WorkingDir)
+		}
+	}
+}
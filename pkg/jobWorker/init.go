@@ -0,0 +1,341 @@
+package jobWorker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	ns "github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker/namespaces"
+	"golang.org/x/net/bpf"
+	"golang.org/x/sys/unix"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+)
+
+var (
+	ErrInitConfigMissing = errors.New("init config missing")
+)
+
+const (
+	// initCommand is the hidden subcommand Job.Start runs the current binary with to start the init shim
+	// inside the job's new namespaces
+	initCommand = "jobworker-init"
+	// initConfigFd is the file descriptor the init shim reads initConfig from, the first of exec.Cmd ExtraFiles
+	initConfigFd = 3
+	// defaultPath is the PATH of jobs whose environment doesn't set one, same as Docker's
+	defaultPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+	// initErrorExitCode is the exit code of the init shim if it fails to prepare or exec the job's command,
+	// same as the shell's exit code for a command not found
+	initErrorExitCode = 127
+)
+
+// initConfig is passed by Job.Start to the init shim through a pipe
+type initConfig struct {
+	Command   string
+	Arguments []string
+	Hostname  string
+	// DomainName is the NIS domain name, empty to clear the one inherited from the host
+	DomainName string
+	// RootFS is the directory to pivot into, empty to keep the host's root filesystem
+	RootFS string
+	// Overlay is mounted at RootFS before pivoting into it, if not nil
+	Overlay *ns.Overlay
+	// Volumes are mounted below RootFS before pivoting into it
+	Volumes []ns.Volume
+	// ShmSizeBytes is the size of the job's /dev/shm tmpfs
+	ShmSizeBytes int64
+	// DelegateCGroup mounts /sys/fs/cgroup writable, so that the job can manage its delegated cgroup subtree
+	DelegateCGroup bool
+	// Env are KEY=VALUE variables set in the environment of the command, later ones override earlier ones
+	Env []string
+	// ClearEnv starts the command with an empty environment with defaultPath instead of the init shim's one
+	ClearEnv bool
+	// WorkingDir is the working directory of the command, created if it doesn't exist in RootFS
+	WorkingDir string
+	// Credential are the user, group and supplementary group IDs the command runs as, root with the init shim's
+	// groups if nil
+	Credential *syscall.Credential
+	// SeccompFilter is loaded right before the command is executed, if not empty
+	SeccompFilter []bpf.RawInstruction
+	// Capabilities are the only capabilities the command runs with and can ever gain
+	Capabilities []string
+	// NoNewPrivs sets no_new_privs before the seccomp filter is loaded
+	NoNewPrivs bool
+	// Rlimits are the resource limits of the command by normalized name
+	Rlimits map[string]ns.Rlimit
+	// Loopback brings the loopback interface up
+	Loopback bool
+	// Interface is moved into the job's network namespace by Job.Start and configured as jobInterfaceName, if not nil
+	Interface *ns.NetworkInterface
+}
+
+// Init runs the init shim and never returns, if the current process has been started by Job.Start.
+// Otherwise, Init returns immediately.
+//
+// Job.Start runs the current binary again with a hidden subcommand as PID 1 of the job's new namespaces, so every
+// binary starting jobs must call Init at the very beginning of main (or TestMain for tests), before parsing flags.
+// The init shim mounts a private /proc, pivots into the job's root filesystem if any, sets the hostname and execs
+// the job's command, so the command replaces the shim as PID 1 and the host mount table is never touched.
+func Init() {
+	if len(os.Args) < 2 || os.Args[1] != initCommand {
+		return
+	}
+
+	// the shim changes the state of the calling thread, which must be the one that execs the command
+	runtime.LockOSThread()
+
+	if err := runInit(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "jobworker init: %s\n", err)
+		os.Exit(initErrorExitCode)
+	}
+}
+
+func runInit() error {
+	config, err := readInitConfig()
+	if err != nil {
+		return err
+	}
+
+	// stop mounts of the job from propagating back to the host, even if / is a shared mount
+	if err = syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("error making mounts private: %w", err)
+	}
+
+	if config.Overlay != nil {
+		if err = ns.MountOverlay(config.RootFS, config.Overlay); err != nil {
+			return err
+		}
+	}
+
+	if config.RootFS != "" {
+		if err = mountRootFS(config.RootFS, config.ShmSizeBytes, config.DelegateCGroup, config.Volumes); err != nil {
+			return err
+		}
+	} else {
+		// mount a new proc filesystem so that commands such as `ps -ef` only see processes of the job's PID namespace
+		if err = ns.MountProc("/"); err != nil {
+			return err
+		}
+		// hide the host's shared memory, which belongs to the host's IPC namespace
+		if err = ns.MountShm("/", config.ShmSizeBytes); err != nil {
+			return err
+		}
+		// hide the host's cgroup hierarchy, which shows the cgroups of other jobs
+		if err = ns.MountCGroup("/", config.DelegateCGroup); err != nil {
+			return err
+		}
+		if err = mountVolumes("/", config.Volumes); err != nil {
+			return err
+		}
+	}
+
+	if err = syscall.Sethostname([]byte(config.Hostname)); err != nil {
+		return fmt.Errorf("error setting hostname: %w", err)
+	}
+	if err = syscall.Setdomainname([]byte(config.DomainName)); err != nil {
+		return fmt.Errorf("error setting domain name: %w", err)
+	}
+
+	if config.Loopback {
+		if err = ns.SetupLoopback(); err != nil {
+			return err
+		}
+	}
+	if config.Interface != nil {
+		if err = config.Interface.Setup(jobInterfaceName); err != nil {
+			return err
+		}
+	}
+
+	if config.WorkingDir != "" {
+		// never create directories in the host's root filesystem
+		if config.RootFS != "" {
+			if err = os.MkdirAll(config.WorkingDir, 0o755); err != nil {
+				return fmt.Errorf("error creating working directory: %w", err)
+			}
+		}
+		if err = os.Chdir(config.WorkingDir); err != nil {
+			return fmt.Errorf("error changing working directory: %w", err)
+		}
+	}
+
+	// the command is looked up in the PATH of its own environment
+	if err = setEnv(config.Env, config.ClearEnv); err != nil {
+		return err
+	}
+
+	// resource limits are set while the shim still has CAP_SYS_RESOURCE, which doesn't allow raising hard limits
+	// above the current process' ones though, because it is checked in the initial user namespace
+	if err = ns.SetRlimits(config.Rlimits); err != nil {
+		return err
+	}
+
+	if config.NoNewPrivs {
+		if err = ns.SetNoNewPrivs(); err != nil {
+			return err
+		}
+	}
+
+	// the filter is loaded while the shim still has CAP_SYS_ADMIN in the user namespace, which is required
+	// without no_new_privs, so filters must allow prctl, capset, setgroups, setgid, setuid and execve
+	if err = ns.LoadSeccompFilter(config.SeccompFilter); err != nil {
+		return err
+	}
+
+	// the credentials and capabilities are changed last, because preparing the namespaces needs root
+	// of the user namespace with all capabilities
+	if err = ns.DropBoundingCapabilities(config.Capabilities); err != nil {
+		return err
+	}
+	if config.Credential != nil {
+		if err = setCredential(config.Credential); err != nil {
+			return err
+		}
+	}
+	if err = ns.SetCapabilities(config.Capabilities); err != nil {
+		return err
+	}
+
+	path, err := exec.LookPath(config.Command)
+	if err != nil {
+		return fmt.Errorf("error looking up command: %w", err)
+	}
+
+	if err = syscall.Exec(path, append([]string{config.Command}, config.Arguments...), os.Environ()); err != nil {
+		return fmt.Errorf("error executing command %s: %w", path, err)
+	}
+	return nil
+}
+
+// mountRootFS switches the root filesystem to rootfs with minimal /dev, /dev/shm, /proc, /sys, /sys/fs/cgroup and /tmp
+// mounts and volumes
+func mountRootFS(rootfs string, shmSizeBytes int64, delegateCGroup bool, volumes []ns.Volume) error {
+	// host devices are no longer reachable and proc and sysfs can't be mounted in a user namespace after pivot_root
+	if err := ns.MountDev(rootfs); err != nil {
+		return err
+	}
+	if err := ns.MountShm(rootfs, shmSizeBytes); err != nil {
+		return err
+	}
+	if err := ns.MountProc(rootfs); err != nil {
+		return err
+	}
+	if err := ns.MountSys(rootfs); err != nil {
+		return err
+	}
+	if err := ns.MountCGroup(rootfs, delegateCGroup); err != nil {
+		return err
+	}
+	if err := ns.MountTmp(rootfs); err != nil {
+		return err
+	}
+	if err := mountVolumes(rootfs, volumes); err != nil {
+		return err
+	}
+
+	if err := ns.PivotRoot(rootfs); err != nil {
+		return fmt.Errorf("error changing root filesystem: %w", err)
+	}
+	return nil
+}
+
+// setEnv sets env in the environment of the init shim, which is cleared first with PATH set to defaultPath
+// unless env sets it, if clear is true
+func setEnv(env []string, clear bool) error {
+	if clear {
+		os.Clearenv()
+		if err := os.Setenv("PATH", defaultPath); err != nil {
+			return fmt.Errorf("error setting environment: %w", err)
+		}
+	}
+
+	for _, variable := range env {
+		key, value, _ := strings.Cut(variable, "=")
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("error setting environment variable %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// setCredential changes the supplementary groups, GID and UID of the init shim in this order, so that the
+// shim still has the privileges to change the groups and GID. The permitted capabilities are kept, so that
+// the ones of the job can be set afterwards.
+func setCredential(credential *syscall.Credential) error {
+	if err := unix.Prctl(unix.PR_SET_KEEPCAPS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("error keeping capabilities: %w", err)
+	}
+
+	groups := make([]int, len(credential.Groups))
+	for i, group := range credential.Groups {
+		groups[i] = int(group)
+	}
+
+	if err := syscall.Setgroups(groups); err != nil {
+		return fmt.Errorf("error setting groups: %w", err)
+	}
+	if err := syscall.Setgid(int(credential.Gid)); err != nil {
+		return fmt.Errorf("error setting GID: %w", err)
+	}
+	if err := syscall.Setuid(int(credential.Uid)); err != nil {
+		return fmt.Errorf("error setting UID: %w", err)
+	}
+	return nil
+}
+
+func mountVolumes(rootfs string, volumes []ns.Volume) error {
+	for _, volume := range volumes {
+		if err := ns.MountVolume(rootfs, &volume); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readInitConfig() (*initConfig, error) {
+	file := os.NewFile(initConfigFd, "init-config")
+	if file == nil {
+		return nil, ErrInitConfigMissing
+	}
+	defer file.Close()
+
+	config := &initConfig{}
+	if err := json.NewDecoder(file).Decode(config); err != nil {
+		return nil, fmt.Errorf("error reading init config: %w", err)
+	}
+	return config, nil
+}
+
+// newInitCommand returns a command running the current binary as the init shim
+func newInitCommand() *exec.Cmd {
+	return exec.Command("/proc/self/exe", initCommand)
+}
+
+// addInitConfigPipe passes the read end of a new pipe to the init command and returns the write end
+// to send initConfig with once the command has started
+func addInitConfigPipe(cmd *exec.Cmd) (*os.File, error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("error creating init config pipe: %w", err)
+	}
+
+	cmd.ExtraFiles = []*os.File{reader}
+	return writer, nil
+}
+
+// closeInitConfigPipe closes both ends of the init config pipe, the started init shim holds its own copy of the read end
+func closeInitConfigPipe(cmd *exec.Cmd, writer *os.File) {
+	for _, file := range cmd.ExtraFiles {
+		_ = file.Close()
+	}
+	_ = writer.Close()
+}
+
+// writeInitConfig sends config to the started init shim and closes the pipe
+func writeInitConfig(cmd *exec.Cmd, writer *os.File, config *initConfig) error {
+	defer closeInitConfigPipe(cmd, writer)
+
+	return json.NewEncoder(writer).Encode(config)
+}
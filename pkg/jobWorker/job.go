@@ -1,15 +1,19 @@
 package jobWorker
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	ns "github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker/namespaces"
 	"github.com/google/uuid"
+	"golang.org/x/net/bpf"
 	"io"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -21,10 +25,28 @@ var (
 	ErrJobAlreadyStarted       = errors.New("job already started")
 	ErrJobNotStarted           = errors.New("job not started")
 	ErrInvalidCommand          = errors.New("command must be provided")
-	ErrInvalidCPU              = errors.New("CPU must be greater than 0")
+	ErrInvalidCPU              = errors.New("CPU must be greater than 0 and not greater than the number of CPUs of the host")
 	ErrInvalidIOBytesPerSecond = errors.New("IOBytesPerSecond must be greater than 0")
 	ErrInvalidMemBytes         = errors.New("MemBytes must be greater than 0")
 	ErrJobAlreadyStopped       = errors.New("Job already stopped")
+	ErrInvalidStopGracePeriod  = errors.New("StopGracePeriod must not be negative")
+	ErrInvalidIOLimit          = errors.New("Read/Write IO limits must not be negative")
+	ErrInvalidMemMaxBytes      = errors.New("MemMaxBytes must not be negative or less than MemBytes")
+	ErrInvalidSwapMaxBytes     = errors.New("SwapMaxBytes must be -1 or greater")
+	ErrOOMKilled               = errors.New("job killed by OOM killer")
+	ErrCPULimitExceeded        = errors.New("job killed after exceeding RLIMIT_CPU")
+	ErrInvalidCPUWeight        = errors.New("CPUWeight must be between 1 and 10000")
+	ErrInvalidCPUSet           = errors.New("CPUSetCPUs and CPUSetMems must be a list of numbers or ranges, such as 0-3,6")
+	ErrInvalidMaxProcesses     = errors.New("MaxProcesses must be 0 or at least 16")
+	ErrUsageNotAvailable       = errors.New("job resource usage is not available")
+	ErrInvalidEnv              = errors.New("Env names must not be empty or contain '=' and Env must not contain NUL characters")
+	ErrInvalidWorkingDir       = errors.New("WorkingDir must be an absolute path")
+	ErrInvalidShmSizeBytes     = errors.New("ShmSizeBytes must not be negative or greater than MemMaxBytes or MemBytes")
+)
+
+const (
+	// pressurePollTimeout is how often pressure watchers check whether the job has exited
+	pressurePollTimeout = 100 * time.Millisecond
 )
 
 type State string
@@ -32,12 +54,27 @@ type State string
 const (
 	JobStatusNotStarted State = "NotStarted"
 	JobStatusRunning    State = "Running"
+	JobStatusStopping   State = "Stopping"
 	JobStatusCompleted  State = "Completed"
 	JobStatusTerminated State = "Terminated"
 )
 
 const (
-	stopGracePeriod = 10 * time.Second
+	// defaultStopGracePeriod is used when neither JobConfig nor Stop caller provide a grace period
+	defaultStopGracePeriod = 10 * time.Second
+	// cpuMaxPeriod is the cpu.max period in microseconds, the job can run CPU*cpuMaxPeriod microseconds each period
+	cpuMaxPeriod = 100_000
+	// cpuMaxMinQuota is the smallest cpu.max quota in microseconds accepted by the kernel
+	cpuMaxMinQuota = 1_000
+	// rlimitCPU is the normalized name of the resource limit of CPU seconds
+	rlimitCPU = "RLIMIT_CPU"
+	// cgroupEmptyTimeout is how long cleanup waits for killed processes to leave the job's cgroup
+	cgroupEmptyTimeout = 5 * time.Second
+	// defaultShmSizeBytes is the size of the job's /dev/shm unless the job's memory limit is lower, same as Docker's
+	defaultShmSizeBytes = 64 << 20
+	// minMaxProcesses is the lowest MaxProcesses, the threads of the Go runtime of the init shim count against
+	// pids.max until it execs the job's command, so a lower limit can make the init shim fail to start
+	minMaxProcesses = 16
 )
 
 // JobStatus represent current status of the Job
@@ -47,39 +84,258 @@ type JobStatus struct {
 	ExitCode int
 	// ExitReason is the reason the job has errored if it has errored during execution or cleanup.
 	ExitReason string
+	// OOMKilled is true if any process of the job has been killed by the OOM killer after reaching MemMaxBytes.
+	OOMKilled bool
+	// CPULimitExceeded is true if the command has been killed by the kernel after exceeding its RLIMIT_CPU.
+	CPULimitExceeded bool
+	// PidsCurrent is the number of processes the job is running, 0 once the job has exited.
+	PidsCurrent int64
+	// PidsPeak is the highest number of processes the job has run at once.
+	PidsPeak int64
+	// PidsLimitReached is true if the job failed to fork because MaxProcesses was reached.
+	PidsLimitReached bool
+	// CPUTime is the total CPU time used by all processes of the job.
+	CPUTime time.Duration
+	// MemoryPeakBytes is the highest memory usage of the job.
+	MemoryPeakBytes int64
+	// IPAddress is the address of the job on the bridge, empty unless the job's NetworkMode is bridge.
+	IPAddress string
 }
 
-// JobConfig represent job configuration settings (all fields are required)
+// JobConfig represent job configuration settings (all fields are required unless marked optional)
 type JobConfig struct {
 	// CPU is the number of CPU cores to limit the job to such as 0.5 for half a CPU core.
+	// The limit is an absolute quota (cpu.max), so the job is capped even on an idle host. It can't be greater than
+	// the number of CPUs of the host, which the job could never use.
 	CPU float64
+	// CPUWeight is the relative share of CPU time between 1 and 10000 when CPUs are contended (optional, 100 by default).
+	CPUWeight uint64
+	// CPUSetCPUs pins the job to the given CPU cores, such as "0-3,6" (optional).
+	CPUSetCPUs string
+	// CPUSetMems pins the job to the given NUMA memory nodes, such as "0" (optional).
+	CPUSetMems string
+	// MaxProcesses is the maximum number of processes and threads the job can run at once, at least 16 as the threads
+	// of the init shim count against it until the command is started (optional).
+	MaxProcesses int64
 	// MemBytes is the number of bytes to limit the job to use, such as 1_000_000_000 for 1 GB.
+	// The job is throttled and reclaimed above the limit, but not killed.
 	MemBytes int64
+	// MemMaxBytes is the hard limit of memory, processes of the job are OOM killed above it (optional).
+	MemMaxBytes int64
+	// SwapMaxBytes is the number of bytes of swap the job can use (optional, -1 disables swap).
+	SwapMaxBytes int64
+	// ShmSizeBytes is the size of the job's /dev/shm tmpfs, which counts against the job's memory and must not be
+	// greater than MemMaxBytes or MemBytes (optional, 64 MB or the memory limit if lower by default).
+	ShmSizeBytes int64
 	// IOBytesPerSecond is the number of bytes per second to limit the job to read/write on the
+	// device IODevicePath is mounted on.
 	IOBytesPerSecond int64
-	// Command is the command to run.
+	// ReadBytesPerSecond overrides IOBytesPerSecond for reads (optional).
+	ReadBytesPerSecond int64
+	// WriteBytesPerSecond overrides IOBytesPerSecond for writes (optional).
+	WriteBytesPerSecond int64
+	// ReadIOPerSecond is the number of read operations per second to limit the job to (optional).
+	ReadIOPerSecond int64
+	// WriteIOPerSecond is the number of write operations per second to limit the job to (optional).
+	WriteIOPerSecond int64
+	// IODevicePath is a path on the block device the IO limits are applied to, such as "/data" (optional, the device of
+	// the job's unpacked RootFS, Image or overlay directory in StateDir, or "/" if the job runs on the host's root
+	// filesystem, by default). IO limits are skipped, if the path is not backed by a block device, such as tmpfs.
+	IODevicePath string
+	// Command is the command to run, the image's Entrypoint and Cmd are used if empty and Image is set.
 	Command string
-	// Arguments are the arguments to pass to the command, if any.
+	// Arguments are the arguments to pass to the command, if any. Arguments replace the image's Cmd if Command is empty.
 	Arguments []string
+	// RootFS is an absolute path to a directory or a tar (optionally gzip compressed) tarball to run the job in as
+	// its root filesystem with minimal /dev, /proc, /sys and /tmp mounts (optional, the host's root filesystem by default).
+	// A directory is always the read-only lower layer of an overlay filesystem with a per-job writable upper layer,
+	// so it is never changed. A tarball is unpacked per job into StateDir and removed once the job has completed.
+	RootFS string
+	// RootFSOverlay mounts a RootFS tarball as the read-only lower layer of an overlay filesystem with a per-job
+	// writable upper layer, so jobs share RootFS without copying it. The tarball is unpacked once into
+	// StateDir and shared by all jobs using it (optional).
+	RootFSOverlay bool
+	// KeepRootFSChanges keeps the overlay upper layer once the job has completed, so the changes of the job
+	// can be exported with ExportRootFSChanges until RemoveRootFSChanges is called or RootFSChangesTTL has passed
+	// (optional, the upper layer is discarded by default).
+	KeepRootFSChanges bool
+	// Image is the name of an OCI image layout or `docker save` tarball in ImageDir, optionally followed by :tag,
+	// to run the job in. The image is unpacked once and used as read-only lower layer of an overlay, its Env and
+	// WorkingDir are used for the job (optional, can't be used with RootFS).
+	Image string
+	// Volumes are bind mounts, tmpfs mounts and read-only remounts set up in the job's mount namespace
+	// in the given order (optional). Without RootFS or Image the targets must exist on the host and must not
+	// be reached through symlinks, because the host's root filesystem is never changed.
+	Volumes []ns.Volume
+	// Env are environment variables of the command, they override the image's Env (optional).
+	Env map[string]string
+	// ClearEnv starts the command with an empty environment with a default PATH instead of the environment
+	// of the current process (optional, true by default).
+	ClearEnv *bool
+	// WorkingDir is the absolute working directory of the command, created in the job's root filesystem if
+	// it doesn't exist (optional, the image's WorkingDir or the working directory of the current process by default).
+	WorkingDir string
+	// UID and GID are the user and group IDs the command runs as in the job's user namespace, IDs other than 0
+	// must be in the subordinate ID ranges of the current user in SubUIDFile and SubGIDFile (optional, 0 by default).
+	UID uint32
+	GID uint32
+	// Groups are the supplementary group IDs of the command (optional, none if UID or GID is set).
+	Groups []uint32
+	// SeccompProfile is the seccomp profile filtering the syscalls of the job, SeccompProfileDefault,
+	// SeccompProfilePermissive or the name of a profile in Docker's JSON format in SeccompProfileDir without
+	// the .json extension (optional, SeccompProfileDefault by default).
+	SeccompProfile string
+	// AddCapabilities are the Linux capabilities of the job's user namespace the command runs with, such as
+	// CAP_NET_BIND_SERVICE, or ALL (optional, none by default).
+	AddCapabilities []string
+	// DropCapabilities removes capabilities from AddCapabilities, such as CAP_SYS_ADMIN after ALL (optional).
+	DropCapabilities []string
+	// NoNewPrivs sets no_new_privs, so that setuid binaries and file capabilities can't raise the privileges
+	// of the command (optional, true by default).
+	NoNewPrivs *bool
+	// Rlimits are the POSIX resource limits of the command, such as RLIMIT_NOFILE or RLIMIT_CPU in seconds, by name
+	// with or without the RLIMIT_ prefix, the limits of the current process by default (optional).
+	Rlimits map[string]ns.Rlimit
+	// NetworkMode is the network of the job's network namespace, ns.NetworkNone without any interface up,
+	// ns.NetworkLoopback with only the loopback interface up or ns.NetworkBridge connected to BridgeName with an address
+	// of BridgeSubnet (optional, ns.NetworkNone by default).
+	NetworkMode string
+	// PortMappings forward ports of the host to ports of the job, NetworkMode must be ns.NetworkBridge (optional).
+	PortMappings []ns.PortMapping
+	// NetworkIngressBytesPerSecond limits the traffic the job receives, NetworkMode must be ns.NetworkBridge (optional).
+	NetworkIngressBytesPerSecond int64
+	// NetworkEgressBytesPerSecond limits the traffic the job sends, NetworkMode must be ns.NetworkBridge (optional).
+	NetworkEgressBytesPerSecond int64
+	// Hostname is the hostname of the job's UTS namespace (optional, a short form of the job's UUID by default).
+	Hostname string
+	// DomainName is the NIS domain name of the job's UTS namespace, the job's hostname resolves to
+	// Hostname.DomainName in its hosts file (optional).
+	DomainName string
+	// DelegateCGroup makes the job's user the owner of the job's cgroup, so that the job can create child cgroups
+	// below /sys/fs/cgroup and move its processes between them. The job's own limits remain read-only, which requires
+	// cgroup2 to be mounted with nsdelegate (optional).
+	DelegateCGroup bool
+	// StopGracePeriod is the time between SIGTERM and SIGKILL when the job is stopped (optional, 10 seconds by default).
+	StopGracePeriod time.Duration
+	// PressureTriggers write a line into the job's output every time the job is stalled on a resource
+	// longer than the trigger's threshold (optional).
+	PressureTriggers []ns.PressureTrigger
 }
 
 func (jobConfig *JobConfig) isValid() error {
-	if jobConfig.Command == "" {
+	if jobConfig.Command == "" && jobConfig.Image == "" {
 		return ErrInvalidCommand
 	}
 
-	if jobConfig.CPU <= 0 {
+	if jobConfig.RootFS != "" && !filepath.IsAbs(jobConfig.RootFS) {
+		return ErrInvalidRootFS
+	}
+
+	if jobConfig.Image != "" {
+		if _, _, err := parseImageReference(jobConfig.Image); err != nil || jobConfig.RootFS != "" {
+			return ErrInvalidImage
+		}
+	}
+
+	if (jobConfig.RootFSOverlay && jobConfig.RootFS == "") ||
+		(jobConfig.KeepRootFSChanges && !jobConfig.RootFSOverlay && jobConfig.Image == "") {
+		return ErrInvalidRootFSOverlay
+	}
+
+	for name, value := range jobConfig.Env {
+		if name == "" || strings.ContainsAny(name, "=\x00") || strings.ContainsRune(value, 0) {
+			return ErrInvalidEnv
+		}
+	}
+
+	if jobConfig.WorkingDir != "" && !filepath.IsAbs(jobConfig.WorkingDir) {
+		return ErrInvalidWorkingDir
+	}
+
+	if err := jobConfig.isValidHostname(); err != nil {
+		return err
+	}
+
+	if _, err := ns.GetCapabilities(jobConfig.AddCapabilities, jobConfig.DropCapabilities); err != nil {
+		return err
+	}
+
+	if _, err := ns.NormalizeRlimits(jobConfig.Rlimits); err != nil {
+		return err
+	}
+
+	if err := ns.IsValidNetworkMode(jobConfig.NetworkMode); err != nil {
+		return err
+	}
+
+	if err := jobConfig.isValidPortMappings(); err != nil {
+		return err
+	}
+
+	if err := jobConfig.isValidNetworkLimits(); err != nil {
+		return err
+	}
+
+	// NaN fails every comparison, and a quota of more CPUs than the host has would overflow cpu.max
+	if !(jobConfig.CPU > 0) || jobConfig.CPU > float64(runtime.NumCPU()) {
 		return ErrInvalidCPU
 	}
 
+	if jobConfig.CPUWeight > 10_000 {
+		return ErrInvalidCPUWeight
+	}
+
+	for _, cpuSet := range []string{jobConfig.CPUSetCPUs, jobConfig.CPUSetMems} {
+		if cpuSet != "" && !cpuSetRegexp.MatchString(cpuSet) {
+			return ErrInvalidCPUSet
+		}
+	}
+
+	if jobConfig.MaxProcesses < 0 || (jobConfig.MaxProcesses > 0 && jobConfig.MaxProcesses < minMaxProcesses) {
+		return ErrInvalidMaxProcesses
+	}
+
 	if jobConfig.IOBytesPerSecond <= 0 {
 		return ErrInvalidIOBytesPerSecond
 	}
 
+	if jobConfig.ReadBytesPerSecond < 0 || jobConfig.WriteBytesPerSecond < 0 ||
+		jobConfig.ReadIOPerSecond < 0 || jobConfig.WriteIOPerSecond < 0 {
+		return ErrInvalidIOLimit
+	}
+
 	if jobConfig.MemBytes <= 0 {
 		return ErrInvalidMemBytes
 	}
 
+	if jobConfig.MemMaxBytes < 0 || (jobConfig.MemMaxBytes > 0 && jobConfig.MemMaxBytes < jobConfig.MemBytes) {
+		return ErrInvalidMemMaxBytes
+	}
+
+	if jobConfig.SwapMaxBytes < -1 {
+		return ErrInvalidSwapMaxBytes
+	}
+
+	if jobConfig.ShmSizeBytes < 0 || jobConfig.ShmSizeBytes > jobConfig.getMemoryLimit() {
+		return ErrInvalidShmSizeBytes
+	}
+
+	if jobConfig.StopGracePeriod < 0 {
+		return ErrInvalidStopGracePeriod
+	}
+
+	for _, volume := range jobConfig.Volumes {
+		if err := volume.IsValid(); err != nil {
+			return err
+		}
+	}
+
+	for _, trigger := range jobConfig.PressureTriggers {
+		if err := trigger.IsValid(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -96,8 +352,46 @@ type Job struct {
 	isStarted bool
 	// isCompleted is true if the job has been successfully completed
 	isCompleted bool
-	// isTerminated is true if the job has been terminated via Stop()
+	// imageConfig holds the configuration of the job's image once the image has been unpacked
+	// 				and has `nil` if the job doesn't run in an image
+	imageConfig *ImageConfig
+	// isRootFSPrepared is true once RootFS or Image has been unpacked into rootfs and overlay by PrepareRootFS or Start
+	isRootFSPrepared bool
+	rootfs           string
+	overlay          *ns.Overlay
+	// layers are the unpacked directories shared with other jobs the job uses, released once its state is removed
+	layers []string
+	// hasRootFSChanges is true from the job's completion until its kept overlay upper layer is removed by
+	// 				RemoveRootFSChanges or once RootFSChangesTTL has passed
+	hasRootFSChanges bool
+	// rootFSChangesTimer removes the kept overlay upper layer once RootFSChangesTTL has passed
+	rootFSChangesTimer *time.Timer
+	// rootFSChangesLock is read-locked by every running export of the kept overlay upper layer, so that
+	// 				RemoveRootFSChanges and the TTL wait for exports to finish before removing it
+	rootFSChangesLock sync.RWMutex
+	// usage holds the final resource usage of the job recorded before its cgroup was deleted
+	// 				and has `nil` until the job has completed running
+	usage *ns.Usage
+	// network holds the job's veth pair and address while the job runs
+	// 				and has `nil` unless the job's NetworkMode is bridge
+	network *jobNetwork
+	// hostPorts are the host ports of PortMappings reserved for the job from its start until it has completed
+	hostPorts []string
+	// ipAddress is the address of the job on the bridge, empty unless the job's NetworkMode is bridge
+	ipAddress string
+	// isOOMKilled is true if any process of the job has been killed by the OOM killer
+	isOOMKilled bool
+	// isCPULimitExceeded is true if the command has been killed by the kernel after exceeding its RLIMIT_CPU
+	isCPULimitExceeded bool
+	// isTerminated is true if the job has been terminated via Stop(), the process may still be shutting down
+	// 				until isCompleted is true
 	isTerminated bool
+	// done is closed once the process has exited and the job has been cleaned up
+	done chan struct{}
+	// stopPressureWatchers is closed once the process has exited to stop goroutines watching pressure triggers
+	stopPressureWatchers chan struct{}
+	// pressureWatchers waits for goroutines watching pressure triggers to stop before the cgroup is deleted
+	pressureWatchers sync.WaitGroup
 	// exitReason is the reason the job has errored if it has errored during execution or cleanup
 	exitReason error
 	// ExitCode returns the exit code of the exited process, or -1
@@ -105,10 +399,169 @@ type Job struct {
 	exitCode int
 }
 
+// cpuSetRegexp matches the cpuset list format, such as "0-3,6"
+var cpuSetRegexp = regexp.MustCompile(`^\d+(-\d+)?(,\d+(-\d+)?)*$`)
+
+// getCPUMax returns cpu.max quota and period in microseconds for the number of CPU cores, such as "50000 100000"
+func (jobConfig *JobConfig) getCPUMax() string {
+	return getCPUMax(jobConfig.CPU)
+}
+
+// getCPUMax returns cpu.max of cpu cores, the quota is raised to cpuMaxMinQuota for tiny fractions of a core
+func getCPUMax(cpu float64) string {
+	quota := max(int64(cpu*cpuMaxPeriod), cpuMaxMinQuota)
+	return fmt.Sprintf("%d %d", quota, cpuMaxPeriod)
+}
+
+// getMemoryLimit returns the hard memory limit of the job, MemMaxBytes or MemBytes if not set
+func (jobConfig *JobConfig) getMemoryLimit() int64 {
+	if jobConfig.MemMaxBytes > 0 {
+		return jobConfig.MemMaxBytes
+	}
+	return jobConfig.MemBytes
+}
+
+// getShmSizeBytes returns the size of the job's /dev/shm, ShmSizeBytes or defaultShmSizeBytes capped at the memory limit
+func (jobConfig *JobConfig) getShmSizeBytes() int64 {
+	if jobConfig.ShmSizeBytes > 0 {
+		return jobConfig.ShmSizeBytes
+	}
+	return min(defaultShmSizeBytes, jobConfig.getMemoryLimit())
+}
+
+// getIOMax returns io.max limits for the device mounted on IODevicePath, such as "8:0 rbps=1000000 wbps=1000000",
+// or an empty string if the path is not backed by a block device io.max can limit.
+// The root filesystem of the job must have been prepared, as its directory is the default IODevicePath.
+func (job *Job) getIOMax() (string, error) {
+	jobConfig := job.config
+	devicePath := jobConfig.IODevicePath
+	if devicePath == "" {
+		devicePath = "/"
+		if job.rootfs != "" {
+			devicePath = job.rootfs
+		}
+	}
+
+	device, err := ns.GetBlockDevice(devicePath)
+	if errors.Is(err, ns.ErrNotBlockDevice) {
+		log.Printf("skip IO limits of job:%s, %v", job, err)
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	readBytesPerSecond := jobConfig.IOBytesPerSecond
+	if jobConfig.ReadBytesPerSecond > 0 {
+		readBytesPerSecond = jobConfig.ReadBytesPerSecond
+	}
+	writeBytesPerSecond := jobConfig.IOBytesPerSecond
+	if jobConfig.WriteBytesPerSecond > 0 {
+		writeBytesPerSecond = jobConfig.WriteBytesPerSecond
+	}
+
+	ioMax := fmt.Sprintf("%s rbps=%d wbps=%d", device, readBytesPerSecond, writeBytesPerSecond)
+	if jobConfig.ReadIOPerSecond > 0 {
+		ioMax += fmt.Sprintf(" riops=%d", jobConfig.ReadIOPerSecond)
+	}
+	if jobConfig.WriteIOPerSecond > 0 {
+		ioMax += fmt.Sprintf(" wiops=%d", jobConfig.WriteIOPerSecond)
+	}
+	return ioMax, nil
+}
+
 func (job *Job) getCGroupName() string {
 	return strings.Replace(job.UUID.String(), "-", "", -1)
 }
 
+// getShortID returns a short form of the job's UUID
+func (job *Job) getShortID() string {
+	return job.getCGroupName()[:12]
+}
+
+// getHostname returns the job's hostname - Hostname or a short form of the job's UUID
+func (job *Job) getHostname() string {
+	if job.config.Hostname != "" {
+		return job.config.Hostname
+	}
+	return job.getShortID()
+}
+
+// delegateCGroup makes the host IDs the job's UID and GID are mapped to the owners of the job's cgroup
+func (job *Job) delegateCGroup(uidMappings []syscall.SysProcIDMap, gidMappings []syscall.SysProcIDMap) error {
+	if err := ns.CheckCGroupDelegation(); err != nil {
+		return err
+	}
+
+	uid, _ := ns.GetHostID(uidMappings, job.config.UID)
+	gid, _ := ns.GetHostID(gidMappings, job.config.GID)
+	if err := ns.DelegateCGroup(job.getCGroupName(), uid, gid); err != nil {
+		return fmt.Errorf("error delegating cgroup: %w", err)
+	}
+	return nil
+}
+
+func (job *Job) getInitConfig(rootfs string, overlay *ns.Overlay, seccompFilter []bpf.RawInstruction) *initConfig {
+	config := &initConfig{
+		Command:    job.config.Command,
+		Arguments:  job.config.Arguments,
+		Hostname:   job.getHostname(),
+		DomainName: job.config.DomainName,
+		RootFS:     rootfs,
+		Overlay:    overlay,
+		Volumes:    job.config.Volumes,
+		ClearEnv:   job.config.ClearEnv == nil || *job.config.ClearEnv,
+
+		SeccompFilter: seccompFilter,
+		Capabilities:  job.getCapabilities(),
+		NoNewPrivs:    job.config.NoNewPrivs == nil || *job.config.NoNewPrivs,
+		Rlimits:       job.getRlimits(),
+		Loopback:      job.config.getNetworkMode() != ns.NetworkNone,
+		ShmSizeBytes:  job.config.getShmSizeBytes(),
+
+		DelegateCGroup: job.config.DelegateCGroup,
+	}
+	if job.config.hasCredentials() {
+		config.Credential = &syscall.Credential{Uid: job.config.UID, Gid: job.config.GID, Groups: job.config.Groups}
+	}
+
+	if job.imageConfig != nil {
+		if job.config.Command == "" {
+			command := job.imageConfig.getCommand(job.config.Arguments)
+			config.Command, config.Arguments = command[0], command[1:]
+		}
+		config.Env = append(config.Env, job.imageConfig.Env...)
+		config.WorkingDir = job.imageConfig.WorkingDir
+	}
+
+	// variables of the job are set after the image's, so they override them
+	names := make([]string, 0, len(job.config.Env))
+	for name := range job.config.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		config.Env = append(config.Env, name+"="+job.config.Env[name])
+	}
+
+	if job.config.WorkingDir != "" {
+		config.WorkingDir = job.config.WorkingDir
+	}
+	return config
+}
+
+// getCapabilities returns the capabilities of the job's command, the configuration has been validated already
+func (job *Job) getCapabilities() []string {
+	capabilities, _ := ns.GetCapabilities(job.config.AddCapabilities, job.config.DropCapabilities)
+	return capabilities
+}
+
+// getRlimits returns the resource limits of the job by normalized name, the configuration has been validated already
+func (job *Job) getRlimits() map[string]ns.Rlimit {
+	rlimits, _ := ns.NormalizeRlimits(job.config.Rlimits)
+	return rlimits
+}
+
 func (job *Job) getExitReason() string {
 	if job.exitReason != nil {
 		return job.exitReason.Error()
@@ -127,20 +580,43 @@ func NewJob(config *JobConfig) *Job {
 		config:   config,
 		output:   output,
 		exitCode: -1,
+		done:     make(chan struct{}),
+
+		stopPressureWatchers: make(chan struct{}),
 	}
 	log.Printf("create  %s", job)
 	return job
 }
 
 // Start - starting the Job in a semi-isolated environment (creating new PID, mount and network and also creates a new control group for the process limiting CPU, IO, and memory)
-// The user running Start() should be the root user or have the necessary permissions to create namespaces and control groups
+// The user running Start() should be the root user or have the necessary permissions to create namespaces and control groups.
+// The binary calling Start must call Init at the beginning of main, because the job is started by running the binary again as init shim.
 //
 // ErrJobAlreadyStarted is returned, if the Job has already been started.
 // ErrInvalidCommand, ErrInvalidCPU, ErrInvalidIOBytesPerSecond, ErrInvalidMemBytes is returned, if provided configuration is invalid
-func (job *Job) Start() error {
+// ErrIDNotMapped is returned, if UID, GID or Groups are not in the subordinate ID ranges of the current user
+// ns.ErrInvalidSeccompProfile is returned, if SeccompProfile can't be assembled
+// ns.ErrInvalidCapability is returned, if AddCapabilities or DropCapabilities contain unknown capabilities
+// ErrPortAllocated or ErrHostPortInUse is returned, if a host port of PortMappings is forwarded to another job or in use
+func (job *Job) Start() (err error) {
 	job.mutex.Lock()
 	defer job.mutex.Unlock()
 
+	// the root filesystem may have been prepared by PrepareRootFS already, it is removed and the reserved host ports
+	// are released, if the job doesn't start
+	defer func() {
+		if err == nil || job.isStarted {
+			return
+		}
+		job.releaseHostPorts()
+		if job.isRootFSPrepared {
+			if removeErr := job.removeState(false); removeErr != nil {
+				log.Printf("error removing job state: %s\n", removeErr)
+			}
+			job.isRootFSPrepared = false
+		}
+	}()
+
 	// validate configuration
 	log.Printf("validate job:%s", job)
 	if err := job.config.isValid(); err != nil {
@@ -153,45 +629,61 @@ func (job *Job) Start() error {
 		return ErrJobAlreadyStarted
 	}
 
-	cmd := exec.Command(job.config.Command, job.config.Arguments...)
+	uidMappings, gidMappings, err := job.getIDMappings()
+	if err != nil {
+		log.Printf("validate job error:%v", err)
+		return err
+	}
+
+	seccompFilter, err := job.getSeccompFilter()
+	if err != nil {
+		log.Printf("validate job error:%v", err)
+		return err
+	}
+
+	// the root filesystem is prepared before the job's cgroup is created, as the IO limits apply to its device
+	if err = job.prepareRootFSOnce(); err != nil {
+		return fmt.Errorf("could not prepare rootfs: %w", err)
+	}
+
+	// host ports are reserved before the job starts, so that a port in use fails Start instead of the started job
+	if err = job.reserveHostPorts(); err != nil {
+		return err
+	}
+
+	// the init shim prepares the job's namespaces and then execs the job's command, see Init
+	cmd := newInitCommand()
 	// combine the stdout and stderr so that the stdout and stderr are combined in the order they are written
 	cmd.Stderr = job.output
 	cmd.Stdout = job.output
 
 	cmd.SysProcAttr = &syscall.SysProcAttr{
 		// CLONE_NEWPID:  creates a new PID namespace preventing the process from seeing/killing host processes
-		// CLONE_NEWNET:  creates a new network namespace preventing the process from accessing the internet or local network
-		// CLONE_NEWNS:   creates a new mount namespace preventing the process from impacting host mounts
+		// CLONE_NEWNET:  creates a new network namespace preventing the process from accessing the internet or local network,
+		//                unless the job's NetworkMode connects it to the bridge
+		// CLONE_NEWNS:   creates a new mount namespace preventing the process from impacting host mounts,
+		//                the init shim mounts a new proc filesystem in it
+		// CLONE_NEWIPC:  creates a new IPC namespace preventing the process from using System V IPC objects and POSIX message
+		//                queues of the host or other jobs, the namespace and all objects left in it are destroyed once
+		//                the last process of the job exits. The init shim mounts a new /dev/shm tmpfs in it.
 		// CLONE_NEWUTS:  creates a new UTS namespaces provide isolation between two system identifiers: the hostname and the NIS domain name
 		// CLONE_NEWUSER: creates new namespaces to isolate security-related identifiers and attributes, in particular, user IDs and group IDs
+		// CLONE_NEWCGROUP: creates a new cgroup namespace rooted at the job's cgroup, so that /proc/self/cgroup and
+		//                the cgroup2 filesystem the init shim mounts at /sys/fs/cgroup only show the job's cgroup subtree
 		Cloneflags: syscall.CLONE_NEWNS |
-			//	syscall.CLONE_NEWIPC | |
+			syscall.CLONE_NEWIPC |
+			syscall.CLONE_NEWCGROUP |
 			syscall.CLONE_NEWNET |
 			syscall.CLONE_NEWUTS |
 			syscall.CLONE_NEWPID |
 			syscall.CLONE_NEWUSER,
-		UidMappings: []syscall.SysProcIDMap{
-			{
-				ContainerID: 0,
-				HostID:      os.Getuid(),
-				Size:        1,
-			},
-		},
-		GidMappings: []syscall.SysProcIDMap{
-			{
-				ContainerID: 0,
-				HostID:      os.Getgid(),
-				Size:        1,
-			},
-		},
+		UidMappings: uidMappings,
+		GidMappings: gidMappings,
+		// the init shim can only set supplementary groups, if setgroups is allowed in the user namespace
+		GidMappingsEnableSetgroups: job.config.hasCredentials(),
 		// force the child processes to start in theirs own process groups
 		Setsid: true,
 		Pgid:   0,
-		//	// Also, enables mounting a new proc filesystem so that command such as `ps -ef` only see the processes in the PID namespace
-		//	Unshareflags: syscall.CLONE_NEWNS,
-		//	// instruct cmd.Run to use the control group file descriptor, so that Job Command does not
-		//	// have to manually add the new PID to the control group
-		//	UseCgroupFD: true,
 	}
 
 	deleteCGroup := func() {
@@ -201,52 +693,149 @@ func (job *Job) Start() error {
 		}
 	}
 
-	err := ns.CreateCGroup(job.getCGroupName())
+	err = ns.CreateCGroup(job.getCGroupName())
 	if err != nil {
 		deleteCGroup()
 		return fmt.Errorf("error creating cgroup: %w", err)
 	}
 
-	if err = ns.AddResourceControl(job.getCGroupName(), ns.CpuWeightFile, strconv.Itoa(int(job.config.CPU*100))); err != nil {
+	if err = ns.AddResourceControl(job.getCGroupName(), ns.CpuMaxFile, job.config.getCPUMax()); err != nil {
 		deleteCGroup()
-		return fmt.Errorf("could not add resources into controller:%s, %v", ns.CpuWeightFile, err)
+		return fmt.Errorf("could not add resources into controller:%s, %v", ns.CpuMaxFile, err)
+	}
+	if job.config.CPUWeight > 0 {
+		if err = ns.AddResourceControl(job.getCGroupName(), ns.CpuWeightFile, strconv.FormatUint(job.config.CPUWeight, 10)); err != nil {
+			deleteCGroup()
+			return fmt.Errorf("could not add resources into controller:%s, %v", ns.CpuWeightFile, err)
+		}
+	}
+	if job.config.CPUSetCPUs != "" {
+		if err = ns.AddResourceControl(job.getCGroupName(), ns.CpuSetCpusFile, job.config.CPUSetCPUs); err != nil {
+			deleteCGroup()
+			return fmt.Errorf("could not add resources into controller:%s, %v", ns.CpuSetCpusFile, err)
+		}
+	}
+	if job.config.CPUSetMems != "" {
+		if err = ns.AddResourceControl(job.getCGroupName(), ns.CpuSetMemsFile, job.config.CPUSetMems); err != nil {
+			deleteCGroup()
+			return fmt.Errorf("could not add resources into controller:%s, %v", ns.CpuSetMemsFile, err)
+		}
 	}
 	if err = ns.AddResourceControl(job.getCGroupName(), ns.MemoryHighFile, strconv.FormatInt(job.config.MemBytes, 10)); err != nil {
 		deleteCGroup()
 		return fmt.Errorf("could not add resources into controller:%s, %v", ns.MemoryHighFile, err)
 	}
-	//if err = ns.AddResourceControl(job.getCGroupName(), ns.IoWeightFile, strconv.FormatInt(job.config.IOBytesPerSecond, 10)); err != nil {
-	//	cleanCGroup <- 1
-	//	defer waitingCleanCGroupToCompleted()
-	//	return fmt.Errorf("could not add resources into controller:%s, %v", ns.IoWeightFile, err)
-	//}
+	if job.config.MaxProcesses > 0 {
+		if err = ns.AddResourceControl(job.getCGroupName(), ns.PidsMaxFile, strconv.FormatInt(job.config.MaxProcesses, 10)); err != nil {
+			deleteCGroup()
+			return fmt.Errorf("could not add resources into controller:%s, %v", ns.PidsMaxFile, err)
+		}
+	}
+	if job.config.MemMaxBytes > 0 {
+		if err = ns.AddResourceControl(job.getCGroupName(), ns.MemoryMaxFile, strconv.FormatInt(job.config.MemMaxBytes, 10)); err != nil {
+			deleteCGroup()
+			return fmt.Errorf("could not add resources into controller:%s, %v", ns.MemoryMaxFile, err)
+		}
+	}
+	if job.config.SwapMaxBytes != 0 {
+		if err = ns.AddResourceControl(job.getCGroupName(), ns.MemorySwapMaxFile, strconv.FormatInt(max(job.config.SwapMaxBytes, 0), 10)); err != nil {
+			deleteCGroup()
+			return fmt.Errorf("could not add resources into controller:%s, %v", ns.MemorySwapMaxFile, err)
+		}
+	}
+	ioMax, err := job.getIOMax()
+	if err != nil {
+		deleteCGroup()
+		return fmt.Errorf("could not find block device for IO limits: %w", err)
+	}
+	if ioMax != "" {
+		if err = ns.AddResourceControl(job.getCGroupName(), ns.IoMaxFile, ioMax); err != nil {
+			deleteCGroup()
+			return fmt.Errorf("could not add resources into controller:%s, %v", ns.IoMaxFile, err)
+		}
+	}
+	if job.config.DelegateCGroup {
+		if err = job.delegateCGroup(uidMappings, gidMappings); err != nil {
+			deleteCGroup()
+			return err
+		}
+	}
 
-	//provide the file descriptor to cmd.Run so that it can add the new PID to the control group
-	if err = ns.AddProcess(job.getCGroupName(), cmd); err != nil {
+	pressureWatchers, err := job.registerPressureTriggers()
+	if err != nil {
 		deleteCGroup()
-		return fmt.Errorf("error AddProcess /proc - %w\n", err)
+		return fmt.Errorf("could not register pressure triggers: %w", err)
+	}
+	closePressureWatchers := func() {
+		for _, watcher := range pressureWatchers {
+			_ = watcher.Close()
+		}
 	}
 
-	unmountProc := func() {
-		if err := ns.UnmountProc(); err != nil {
-			job.exitReason = errors.Join(job.exitReason, fmt.Errorf("error unmounting /proc - %w\n", err))
+	removeState := func(keepRootFSChanges bool) {
+		if err := job.removeState(keepRootFSChanges); err != nil {
+			log.Printf("error removing job state: %s\n", err)
+			job.exitReason = errors.Join(job.exitReason, err)
 		}
 	}
 
-	if err = ns.MountProc(); err != nil {
+	// provide the cgroup's file descriptor to cmd.Start, so that the process is cloned into the job's cgroup instead of
+	// being added to it after it has started. The job's cgroup namespace is rooted at the cgroup the process is
+	// cloned into, otherwise the job would see the cgroups of other jobs.
+	cgroupDir, err := ns.AddProcess(job.getCGroupName(), cmd)
+	if err != nil {
+		removeState(false)
+		closePressureWatchers()
 		deleteCGroup()
-		return fmt.Errorf("Error mounting /proc - %w\n", err)
+		return fmt.Errorf("error AddProcess /proc - %w\n", err)
+	}
+
+	initConfigWriter, err := addInitConfigPipe(cmd)
+	if err != nil {
+		_ = cgroupDir.Close()
+		removeState(false)
+		closePressureWatchers()
+		deleteCGroup()
+		return err
 	}
 
 	log.Printf("starting job:%s, cmd:%s", job, cmd.String())
-	if err = cmd.Start(); err != nil {
+	err = cmd.Start()
+	// the process is in the job's cgroup once it has started, the file descriptor is no longer needed
+	_ = cgroupDir.Close()
+	if err != nil {
+		closeInitConfigPipe(cmd, initConfigWriter)
+		removeState(false)
+		closePressureWatchers()
 		deleteCGroup()
-		unmountProc()
 		return fmt.Errorf("error starting command: %w", err)
 	}
 	job.cmd = cmd
 	job.isStarted = true
 
+	// the init shim exits if it does not receive its config, so the job completes with the error as exit reason
+	initConfig := job.getInitConfig(job.rootfs, job.overlay, seccompFilter)
+	if initConfig.Interface, err = job.setupNetwork(cmd.Process.Pid); err != nil {
+		log.Printf("error setting up network: %s\n", err)
+		job.exitReason = errors.Join(job.exitReason, fmt.Errorf("error setting up network: %w\n", err))
+		closeInitConfigPipe(cmd, initConfigWriter)
+	} else if err = job.addHostsVolume(initConfig); err != nil {
+		log.Printf("error setting up hosts file: %s\n", err)
+		job.exitReason = errors.Join(job.exitReason, fmt.Errorf("error setting up hosts file: %w\n", err))
+		closeInitConfigPipe(cmd, initConfigWriter)
+	} else if err = writeInitConfig(cmd, initConfigWriter, initConfig); err != nil {
+		log.Printf("error sending init config: %s\n", err)
+		job.exitReason = errors.Join(job.exitReason, fmt.Errorf("error sending init config: %w\n", err))
+	}
+	if job.network != nil {
+		job.ipAddress = job.network.address.IP.String()
+	}
+
+	for _, watcher := range pressureWatchers {
+		job.pressureWatchers.Add(1)
+		go job.watchPressure(watcher)
+	}
+
 	// run the command in a Goroutine so that Start can return immediately
 	go func() {
 		// Use cmd.Process.Wait() instead of cmd.Wait() since cmd.Wait() is not thread safe
@@ -257,18 +846,49 @@ func (job *Job) Start() error {
 		// process state), and the user invokes Status().
 		processState, err := job.cmd.Process.Wait()
 
+		// pressure triggers hold the cgroup's pressure files open, release them first
+		close(job.stopPressureWatchers)
+		job.pressureWatchers.Wait()
+
+		// the command may have forked processes which outlived it, kill the whole cgroup
+		// so that it can be deleted
+		cleanupErr := job.killProcesses()
+
 		job.mutex.Lock()
 		defer job.mutex.Unlock()
 
+		job.exitReason = errors.Join(job.exitReason, cleanupErr)
+
 		job.processState = processState
 		job.exitCode = processState.ExitCode()
 
 		// at this stage job in completed (successfully or not we can detect from checking job.exitReason and isTerminated )
 		job.isCompleted = true
 
-		// at this stage command completed and we no longer need cgroup and mounted filesystem and can release
+		// memory.events and stat files are gone with the cgroup, so check for OOM kills and record
+		// the final resource usage before releasing it
+		job.checkOOMKilled()
+		job.checkCPULimitExceeded()
+		job.recordUsage()
+
+		// at this stage command completed and we no longer need cgroup and can release
 		deleteCGroup()
-		unmountProc()
+		// the job's mount namespace is gone with its last process, so the rootfs and overlay are no longer mounted
+		removeState(job.config.KeepRootFSChanges)
+		if job.config.KeepRootFSChanges {
+			job.hasRootFSChanges = true
+			job.rootFSChangesTimer = time.AfterFunc(RootFSChangesTTL, func() {
+				if err := job.RemoveRootFSChanges(); err != nil && !errors.Is(err, ErrRootFSChangesNotAvailable) {
+					log.Printf("error removing rootfs changes of job:%s, %s\n", job, err)
+				}
+			})
+		}
+		// the job's network namespace is gone with its last process too, so its address and host ports are free
+		if networkErr := job.removeNetwork(); networkErr != nil {
+			log.Printf("error removing job network: %s\n", networkErr)
+			job.exitReason = errors.Join(job.exitReason, networkErr)
+		}
+		job.releaseHostPorts()
 		if err != nil {
 			job.exitReason = errors.Join(job.exitReason, fmt.Errorf("error running command: %w\n", err))
 		}
@@ -282,41 +902,250 @@ func (job *Job) Start() error {
 		if err = job.output.Close(); err != nil {
 			job.exitReason = errors.Join(job.exitReason, fmt.Errorf("error closing output: %w\n", err))
 		}
+
+		close(job.done)
 	}()
 	return nil
 }
 
+// registerPressureTriggers registers PSI triggers of the job's config on the job's cgroup
+func (job *Job) registerPressureTriggers() ([]*ns.PressureWatcher, error) {
+	var watchers []*ns.PressureWatcher
+
+	for _, trigger := range job.config.PressureTriggers {
+		watcher, err := ns.WatchPressure(job.getCGroupName(), trigger)
+		if err != nil {
+			for _, registered := range watchers {
+				_ = registered.Close()
+			}
+			return nil, err
+		}
+		watchers = append(watchers, watcher)
+	}
+	return watchers, nil
+}
+
+// watchPressure writes a line into the job's output every time the pressure trigger fires until the process exits
+func (job *Job) watchPressure(watcher *ns.PressureWatcher) {
+	defer job.pressureWatchers.Done()
+	defer watcher.Close()
+
+	for {
+		select {
+		case <-job.stopPressureWatchers:
+			return
+		default:
+		}
+
+		fired, err := watcher.Wait(pressurePollTimeout)
+		if err != nil {
+			log.Printf("error watching pressure of job:%s, %s\n", job, err)
+			return
+		}
+
+		if fired {
+			log.Printf("pressure trigger:%s fired for job:%s", &watcher.Trigger, job)
+			_, _ = fmt.Fprintf(job.output, "[jobworker] pressure threshold exceeded: %s\n", &watcher.Trigger)
+		}
+	}
+}
+
+// killProcesses kills every process left in the job's cgroup and waits until the cgroup is empty.
+func (job *Job) killProcesses() error {
+	if err := ns.KillCGroup(job.getCGroupName()); err != nil {
+		log.Printf("error killing cgroup processes: %s\n", err)
+		return fmt.Errorf("error killing cgroup processes: %w\n", err)
+	}
+
+	if err := ns.WaitCGroupEmpty(job.getCGroupName(), cgroupEmptyTimeout); err != nil {
+		log.Printf("error waiting cgroup processes: %s\n", err)
+		return fmt.Errorf("error waiting cgroup processes: %w\n", err)
+	}
+	return nil
+}
+
+// checkOOMKilled reads memory.events of the job's cgroup and records whether the OOM killer killed any process of the job.
+// Must be called with job.mutex held and before the cgroup is deleted.
+func (job *Job) checkOOMKilled() {
+	events, err := ns.ReadFlatKeyedFile(job.getCGroupName(), ns.MemoryEventsFile)
+	if err != nil {
+		log.Printf("error reading memory events: %s\n", err)
+		job.exitReason = errors.Join(job.exitReason, fmt.Errorf("error reading memory events: %w\n", err))
+		return
+	}
+
+	if oomKills := events["oom_kill"]; oomKills > 0 {
+		log.Printf("job:%s had %d process(es) killed by OOM killer", job, oomKills)
+		job.isOOMKilled = true
+		job.exitReason = errors.Join(job.exitReason, fmt.Errorf("%w: %d process(es) killed", ErrOOMKilled, oomKills))
+	}
+}
+
+// checkCPULimitExceeded records whether the kernel killed the command after it exceeded its RLIMIT_CPU.
+// The command runs as PID 1 of its PID namespace, which ignores SIGXCPU at the soft limit unless it handles it,
+// so it is killed by either SIGXCPU or by SIGKILL once it has used at least the soft limit of CPU seconds.
+// Must be called with job.mutex held once the process has exited.
+func (job *Job) checkCPULimitExceeded() {
+	rlimit, ok := job.getRlimits()[rlimitCPU]
+	if !ok || job.isTerminated || job.isOOMKilled {
+		return
+	}
+
+	status, ok := job.processState.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return
+	}
+
+	cpuTime := job.processState.UserTime() + job.processState.SystemTime()
+	if status.Signal() == syscall.SIGXCPU ||
+		(status.Signal() == syscall.SIGKILL && cpuTime >= time.Duration(rlimit.Soft)*time.Second) {
+		log.Printf("job:%s killed after using %s of CPU time", job, cpuTime)
+		job.isCPULimitExceeded = true
+		job.exitReason = errors.Join(job.exitReason, fmt.Errorf("%w: %s of CPU time used", ErrCPULimitExceeded, cpuTime))
+	}
+}
+
+// recordUsage keeps the final resource usage of the job, so that it can be reported after the job's cgroup is deleted.
+// Must be called with job.mutex held and before the cgroup is deleted.
+func (job *Job) recordUsage() {
+	usage, err := ns.ReadUsage(job.getCGroupName())
+	if err != nil {
+		log.Printf("error reading resource usage: %s\n", err)
+		return
+	}
+	usage.Memory.Current = 0
+	usage.Pids.Current = 0
+	if err = readNetworkStats(job.network, usage); err != nil {
+		log.Printf("error reading network usage: %s\n", err)
+	}
+	job.usage = usage
+}
+
+// Usage returns resource usage of the Job, read from the job's cgroup and veth pair while the Job is running
+// or the final usage once the Job has completed.
+//
+// ErrJobNotStarted is returned, if the Job has not been started.
+// ErrUsageNotAvailable is returned, if the final usage could not be recorded when the Job completed.
+func (job *Job) Usage() (*ns.Usage, error) {
+	job.mutex.Lock()
+	isStarted, isCompleted, network := job.isStarted, job.isCompleted, job.network
+	job.mutex.Unlock()
+
+	if !isStarted {
+		return nil, ErrJobNotStarted
+	}
+	if !isCompleted {
+		return job.readLiveUsage(network)
+	}
+
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	return job.getFinalUsage()
+}
+
+// readLiveUsage reads the usage of the running job from its cgroup and veth pair. It is called without job.mutex held,
+// so that slow reads of cgroup, PSI and network files don't block Stop and the job's completion, and returns the
+// final usage, if the job has completed and its cgroup has been deleted in the meantime.
+func (job *Job) readLiveUsage(network *jobNetwork) (*ns.Usage, error) {
+	usage, err := ns.ReadUsage(job.getCGroupName())
+	if err == nil {
+		err = readNetworkStats(network, usage)
+	}
+	if err == nil {
+		return usage, nil
+	}
+
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	if !job.isCompleted {
+		return nil, err
+	}
+	return job.getFinalUsage()
+}
+
+// getFinalUsage returns a copy of the usage recorded when the job completed. Must be called with job.mutex held.
+func (job *Job) getFinalUsage() (*ns.Usage, error) {
+	if job.usage == nil {
+		return nil, ErrUsageNotAvailable
+	}
+	usage := *job.usage
+	return &usage, nil
+}
+
 // Status returns the current Status of the Job.
 func (job *Job) Status() *JobStatus {
 	job.mutex.Lock()
-	defer job.mutex.Unlock()
+	if !job.isStarted || job.isCompleted {
+		defer job.mutex.Unlock()
+		return job.getFinalStatus()
+	}
 
-	if !job.isStarted {
-		return &JobStatus{
-			State:    JobStatusNotStarted,
-			ExitCode: job.exitCode,
+	state := JobStatusRunning
+	if job.isTerminated {
+		state = JobStatusStopping
+	}
+	exitCode, ipAddress := job.exitCode, job.ipAddress
+	job.mutex.Unlock()
+
+	// the job's cgroup still exists, so report the current resource usage, read without holding job.mutex
+	usage, err := ns.ReadUsage(job.getCGroupName())
+	if err != nil {
+		job.mutex.Lock()
+		defer job.mutex.Unlock()
+
+		// the job's cgroup has been deleted, if the job has completed in the meantime
+		if job.isCompleted {
+			return job.getFinalStatus()
 		}
+		log.Printf("error reading resource usage: %s\n", err)
+		usage = &ns.Usage{}
 	}
 
-	if job.isStarted && !job.isCompleted {
+	return &JobStatus{
+		State:            state,
+		ExitCode:         exitCode,
+		PidsCurrent:      usage.Pids.Current,
+		PidsPeak:         usage.Pids.Peak,
+		PidsLimitReached: usage.Pids.LimitHits > 0,
+		CPUTime:          time.Duration(usage.CPU.UsageUsec) * time.Microsecond,
+		MemoryPeakBytes:  usage.Memory.Peak,
+		IPAddress:        ipAddress,
+	}
+}
+
+// getFinalStatus returns the Status of a Job that has not been started or has completed.
+// Must be called with job.mutex held.
+func (job *Job) getFinalStatus() *JobStatus {
+	if !job.isStarted {
 		return &JobStatus{
-			State:    JobStatusRunning,
+			State:    JobStatusNotStarted,
 			ExitCode: job.exitCode,
 		}
 	}
 
+	state := JobStatusCompleted
 	if job.isTerminated {
-		return &JobStatus{
-			State:      JobStatusTerminated,
-			ExitCode:   job.exitCode,
-			ExitReason: job.getExitReason(),
-		}
+		state = JobStatusTerminated
+	}
+
+	usage := job.usage
+	if usage == nil {
+		usage = &ns.Usage{}
 	}
 
 	return &JobStatus{
-		State:      JobStatusCompleted,
-		ExitCode:   job.exitCode,
-		ExitReason: job.getExitReason(),
+		State:            state,
+		ExitCode:         job.exitCode,
+		ExitReason:       job.getExitReason(),
+		OOMKilled:        job.isOOMKilled,
+		CPULimitExceeded: job.isCPULimitExceeded,
+		PidsPeak:         usage.Pids.Peak,
+		PidsLimitReached: usage.Pids.LimitHits > 0,
+		CPUTime:          time.Duration(usage.CPU.UsageUsec) * time.Microsecond,
+		MemoryPeakBytes:  usage.Memory.Peak,
+		IPAddress:        job.ipAddress,
 	}
 }
 
@@ -326,7 +1155,18 @@ func (job *Job) Stream() io.ReadCloser {
 	return NewOutputReadCloser(job.output)
 }
 
+// Stop sends SIGTERM to the Job using the StopGracePeriod from the JobConfig, see StopWithGracePeriod.
 func (job *Job) Stop() error {
+	return job.StopWithGracePeriod(job.config.StopGracePeriod)
+}
+
+// StopWithGracePeriod sends SIGTERM to the Job and returns immediately, leaving the Job in the Stopping state.
+// If the process is still running after gracePeriod (10 seconds if zero) it is killed with SIGKILL in the background.
+// Callers can check Status or read Stream until EOF to find out when the Job has finally stopped.
+//
+// ErrJobAlreadyStopped is returned, if the Job has already been stopped or completed.
+// ErrJobNotStarted is returned, if the Job has not been started.
+func (job *Job) StopWithGracePeriod(gracePeriod time.Duration) error {
 	job.mutex.Lock()
 	defer job.mutex.Unlock()
 
@@ -339,41 +1179,46 @@ func (job *Job) Stop() error {
 		return ErrJobNotStarted
 	}
 
+	if gracePeriod < 0 {
+		return ErrInvalidStopGracePeriod
+	}
+	if gracePeriod == 0 {
+		gracePeriod = defaultStopGracePeriod
+	}
+
 	if err := job.cmd.Process.Signal(syscall.SIGTERM); err != nil {
 		return fmt.Errorf("error sending SIGTERM: %w", err)
 	}
+	job.isTerminated = true
 
-	// Set up timeout
-	killCtx, cancel := context.WithTimeout(context.Background(), stopGracePeriod)
-	defer cancel()
-
-	cmdWait := make(chan error, 1)
+	// escalate to SIGKILL in the background, so that Status, Stream and others are not blocked
+	// while the process is shutting down
 	go func() {
-		_, err := job.cmd.Process.Wait()
-		cmdWait <- err
-	}()
+		timer := time.NewTimer(gracePeriod)
+		defer timer.Stop()
 
-	// TODO: At the current implementation Stop method are blocking all other functions
-	//       and waiting when `cmd` terminated or complete execution.
-	//		 But for production looks like we need to fire-and-forget approach and
-	//		 caller do periodically check Status orff just leave "Stop" to complete.
-	select {
-	case <-cmdWait:
-		{
+		select {
+		case <-job.done:
 			// command exited before timer expired, so nothing to do
-			log.Printf("process compeled :%s", job)
-			job.isCompleted = true
-		}
-	case <-killCtx.Done():
-		{
-			//send SIGKILL if process is still running after timer expires
+			log.Printf("process completed :%s", job)
+		case <-timer.C:
+			job.mutex.Lock()
+			defer job.mutex.Unlock()
+
+			if job.isCompleted {
+				return
+			}
+
+			//send SIGKILL to every process of the job if process is still running after timer expires
 			log.Printf("send SIGKILL to job:%s", job)
-			if err := syscall.Kill(job.cmd.Process.Pid, syscall.SIGKILL); err != nil {
-				return fmt.Errorf("error sending SIGKILL: %w", err)
+			if err := job.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+				job.exitReason = errors.Join(job.exitReason, fmt.Errorf("error sending SIGKILL: %w", err))
+			}
+			if err := ns.KillCGroup(job.getCGroupName()); err != nil {
+				job.exitReason = errors.Join(job.exitReason, fmt.Errorf("error sending SIGKILL: %w", err))
 			}
-			job.isTerminated = true
 		}
-	}
+	}()
 
 	return nil
 }
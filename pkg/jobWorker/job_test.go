@@ -2,15 +2,30 @@ package jobWorker
 
 import (
 	"fmt"
+	ns "github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker/namespaces"
 	"io"
 	"log"
+	"math"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 )
 
+func TestMain(m *testing.M) {
+	// jobs run the test binary again as the init shim
+	Init()
+
+	os.Exit(m.Run())
+}
+
 func Test_Job_Running(t *testing.T) {
 	// There is no problem to run test in parallel, but log output are confusing if you need to investigate anything.
 	// TODO: Uncomment in final version when testing completely done.
@@ -57,6 +72,140 @@ func Test_Job_Running(t *testing.T) {
 	}
 }
 
+func Test_Job_Started_in_its_cgroup(t *testing.T) {
+	//t.Parallel()
+
+	config := JobConfig{
+		Command:          "sleep",
+		Arguments:        []string{"60"},
+		CPU:              0.5,           // half a CPU core
+		IOBytesPerSecond: 100_000_000,   // 100 MB/s
+		MemBytes:         1_000_000_000, // 1 GB
+	}
+
+	testJob := NewJob(&config)
+
+	// start the job
+	err := testJob.Start()
+	if err != nil {
+		t.Fatalf("error starting job: %v", err)
+	}
+	defer func() {
+		_ = testJob.Stop()
+		_, _ = io.ReadAll(testJob.Stream())
+	}()
+
+	// the process is cloned into the job's cgroup, so it is listed as soon as Start returns
+	procs, err := os.ReadFile(filepath.Join(ns.GetCGroupPath(testJob.getCGroupName()), "cgroup.procs"))
+	if err != nil {
+		t.Fatalf("error reading cgroup.procs: %v", err)
+	}
+	pid := strconv.Itoa(testJob.cmd.Process.Pid)
+	if !slices.Contains(strings.Fields(string(procs)), pid) {
+		t.Errorf("expected pid %s in cgroup.procs of the job's cgroup, got %q", pid, procs)
+	}
+
+	// the job's cgroup is a leaf, so that its limits apply to all of its processes
+	if entries, err := os.ReadDir(ns.GetCGroupPath(testJob.getCGroupName())); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				t.Errorf("expected no child cgroups, got %s", entry.Name())
+			}
+		}
+	}
+}
+
+func Test_Job_Mounts_private_proc_and_sets_hostname(t *testing.T) {
+	//t.Parallel()
+
+	config := JobConfig{
+		Command:          "/bin/sh",
+		Arguments:        []string{"-c", "cat /proc/1/comm; hostname"},
+		CPU:              0.5,           // half a CPU core
+		IOBytesPerSecond: 100_000_000,   // 100 MB/s
+		MemBytes:         1_000_000_000, // 1 GB
+	}
+
+	testJob := NewJob(&config)
+
+	// start the job
+	err := testJob.Start()
+	if err != nil {
+		t.Fatalf("error starting job: %v", err)
+	}
+
+	// wait for the job to finish by waiting for io.ReadAll to complete
+	output, err := io.ReadAll(testJob.Stream())
+	if err != nil {
+		t.Fatalf("error reading output: %v", err)
+	}
+
+	// the command replaces the init shim as PID 1 of the job's PID namespace
+	expectedOutput := fmt.Sprintf("sh\n%s\n", testJob.getHostname())
+	if string(output) != expectedOutput {
+		t.Errorf("expected output to be %q, got %q", expectedOutput, output)
+	}
+
+	// the host's /proc must not be replaced by the job's one
+	hostPid, err := os.Readlink("/proc/self")
+	if err != nil || hostPid != strconv.Itoa(os.Getpid()) {
+		t.Errorf("expected host /proc/self to be %d, got %q, error: %v", os.Getpid(), hostPid, err)
+	}
+}
+
+func Test_Job_getInitConfig_expected_env_and_working_dir(t *testing.T) {
+	t.Parallel()
+
+	inheritEnv := false
+	testCases := []struct {
+		config             JobConfig
+		imageConfig        *ImageConfig
+		expectedEnv        []string
+		expectedClearEnv   bool
+		expectedWorkingDir string
+		expectedErr        error
+	}{
+		{config: JobConfig{}, expectedClearEnv: true},
+		{config: JobConfig{ClearEnv: &inheritEnv, Env: map[string]string{"B": "2", "A": "1"}}, expectedEnv: []string{"A=1", "B=2"}},
+		{
+			config:             JobConfig{Env: map[string]string{"MODE": "job"}, WorkingDir: "/work"},
+			imageConfig:        &ImageConfig{Env: []string{"PATH=/bin", "MODE=image"}, WorkingDir: "/srv"},
+			expectedEnv:        []string{"PATH=/bin", "MODE=image", "MODE=job"},
+			expectedClearEnv:   true,
+			expectedWorkingDir: "/work",
+		},
+		{config: JobConfig{Env: map[string]string{"A=B": "1"}}, expectedErr: ErrInvalidEnv},
+		{config: JobConfig{Env: map[string]string{"": "1"}}, expectedErr: ErrInvalidEnv},
+		{config: JobConfig{WorkingDir: "work"}, expectedErr: ErrInvalidWorkingDir},
+	}
+
+	for _, testCase := range testCases {
+		config := testCase.config
+		config.Command = "echo"
+		config.CPU = 0.5
+		config.IOBytesPerSecond = 100_000_000
+		config.MemBytes = 1_000_000_000
+
+		if err := config.isValid(); err != testCase.expectedErr {
+			t.Errorf("config:%+v, expected error %v, got %v", testCase.config, testCase.expectedErr, err)
+			continue
+		}
+		if testCase.expectedErr != nil {
+			continue
+		}
+
+		job := NewJob(&config)
+		job.imageConfig = testCase.imageConfig
+		initConfig := job.getInitConfig("", nil, nil)
+		if !reflect.DeepEqual(initConfig.Env, testCase.expectedEnv) || initConfig.ClearEnv != testCase.expectedClearEnv ||
+			initConfig.WorkingDir != testCase.expectedWorkingDir {
+			t.Errorf("config:%+v, expected env %v (clear: %t) in %q, got %v (clear: %t) in %q", testCase.config,
+				testCase.expectedEnv, testCase.expectedClearEnv, testCase.expectedWorkingDir,
+				initConfig.Env, initConfig.ClearEnv, initConfig.WorkingDir)
+		}
+	}
+}
+
 func Test_Job_Second_Call_Stop_expected_not_send_SIGKIL_again(t *testing.T) {
 	// There is no problem to run test in parallel, but log output are confusing if you need to investigate anything.
 	// TODO: Uncomment in final version when testing completely done.
@@ -236,6 +385,51 @@ func Test_Job_Stopping_Long_Lived_Command(t *testing.T) {
 	}
 }
 
+func Test_Job_Stop_expected_return_immediately_with_Stopping_state(t *testing.T) {
+	//t.Parallel()
+
+	config := JobConfig{
+		Command:          "/bin/bash",
+		Arguments:        []string{"-c", "trap '' TERM; while :; do  echo thinking; sleep 1; done"},
+		CPU:              0.5,           // half a CPU core
+		IOBytesPerSecond: 100_000_000,   // 100 MB/s
+		MemBytes:         1_000_000_000, // 1 GB
+		StopGracePeriod:  2 * time.Second,
+	}
+
+	testJob := NewJob(&config)
+
+	// start the job
+	err := testJob.Start()
+	if err != nil {
+		t.Fatalf("error starting job: %v", err)
+	}
+
+	// stop job, the command ignores SIGTERM, so Stop must not wait for the grace period
+	stopStarted := time.Now()
+	if err = testJob.Stop(); err != nil {
+		t.Fatalf("error stopping job: %v", err)
+	}
+	if elapsed := time.Since(stopStarted); elapsed >= config.StopGracePeriod {
+		t.Errorf("expected Stop to return before grace period %s, took %s", config.StopGracePeriod, elapsed)
+	}
+
+	status := testJob.Status()
+	if status.State != JobStatusStopping {
+		t.Errorf("expected job state to be '%s', got '%s'", JobStatusStopping, status.State)
+	}
+
+	// wait for job to be killed after the grace period
+	if _, err = io.ReadAll(testJob.Stream()); err != nil {
+		t.Errorf("error reading: %v", err)
+	}
+
+	status = testJob.Status()
+	if status.State != JobStatusTerminated {
+		t.Errorf("expected job state to be '%s', got '%s'", JobStatusTerminated, status.State)
+	}
+}
+
 func Test_Job_Load_10000_Read_and_Write(t *testing.T) {
 	config := JobConfig{
 		Command:          "/bin/bash",
@@ -310,6 +504,240 @@ func Test_Job_Load_10000_Read_and_Write(t *testing.T) {
 
 }
 
+func Test_Job_MemMaxBytes_expected_OOMKilled(t *testing.T) {
+	//t.Parallel()
+
+	// tail keeps the whole line in memory, since /dev/zero never produces a newline
+	config := JobConfig{
+		Command:          "/bin/sh",
+		Arguments:        []string{"-c", "head -c 500000000 /dev/zero | tail"},
+		CPU:              0.5,         // half a CPU core
+		IOBytesPerSecond: 100_000_000, // 100 MB/s
+		MemBytes:         50_000_000,  // 50 MB
+		MemMaxBytes:      50_000_000,  // 50 MB
+		SwapMaxBytes:     -1,          // no swap
+	}
+
+	testJob := NewJob(&config)
+
+	// start the job
+	err := testJob.Start()
+	if err != nil {
+		t.Fatalf("error starting job: %v", err)
+	}
+
+	// wait for the job to finish by waiting for io.ReadAll to complete
+	if _, err = io.ReadAll(testJob.Stream()); err != nil {
+		t.Fatalf("error reading output: %v", err)
+	}
+
+	status := testJob.Status()
+	if !status.OOMKilled {
+		t.Errorf("expected job to be killed by OOM killer, got status: %+v", status)
+	}
+
+	if !strings.Contains(status.ExitReason, ErrOOMKilled.Error()) {
+		t.Errorf("expected job exit reason to contain %q, got %q", ErrOOMKilled, status.ExitReason)
+	}
+}
+
+func Test_Job_MaxProcesses_expected_PidsLimitReached(t *testing.T) {
+	//t.Parallel()
+
+	config := JobConfig{
+		Command:          "/bin/sh",
+		Arguments:        []string{"-c", "i=0; while [ $i -lt 32 ]; do sleep 1 & i=$((i+1)); done; wait"},
+		CPU:              0.5,           // half a CPU core
+		IOBytesPerSecond: 100_000_000,   // 100 MB/s
+		MemBytes:         1_000_000_000, // 1 GB
+		// the lowest limit, the threads of the init shim are gone once it has started the command
+		MaxProcesses: minMaxProcesses,
+	}
+
+	testJob := NewJob(&config)
+
+	// start the job
+	err := testJob.Start()
+	if err != nil {
+		t.Fatalf("error starting job: %v", err)
+	}
+
+	// wait for the job to finish by waiting for io.ReadAll to complete
+	if _, err = io.ReadAll(testJob.Stream()); err != nil {
+		t.Fatalf("error reading output: %v", err)
+	}
+
+	status := testJob.Status()
+	if !status.PidsLimitReached {
+		t.Errorf("expected job to reach MaxProcesses, got status: %+v", status)
+	}
+
+	if status.PidsPeak > config.MaxProcesses {
+		t.Errorf("expected job to run at most %d processes, got %d", config.MaxProcesses, status.PidsPeak)
+	}
+
+	if status.PidsCurrent != 0 {
+		t.Errorf("expected no processes once job completed, got %d", status.PidsCurrent)
+	}
+}
+
+func Test_Job_Usage_expected_final_usage_after_exit(t *testing.T) {
+	//t.Parallel()
+
+	config := JobConfig{
+		Command:          "/bin/sh",
+		Arguments:        []string{"-c", "i=0; while [ $i -lt 100000 ]; do i=$((i+1)); done"},
+		CPU:              0.5,           // half a CPU core
+		IOBytesPerSecond: 100_000_000,   // 100 MB/s
+		MemBytes:         1_000_000_000, // 1 GB
+	}
+
+	testJob := NewJob(&config)
+
+	if _, err := testJob.Usage(); err != ErrJobNotStarted {
+		t.Errorf("expected error(ErrJobNotStarted), got %v", err)
+	}
+
+	// start the job
+	err := testJob.Start()
+	if err != nil {
+		t.Fatalf("error starting job: %v", err)
+	}
+
+	// wait for the job to finish by waiting for io.ReadAll to complete
+	if _, err = io.ReadAll(testJob.Stream()); err != nil {
+		t.Fatalf("error reading output: %v", err)
+	}
+
+	// the job's cgroup is deleted, so the usage recorded when the job completed is returned
+	usage, err := testJob.Usage()
+	if err != nil {
+		t.Fatalf("error reading usage: %v", err)
+	}
+	if usage.CPU.UsageUsec == 0 || usage.Pids.Peak == 0 {
+		t.Errorf("expected CPU time and processes used by the job, got %+v", usage)
+	}
+	if usage.Memory.Current != 0 || usage.Pids.Current != 0 {
+		t.Errorf("expected no memory and processes in use once job completed, got %+v", usage)
+	}
+
+	// the usage is a copy, which callers can't change
+	usage.CPU.UsageUsec = 0
+	if usage, err = testJob.Usage(); err != nil || usage.CPU.UsageUsec == 0 {
+		t.Errorf("expected recorded usage unchanged, got %+v, error: %v", usage, err)
+	}
+}
+
+func Test_JobConfig_CPU_limits(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		config         JobConfig
+		expectedCPUMax string
+		expectedErr    error
+	}{
+		{config: JobConfig{CPU: 0.5}, expectedCPUMax: "50000 100000"},
+		{config: JobConfig{CPU: 1}, expectedCPUMax: "100000 100000"},
+		// quota is raised to the minimum accepted by the kernel
+		{config: JobConfig{CPU: 0.001}, expectedCPUMax: "1000 100000"},
+		{config: JobConfig{CPU: 1, CPUWeight: 10_001}, expectedErr: ErrInvalidCPUWeight},
+		{config: JobConfig{CPU: 1, CPUSetCPUs: "0-3,6"}, expectedCPUMax: "100000 100000"},
+		{config: JobConfig{CPU: 1, CPUSetCPUs: "0-3;6"}, expectedErr: ErrInvalidCPUSet},
+		{config: JobConfig{CPU: 1, CPUSetMems: "first"}, expectedErr: ErrInvalidCPUSet},
+		{config: JobConfig{CPU: float64(runtime.NumCPU() + 1)}, expectedErr: ErrInvalidCPU},
+		{config: JobConfig{CPU: math.Inf(1)}, expectedErr: ErrInvalidCPU},
+		{config: JobConfig{CPU: math.NaN()}, expectedErr: ErrInvalidCPU},
+	}
+
+	for _, testCase := range testCases {
+		config := testCase.config
+		config.Command = "echo"
+		config.IOBytesPerSecond = 100_000_000
+		config.MemBytes = 1_000_000_000
+
+		if err := config.isValid(); err != testCase.expectedErr {
+			t.Errorf("config:%+v, expected error %v, got %v", testCase.config, testCase.expectedErr, err)
+			continue
+		}
+		if testCase.expectedErr != nil {
+			continue
+		}
+		if cpuMax := config.getCPUMax(); cpuMax != testCase.expectedCPUMax {
+			t.Errorf("config:%+v, expected cpu.max %q, got %q", testCase.config, testCase.expectedCPUMax, cpuMax)
+		}
+	}
+
+	// the quota of several CPUs, which isValid only accepts on hosts with as many CPUs
+	if cpuMax := getCPUMax(2); cpuMax != "200000 100000" {
+		t.Errorf("expected cpu.max %q of 2 CPUs, got %q", "200000 100000", cpuMax)
+	}
+}
+
+func Test_JobConfig_MaxProcesses(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		maxProcesses int64
+		expectedErr  error
+	}{
+		{maxProcesses: 0},
+		{maxProcesses: 16},
+		// the threads of the init shim count against pids.max
+		{maxProcesses: 4, expectedErr: ErrInvalidMaxProcesses},
+		{maxProcesses: -1, expectedErr: ErrInvalidMaxProcesses},
+	}
+
+	for _, testCase := range testCases {
+		config := JobConfig{
+			Command:          "echo",
+			CPU:              1,
+			IOBytesPerSecond: 100_000_000,
+			MemBytes:         1_000_000_000,
+			MaxProcesses:     testCase.maxProcesses,
+		}
+
+		if err := config.isValid(); err != testCase.expectedErr {
+			t.Errorf("max processes:%d, expected error %v, got %v", testCase.maxProcesses, testCase.expectedErr, err)
+		}
+	}
+}
+
+func Test_JobConfig_ShmSizeBytes(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		config               JobConfig
+		expectedShmSizeBytes int64
+		expectedErr          error
+	}{
+		{config: JobConfig{MemBytes: 1_000_000_000}, expectedShmSizeBytes: 64 << 20},
+		// the default size is capped at the memory limit
+		{config: JobConfig{MemBytes: 10_000_000}, expectedShmSizeBytes: 10_000_000},
+		{config: JobConfig{MemBytes: 10_000_000, MemMaxBytes: 20_000_000}, expectedShmSizeBytes: 20_000_000},
+		{config: JobConfig{MemBytes: 1_000_000_000, ShmSizeBytes: 1_000_000_000}, expectedShmSizeBytes: 1_000_000_000},
+		{config: JobConfig{MemBytes: 1_000_000_000, ShmSizeBytes: 1_000_000_001}, expectedErr: ErrInvalidShmSizeBytes},
+		{config: JobConfig{MemBytes: 1_000_000_000, ShmSizeBytes: -1}, expectedErr: ErrInvalidShmSizeBytes},
+	}
+
+	for _, testCase := range testCases {
+		config := testCase.config
+		config.Command = "echo"
+		config.CPU = 1
+		config.IOBytesPerSecond = 100_000_000
+
+		if err := config.isValid(); err != testCase.expectedErr {
+			t.Errorf("config:%+v, expected error %v, got %v", testCase.config, testCase.expectedErr, err)
+			continue
+		}
+		if testCase.expectedErr != nil {
+			continue
+		}
+		if shmSizeBytes := config.getShmSizeBytes(); shmSizeBytes != testCase.expectedShmSizeBytes {
+			t.Errorf("config:%+v, expected shm size %d, got %d", testCase.config, testCase.expectedShmSizeBytes, shmSizeBytes)
+		}
+	}
+}
+
 func Test_Job_IOLimits(t *testing.T) {
 	//t.Parallel()
 	t.Skip()
@@ -342,3 +770,38 @@ func Test_Job_IOLimits(t *testing.T) {
 		t.Errorf("expected output to contain %q, got %q", expectedIOLimitOutput, output)
 	}
 }
+
+func Test_Job_DelegateCGroup_expected_child_cgroups_deleted(t *testing.T) {
+	//t.Parallel()
+
+	config := JobConfig{
+		Command:          "mkdir",
+		Arguments:        []string{"/sys/fs/cgroup/child"},
+		DelegateCGroup:   true,
+		CPU:              0.5,           // half a CPU core
+		IOBytesPerSecond: 100_000_000,   // 100 MB/s
+		MemBytes:         1_000_000_000, // 1 GB
+	}
+
+	testJob := NewJob(&config)
+
+	err := testJob.Start()
+	if err != nil {
+		t.Fatalf("error starting job: %v", err)
+	}
+
+	// the output is closed once the job's cgroup has been deleted
+	output, err := io.ReadAll(testJob.Stream())
+	if err != nil {
+		t.Fatalf("error reading output: %v", err)
+	}
+
+	status := testJob.Status()
+	if status.ExitCode != 0 || status.ExitReason != "" {
+		t.Errorf("expected job to create the child cgroup and its cgroup to be deleted, got exit code %d, exit reason %q, output %q",
+			status.ExitCode, status.ExitReason, output)
+	}
+	if _, err = os.Stat(ns.GetCGroupPath(testJob.getCGroupName())); !os.IsNotExist(err) {
+		t.Errorf("expected cgroup of the job to be deleted, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package jobWorker
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxUnusedLayers is the number of unpacked tarballs, images and image archives no job uses, that are kept in each
+// cache directory of StateDir for later jobs. The least recently used ones are removed beyond it.
+var MaxUnusedLayers = 8
+
+// layerCache counts the jobs using each unpacked directory shared between jobs, so that it is never removed while used
+var layerCache = struct {
+	sync.Mutex
+	refs map[string]int
+}{refs: map[string]int{}}
+
+// acquireLayer marks dir as used, so that it is not removed until releaseLayer is called.
+// dir doesn't need to exist yet, so it can be acquired before it is unpacked.
+func acquireLayer(dir string) {
+	layerCache.Lock()
+	defer layerCache.Unlock()
+
+	layerCache.refs[dir]++
+}
+
+// releaseLayer marks dir as no longer used by the caller and removes the least recently used directories next to it
+// beyond MaxUnusedLayers, which no job uses
+func releaseLayer(dir string) {
+	layerCache.Lock()
+	defer layerCache.Unlock()
+
+	if layerCache.refs[dir]--; layerCache.refs[dir] <= 0 {
+		delete(layerCache.refs, dir)
+		// the modification time orders unused directories by their last use
+		now := time.Now()
+		_ = os.Chtimes(dir, now, now)
+	}
+
+	evictLayers(filepath.Dir(dir))
+}
+
+// evictLayers removes the least recently used directories of cacheDir beyond MaxUnusedLayers, which no job uses.
+// layerCache must be locked.
+func evictLayers(cacheDir string) {
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return
+	}
+
+	type unusedLayer struct {
+		dir     string
+		modTime time.Time
+	}
+	var unused []unusedLayer
+	for _, entry := range entries {
+		dir := filepath.Join(cacheDir, entry.Name())
+		// directories being unpacked are only renamed to their final name once complete
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".unpack-") || layerCache.refs[dir] > 0 {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		unused = append(unused, unusedLayer{dir: dir, modTime: info.ModTime()})
+	}
+	if len(unused) <= MaxUnusedLayers {
+		return
+	}
+
+	slices.SortFunc(unused, func(a, b unusedLayer) int { return a.modTime.Compare(b.modTime) })
+	for _, layer := range unused[:len(unused)-MaxUnusedLayers] {
+		if err = os.RemoveAll(layer.dir); err != nil {
+			log.Printf("error removing unused layer %s: %s\n", layer.dir, err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package jobWorker
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_releaseLayer_expected_least_recently_used_unused_layers_removed(t *testing.T) {
+	// not parallel, because the test replaces StateDir and MaxUnusedLayers
+	defer func(path string) { StateDir = path }(StateDir)
+	StateDir = t.TempDir()
+	defer func(count int) { MaxUnusedLayers = count }(MaxUnusedLayers)
+	MaxUnusedLayers = 1
+
+	layers := []string{"a", "b", "c"}
+	for i, layer := range layers {
+		layers[i] = filepath.Join(getLayersDir(), layer)
+		if err := unpackOnce(layers[i], func(unpackDir string) error { return nil }); err != nil {
+			t.Fatalf("could not unpack layer: %v", err)
+		}
+	}
+
+	exists := func(dir string) bool {
+		_, err := os.Stat(dir)
+		return err == nil
+	}
+
+	releaseLayer(layers[0])
+	// the modification time orders the unused layers
+	time.Sleep(10 * time.Millisecond)
+	releaseLayer(layers[1])
+	if exists(layers[0]) || !exists(layers[1]) {
+		t.Errorf("expected least recently used layer %s removed and %s kept", layers[0], layers[1])
+	}
+	// a layer used by a job is never removed
+	if !exists(layers[2]) {
+		t.Errorf("expected used layer %s kept", layers[2])
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	releaseLayer(layers[2])
+	if exists(layers[1]) || !exists(layers[2]) {
+		t.Errorf("expected least recently used layer %s removed and %s kept", layers[1], layers[2])
+	}
+}
+
+func Test_Job_RemoveRootFSChanges(t *testing.T) {
+	// not parallel, because the test replaces StateDir
+	defer func(path string) { StateDir = path }(StateDir)
+	StateDir = t.TempDir()
+
+	job := NewJob(&JobConfig{Command: "/bin/sh", RootFS: t.TempDir(), RootFSOverlay: true, KeepRootFSChanges: true})
+	if err := os.MkdirAll(filepath.Join(job.getStateDir(), "upper"), 0o755); err != nil {
+		t.Fatalf("could not create upper layer: %v", err)
+	}
+	if err := job.RemoveRootFSChanges(); !errors.Is(err, ErrRootFSChangesNotAvailable) {
+		t.Errorf("expected error(ErrRootFSChangesNotAvailable) before the job has completed, got %v", err)
+	}
+
+	// the job has completed and kept its changes
+	job.hasRootFSChanges = true
+	job.rootFSChangesTimer = time.AfterFunc(time.Hour, func() {})
+
+	if err := job.RemoveRootFSChanges(); err != nil {
+		t.Fatalf("could not remove rootfs changes: %v", err)
+	}
+	if _, err := os.Stat(job.getStateDir()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected job state to be removed, got %v", err)
+	}
+	if err := job.ExportRootFSChanges(io.Discard); !errors.Is(err, ErrRootFSChangesNotAvailable) {
+		t.Errorf("expected error(ErrRootFSChangesNotAvailable) once removed, got %v", err)
+	}
+}
+
+// blockingWriter blocks the first write until unblock is closed, after signalling started
+type blockingWriter struct {
+	started chan struct{}
+	unblock chan struct{}
+	once    sync.Once
+}
+
+func (writer *blockingWriter) Write(p []byte) (int, error) {
+	writer.once.Do(func() {
+		close(writer.started)
+		<-writer.unblock
+	})
+	return len(p), nil
+}
+
+func Test_Job_RemoveRootFSChanges_expected_wait_for_export(t *testing.T) {
+	// not parallel, because the test replaces StateDir
+	defer func(path string) { StateDir = path }(StateDir)
+	StateDir = t.TempDir()
+
+	job := NewJob(&JobConfig{Command: "/bin/sh", RootFS: t.TempDir(), RootFSOverlay: true, KeepRootFSChanges: true})
+	upper := filepath.Join(job.getStateDir(), "upper")
+	if err := os.MkdirAll(upper, 0o755); err != nil {
+		t.Fatalf("could not create upper layer: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(upper, "changed"), []byte("changed"), 0o644); err != nil {
+		t.Fatalf("could not write changed file: %v", err)
+	}
+	// the job has completed and kept its changes
+	job.hasRootFSChanges = true
+	job.rootFSChangesTimer = time.AfterFunc(time.Hour, func() {})
+
+	writer := &blockingWriter{started: make(chan struct{}), unblock: make(chan struct{})}
+	exported := make(chan error, 1)
+	go func() { exported <- job.ExportRootFSChanges(writer) }()
+	<-writer.started
+
+	removed := make(chan error, 1)
+	go func() { removed <- job.RemoveRootFSChanges() }()
+	select {
+	case err := <-removed:
+		t.Fatalf("expected RemoveRootFSChanges to wait for the export, returned %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(writer.unblock)
+	if err := <-exported; err != nil {
+		t.Errorf("could not export rootfs changes: %v", err)
+	}
+	if err := <-removed; err != nil {
+		t.Errorf("could not remove rootfs changes: %v", err)
+	}
+	if _, err := os.Stat(job.getStateDir()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected job state to be removed, got %v", err)
+	}
+}
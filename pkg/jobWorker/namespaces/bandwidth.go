@@ -0,0 +1,162 @@
+package namespaces
+
+import (
+	"errors"
+	"fmt"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+	"math"
+	"time"
+)
+
+const (
+	// bandwidthMinBurst is the smallest burst of bandwidth limits in bytes, large enough for GSO packets of veth pairs
+	bandwidthMinBurst = 64 << 10
+	// bandwidthBurstTime is how long a job can send or receive at full speed before it is limited
+	bandwidthBurstTime = 100 * time.Millisecond
+	// bandwidthLatency is how long packets are queued at most before they are dropped
+	bandwidthLatency = 50 * time.Millisecond
+)
+
+var (
+	ErrInvalidBandwidth = errors.New("bandwidth limits must not be negative")
+)
+
+// NetworkStats represents the traffic of a job's network interface from the job's point of view
+type NetworkStats struct {
+	RxBytes   int64
+	RxPackets int64
+	TxBytes   int64
+	TxPackets int64
+	// RxDropped and TxDropped are the packets dropped by the bandwidth limits.
+	RxDropped int64
+	TxDropped int64
+}
+
+// IsValidBandwidth returns ErrInvalidBandwidth, if ingress or egress bytes per second are negative
+func IsValidBandwidth(ingress int64, egress int64) error {
+	if ingress < 0 || egress < 0 {
+		return ErrInvalidBandwidth
+	}
+	return nil
+}
+
+// SetBandwidth limits the traffic of a job through the host end hostVeth of its veth pair in bytes per second,
+// a limit of 0 doesn't limit the direction. Traffic to the job is shaped by a token bucket qdisc on hostVeth.
+// Traffic from the job arrives on ingress of hostVeth, which can't be shaped, so it is redirected to the new
+// IFB device ifb and shaped there. Both are on the host, so that the job can't remove the limits.
+func SetBandwidth(hostVeth string, ifb string, ingress int64, egress int64) error {
+	link, err := netlink.LinkByName(hostVeth)
+	if err != nil {
+		return fmt.Errorf("error finding interface %s: %w", hostVeth, err)
+	}
+
+	if ingress > 0 {
+		if err = addTbf(link, ingress); err != nil {
+			return fmt.Errorf("error limiting ingress bandwidth of %s: %w", hostVeth, err)
+		}
+	}
+
+	if egress > 0 {
+		ifbLink := &netlink.Ifb{LinkAttrs: netlink.LinkAttrs{Name: ifb}}
+		if err = netlink.LinkAdd(ifbLink); err != nil {
+			return fmt.Errorf("error creating IFB device %s: %w", ifb, err)
+		}
+		if err = netlink.LinkSetUp(ifbLink); err != nil {
+			return fmt.Errorf("error setting IFB device %s up: %w", ifb, err)
+		}
+		if err = addTbf(ifbLink, egress); err != nil {
+			return fmt.Errorf("error limiting egress bandwidth of %s: %w", hostVeth, err)
+		}
+
+		ingressQdisc := &netlink.Ingress{
+			QdiscAttrs: netlink.QdiscAttrs{LinkIndex: link.Attrs().Index, Handle: netlink.MakeHandle(0xffff, 0), Parent: netlink.HANDLE_INGRESS},
+		}
+		if err = netlink.QdiscAdd(ingressQdisc); err != nil {
+			return fmt.Errorf("error adding ingress qdisc to %s: %w", hostVeth, err)
+		}
+
+		// u32 match u32 0 0 action mirred egress redirect dev ifb
+		filter := &netlink.U32{
+			FilterAttrs: netlink.FilterAttrs{
+				LinkIndex: link.Attrs().Index,
+				Parent:    ingressQdisc.Handle,
+				Priority:  1,
+				Protocol:  unix.ETH_P_ALL,
+			},
+			Sel: &netlink.TcU32Sel{
+				Nkeys: 1,
+				Keys:  []netlink.TcU32Key{{Mask: 0, Val: 0}},
+				Flags: netlink.TC_U32_TERMINAL,
+			},
+			Actions: []netlink.Action{netlink.NewMirredAction(ifbLink.Attrs().Index)},
+		}
+		if err = netlink.FilterAdd(filter); err != nil {
+			return fmt.Errorf("error redirecting egress of %s to %s: %w", hostVeth, ifb, err)
+		}
+	}
+	return nil
+}
+
+// addTbf adds a token bucket qdisc shaping the traffic link sends to bytesPerSecond
+func addTbf(link netlink.Link, bytesPerSecond int64) error {
+	burst := getBandwidthBurst(bytesPerSecond)
+	tbf := &netlink.Tbf{
+		QdiscAttrs: netlink.QdiscAttrs{LinkIndex: link.Attrs().Index, Handle: netlink.MakeHandle(1, 0), Parent: netlink.HANDLE_ROOT},
+		Rate:       uint64(bytesPerSecond),
+		// the buffer is the time sending the burst takes at the rate
+		Buffer: netlink.Xmittime(uint64(bytesPerSecond), burst),
+		Limit:  uint32(min(float64(bytesPerSecond)*bandwidthLatency.Seconds()+float64(burst), math.MaxUint32)),
+	}
+	return netlink.QdiscAdd(tbf)
+}
+
+// getBandwidthBurst returns the burst in bytes of bandwidth limited to bytesPerSecond
+func getBandwidthBurst(bytesPerSecond int64) uint32 {
+	return uint32(min(max(float64(bytesPerSecond)*bandwidthBurstTime.Seconds(), bandwidthMinBurst), math.MaxUint32))
+}
+
+// ReadNetworkStats returns the traffic of a job through the host end hostVeth of its veth pair and the IFB device
+// ifb shaping its egress, if not empty. Packets the job receives are sent by hostVeth and packets the job sends
+// are received by hostVeth.
+func ReadNetworkStats(hostVeth string, ifb string) (*NetworkStats, error) {
+	link, err := netlink.LinkByName(hostVeth)
+	if err != nil {
+		return nil, fmt.Errorf("error finding interface %s: %w", hostVeth, err)
+	}
+
+	stats := &NetworkStats{}
+	if linkStats := link.Attrs().Statistics; linkStats != nil {
+		stats.RxBytes, stats.RxPackets = int64(linkStats.TxBytes), int64(linkStats.TxPackets)
+		stats.TxBytes, stats.TxPackets = int64(linkStats.RxBytes), int64(linkStats.RxPackets)
+	}
+
+	if stats.RxDropped, err = readTbfDrops(link); err != nil {
+		return nil, err
+	}
+	if ifb != "" {
+		ifbLink, err := netlink.LinkByName(ifb)
+		if err != nil {
+			return nil, fmt.Errorf("error finding IFB device %s: %w", ifb, err)
+		}
+		if stats.TxDropped, err = readTbfDrops(ifbLink); err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
+// readTbfDrops returns the packets dropped by the token bucket qdisc of link, 0 if link has none
+func readTbfDrops(link netlink.Link) (int64, error) {
+	qdiscs, err := netlink.QdiscList(link)
+	if err != nil {
+		return 0, fmt.Errorf("error listing qdiscs of %s: %w", link.Attrs().Name, err)
+	}
+	for _, qdisc := range qdiscs {
+		statistics := qdisc.Attrs().Statistics
+		if _, ok := qdisc.(*netlink.Tbf); ok && statistics != nil && statistics.Queue != nil {
+			return int64(statistics.Queue.Drops), nil
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,183 @@
+package namespaces
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/sys/unix"
+	"slices"
+	"strings"
+)
+
+const (
+	// CapabilityAll stands for all capabilities in AddCapabilities and DropCapabilities
+	CapabilityAll = "ALL"
+	// capabilityPrefix is the prefix of capability names, which may be omitted
+	capabilityPrefix = "CAP_"
+)
+
+var (
+	ErrInvalidCapability = errors.New("capability must be ALL or a Linux capability such as CAP_NET_BIND_SERVICE or NET_BIND_SERVICE")
+)
+
+// capabilities maps the names of Linux capabilities to their numbers
+var capabilities = map[string]uintptr{
+	"CAP_CHOWN":              unix.CAP_CHOWN,
+	"CAP_DAC_OVERRIDE":       unix.CAP_DAC_OVERRIDE,
+	"CAP_DAC_READ_SEARCH":    unix.CAP_DAC_READ_SEARCH,
+	"CAP_FOWNER":             unix.CAP_FOWNER,
+	"CAP_FSETID":             unix.CAP_FSETID,
+	"CAP_KILL":               unix.CAP_KILL,
+	"CAP_SETGID":             unix.CAP_SETGID,
+	"CAP_SETUID":             unix.CAP_SETUID,
+	"CAP_SETPCAP":            unix.CAP_SETPCAP,
+	"CAP_LINUX_IMMUTABLE":    unix.CAP_LINUX_IMMUTABLE,
+	"CAP_NET_BIND_SERVICE":   unix.CAP_NET_BIND_SERVICE,
+	"CAP_NET_BROADCAST":      unix.CAP_NET_BROADCAST,
+	"CAP_NET_ADMIN":          unix.CAP_NET_ADMIN,
+	"CAP_NET_RAW":            unix.CAP_NET_RAW,
+	"CAP_IPC_LOCK":           unix.CAP_IPC_LOCK,
+	"CAP_IPC_OWNER":          unix.CAP_IPC_OWNER,
+	"CAP_SYS_MODULE":         unix.CAP_SYS_MODULE,
+	"CAP_SYS_RAWIO":          unix.CAP_SYS_RAWIO,
+	"CAP_SYS_CHROOT":         unix.CAP_SYS_CHROOT,
+	"CAP_SYS_PTRACE":         unix.CAP_SYS_PTRACE,
+	"CAP_SYS_PACCT":          unix.CAP_SYS_PACCT,
+	"CAP_SYS_ADMIN":          unix.CAP_SYS_ADMIN,
+	"CAP_SYS_BOOT":           unix.CAP_SYS_BOOT,
+	"CAP_SYS_NICE":           unix.CAP_SYS_NICE,
+	"CAP_SYS_RESOURCE":       unix.CAP_SYS_RESOURCE,
+	"CAP_SYS_TIME":           unix.CAP_SYS_TIME,
+	"CAP_SYS_TTY_CONFIG":     unix.CAP_SYS_TTY_CONFIG,
+	"CAP_MKNOD":              unix.CAP_MKNOD,
+	"CAP_LEASE":              unix.CAP_LEASE,
+	"CAP_AUDIT_WRITE":        unix.CAP_AUDIT_WRITE,
+	"CAP_AUDIT_CONTROL":      unix.CAP_AUDIT_CONTROL,
+	"CAP_SETFCAP":            unix.CAP_SETFCAP,
+	"CAP_MAC_OVERRIDE":       unix.CAP_MAC_OVERRIDE,
+	"CAP_MAC_ADMIN":          unix.CAP_MAC_ADMIN,
+	"CAP_SYSLOG":             unix.CAP_SYSLOG,
+	"CAP_WAKE_ALARM":         unix.CAP_WAKE_ALARM,
+	"CAP_BLOCK_SUSPEND":      unix.CAP_BLOCK_SUSPEND,
+	"CAP_AUDIT_READ":         unix.CAP_AUDIT_READ,
+	"CAP_PERFMON":            unix.CAP_PERFMON,
+	"CAP_BPF":                unix.CAP_BPF,
+	"CAP_CHECKPOINT_RESTORE": unix.CAP_CHECKPOINT_RESTORE,
+}
+
+// normalizeCapability returns the name of a capability with the CAP_ prefix in upper case
+func normalizeCapability(name string) (string, error) {
+	name = strings.ToUpper(name)
+	if name == CapabilityAll {
+		return name, nil
+	}
+	if !strings.HasPrefix(name, capabilityPrefix) {
+		name = capabilityPrefix + name
+	}
+	if _, ok := capabilities[name]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrInvalidCapability, name)
+	}
+	return name, nil
+}
+
+// GetCapabilities returns the sorted names of the capabilities in add, but not in drop. Jobs start without
+// capabilities, so drop only removes capabilities added by add, such as add ALL and drop CAP_SYS_ADMIN.
+func GetCapabilities(add []string, drop []string) ([]string, error) {
+	added, err := expandCapabilities(add)
+	if err != nil {
+		return nil, err
+	}
+	dropped, err := expandCapabilities(drop)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for capability := range added {
+		if !dropped[capability] {
+			result = append(result, capability)
+		}
+	}
+	slices.Sort(result)
+	return result, nil
+}
+
+// expandCapabilities returns the set of normalized names, ALL is expanded to all capabilities
+func expandCapabilities(names []string) (map[string]bool, error) {
+	set := map[string]bool{}
+	for _, name := range names {
+		name, err := normalizeCapability(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if name != CapabilityAll {
+			set[name] = true
+			continue
+		}
+		for capability := range capabilities {
+			set[capability] = true
+		}
+	}
+	return set, nil
+}
+
+// SetNoNewPrivs sets no_new_privs of the calling thread, so that its execs never gain privileges,
+// such as by setuid binaries or file capabilities
+func SetNoNewPrivs() error {
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("error setting no_new_privs: %w", err)
+	}
+	return nil
+}
+
+// DropBoundingCapabilities removes all capabilities not in names from the bounding set of the calling thread,
+// which limits the capabilities it and its children can ever gain, even by running setuid binaries as root
+func DropBoundingCapabilities(names []string) error {
+	keep := map[uintptr]bool{}
+	for _, name := range names {
+		keep[capabilities[name]] = true
+	}
+
+	// capabilities are numbered without gaps, so all of them are dropped once the kernel doesn't know the next one
+	for capability := uintptr(0); ; capability++ {
+		if keep[capability] {
+			continue
+		}
+		err := unix.Prctl(unix.PR_CAPBSET_DROP, capability, 0, 0, 0)
+		if errors.Is(err, unix.EINVAL) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error dropping capability %d from bounding set: %w", capability, err)
+		}
+	}
+}
+
+// SetCapabilities sets the permitted, effective, inheritable and ambient capabilities of the calling thread to names,
+// so that a non-root user keeps them across exec. The thread must have all of names in its permitted set.
+func SetCapabilities(names []string) error {
+	// version 3 capability sets are two 32-bit words
+	var data [2]unix.CapUserData
+	for _, name := range names {
+		capability := capabilities[name]
+		data[capability/32].Permitted |= 1 << (capability % 32)
+	}
+	for i := range data {
+		data[i].Effective, data[i].Inheritable = data[i].Permitted, data[i].Permitted
+	}
+
+	header := unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}
+	if err := unix.Capset(&header, &data[0]); err != nil {
+		return fmt.Errorf("error setting capabilities: %w", err)
+	}
+
+	if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil {
+		return fmt.Errorf("error clearing ambient capabilities: %w", err)
+	}
+	for _, name := range names {
+		if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_RAISE, capabilities[name], 0, 0); err != nil {
+			return fmt.Errorf("error raising ambient capability %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package namespaces
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_GetCapabilities(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		add                  []string
+		drop                 []string
+		expectedCapabilities []string
+		expectedCount        int
+		expectedErr          error
+	}{
+		{},
+		{add: []string{"net_bind_service", "CAP_KILL"}, expectedCapabilities: []string{"CAP_KILL", "CAP_NET_BIND_SERVICE"}},
+		{add: []string{"CAP_KILL"}, drop: []string{"kill"}},
+		{add: []string{"CAP_KILL"}, drop: []string{"ALL"}},
+		{add: []string{"all"}, drop: []string{"SYS_ADMIN"}, expectedCount: len(capabilities) - 1},
+		{add: []string{"CAP_FLY"}, expectedErr: ErrInvalidCapability},
+		{drop: []string{"FLY"}, expectedErr: ErrInvalidCapability},
+	}
+
+	for _, testCase := range testCases {
+		result, err := GetCapabilities(testCase.add, testCase.drop)
+		if !errors.Is(err, testCase.expectedErr) {
+			t.Errorf("add:%v drop:%v, expected error %v, got %v", testCase.add, testCase.drop, testCase.expectedErr, err)
+			continue
+		}
+		if testCase.expectedCount > 0 {
+			if len(result) != testCase.expectedCount {
+				t.Errorf("add:%v drop:%v, expected %d capabilities, got %v", testCase.add, testCase.drop, testCase.expectedCount, result)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(result, testCase.expectedCapabilities) {
+			t.Errorf("add:%v drop:%v, expected %v, got %v", testCase.add, testCase.drop, testCase.expectedCapabilities, result)
+		}
+	}
+}
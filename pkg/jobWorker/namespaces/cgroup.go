@@ -1,18 +1,53 @@
 package namespaces
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 )
 
 const (
 	CpuWeightFile  = "cpu.weight"
+	CpuMaxFile     = "cpu.max"
+	CpuSetCpusFile = "cpuset.cpus"
+	CpuSetMemsFile = "cpuset.mems"
 	MemoryHighFile = "memory.high"
-	IoWeightFile   = "io.weight"
+	MemoryMaxFile  = "memory.max"
+	// MemorySwapMaxFile is the hard limit of swap usage
+	MemorySwapMaxFile = "memory.swap.max"
+	// PidsMaxFile is the maximum number of processes in the cgroup
+	PidsMaxFile     = "pids.max"
+	PidsCurrentFile = "pids.current"
+	PidsPeakFile    = "pids.peak"
+	// PidsEventsFile counts "max" - the number of times a fork failed because of pids.max
+	PidsEventsFile = "pids.events"
+	// MemoryEventsFile counts memory events, such as "oom_kill" - the number of processes killed by the OOM killer
+	MemoryEventsFile = "memory.events"
+	IoWeightFile     = "io.weight"
+	IoMaxFile        = "io.max"
+
+	cgroupProcsFile  = "cgroup.procs"
+	cgroupKillFile   = "cgroup.kill"
+	cgroupEventsFile = "cgroup.events"
+	// cgroupThreadsFile and cgroupSubtreeControlFile are delegated with cgroupProcsFile, so that the owner of
+	// a cgroup can manage its child cgroups
+	cgroupThreadsFile        = "cgroup.threads"
+	cgroupSubtreeControlFile = "cgroup.subtree_control"
+	// cgroupControllersFile lists the controllers a cgroup's parent enabled for it
+	cgroupControllersFile = "cgroup.controllers"
+	// nsDelegateOption makes cgroup namespaces delegation boundaries, processes in a cgroup namespace can't write
+	// the interface files of the namespace's root cgroup, such as memory.max
+	nsDelegateOption = "nsdelegate"
 	/*
 		Common Permission Usages
 
@@ -30,63 +65,345 @@ const (
 
 var (
 	rootCgroupPath = "/sys/fs/cgroup"
+	// parentCgroupName is the cgroup of the server below rootCgroupPath, which contains the cgroups of all jobs,
+	// see SetupParentCGroup
+	parentCgroupName = "jobworker.slice"
+	// jobControllers are the controllers enabled for job cgroups, which limit the jobs' resources
+	jobControllers = []string{"cpu", "cpuset", "io", "memory", "pids"}
 )
 
-// AddProcess mutates the given cmd to instruct to add the PID of the started process to a given cgroup
-func AddProcess(cgroupName string, cmd *exec.Cmd) error {
-	// Add job's process to cgroup
-	cgroupDir := GetCGroupPath(cgroupName)
-	fd, err := syscall.Open(filepath.Join(cgroupDir, "cgroup.procs"), os.O_RDWR, 0)
+var (
+	ErrCGroupNotEmpty               = errors.New("cgroup still has running processes")
+	ErrCGroupDelegationNotSupported = errors.New("cgroup delegation requires cgroup2 mounted with nsdelegate at the cgroup root")
+	ErrInvalidParentCGroup          = errors.New("parent cgroup must be below the cgroup root /sys/fs/cgroup")
+	ErrControllerNotAvailable       = errors.New("cgroup controller required by jobs is not available")
+)
+
+const (
+	// killCGroupAttempts limits how many times the cgroup.procs fallback re-reads the list of processes
+	// to catch processes forked while the previous ones were being killed
+	killCGroupAttempts = 100
+	// cgroupEventsPollInterval is how often cgroup.events is checked while waiting for the cgroup to become empty
+	cgroupEventsPollInterval = 10 * time.Millisecond
+)
+
+// AddProcess mutates the given cmd to instruct clone3 to start the process in a given cgroup, so that the process
+// is limited from its first instruction. The returned cgroup directory must be closed once the process has started.
+func AddProcess(cgroupName string, cmd *exec.Cmd) (*os.File, error) {
+	// clone3 with CLONE_INTO_CGROUP expects a file descriptor of the cgroup's directory, not of its cgroup.procs
+	cgroupDir, err := os.Open(GetCGroupPath(cgroupName))
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("error opening cgroup: %w", err)
 	}
-	// This is where clone args and namespaces for user, PID and fs can be set
-	cmd.SysProcAttr.CgroupFD = fd
 
-	return nil
+	cmd.SysProcAttr.CgroupFD = int(cgroupDir.Fd())
+	cmd.SysProcAttr.UseCgroupFD = true
+	return cgroupDir, nil
 }
 
-// CreateCGroup creates a directory in the cgroup root path to signal cgroup to create a group
-// TODO in production we could check here the cgroup was created correctly, such as checking cgroup.controllers file for supported controllers
+// CreateCGroup creates a directory in the parent cgroup to signal cgroup to create a group. The parent cgroup is
+// created first, if it doesn't exist, with the controllers of jobControllers enabled for its child cgroups.
 func CreateCGroup(cgroupName string) (err error) {
-	cgroupDir := GetCGroupPath(cgroupName)
+	if err = createParentCGroup(); err != nil {
+		return err
+	}
 
-	// create a directory structure like /sys/fs/cgroup/<uuid>
+	// create a directory structure like /sys/fs/cgroup/jobworker.slice/<uuid>, job cgroups have no child cgroups,
+	// because processes can only be placed into leaves of cgroups with controllers enabled for their children
+	cgroupDir := GetCGroupPath(cgroupName)
 	log.Printf("create cgroup/<UUID>:%s", cgroupDir)
 	if err := os.Mkdir(cgroupDir, FileModeWeb); err != nil {
 		log.Printf("error creating new control group: %s", err)
 		return fmt.Errorf("error creating new control group: %w", err)
 	}
-	// create a directory structure like /sys/fs/cgroup/<uuid>/tasks
-	cgroupTasksDir := filepath.Join(cgroupDir, "tasks")
-	log.Printf("create cgroup/<UUID>/tasks:%s", cgroupTasksDir)
-	if err := os.MkdirAll(cgroupTasksDir, FileModeWeb); err != nil {
-		log.Printf("error creating new control group tasks: %s", err)
-		return fmt.Errorf("error creating new control group tasks: %w", err)
+	return nil
+}
+
+// SetupParentCGroup creates or adopts the parent cgroup of all jobs at path below the cgroup root, such as
+// /sys/fs/cgroup/jobworker.slice, enables jobControllers for job cgroups and applies limits shared by all jobs,
+// such as memory.max, by interface file.
+//
+// ErrInvalidParentCGroup is returned, if path is not below the cgroup root, and ErrControllerNotAvailable, if
+// a controller of jobControllers is not enabled for the parent cgroup by its own parent.
+func SetupParentCGroup(path string, limits map[string]string) error {
+	name, err := filepath.Rel(rootCgroupPath, filepath.Clean(path))
+	if err != nil || name == "." || name == ".." || strings.HasPrefix(name, "../") {
+		return fmt.Errorf("%w: %s", ErrInvalidParentCGroup, path)
+	}
+	parentCgroupName = name
+
+	if err = createParentCGroup(); err != nil {
+		return err
+	}
+
+	parentDir := filepath.Join(rootCgroupPath, parentCgroupName)
+	for file, value := range limits {
+		if err = os.WriteFile(filepath.Join(parentDir, file), []byte(value), FileModeWeb); err != nil {
+			return fmt.Errorf("error limiting parent control group %s: %w", file, err)
+		}
 	}
+	log.Printf("parent cgroup:%s set up with controllers:%v", parentDir, jobControllers)
 	return nil
 }
 
-// DeleteCGroup deletes a cgroup's directory signalling cgroup to delete the group
-// TODO in production before deleting a group we could check cgroup.events to ensure no processes are still running in their cgroup
-func DeleteCGroup(cgroupName string) error {
-	cgroupDir := GetCGroupPath(cgroupName)
+// createParentCGroup creates parentCgroupName below the cgroup root, if it doesn't exist, and enables jobControllers
+// in its cgroup.subtree_control, so that the limits of job cgroups are applied
+func createParentCGroup() error {
+	parentDir := filepath.Join(rootCgroupPath, parentCgroupName)
+	if err := os.MkdirAll(parentDir, FileModeWeb); err != nil {
+		return fmt.Errorf("error creating parent control group: %w", err)
+	}
 
-	cgroupTasksDir := filepath.Join(cgroupDir, "tasks")
-	log.Printf("remove cgroup/<UUID>/tasks:%s", cgroupTasksDir)
-	if err := os.RemoveAll(cgroupTasksDir); err != nil {
-		log.Printf("error removing cgroup/<UUID>/tasks: %s", err)
-		return fmt.Errorf("error removing cgroup/<UUID>/tasks: %w", err)
+	// the kernel rejects the whole write to cgroup.subtree_control with ENOENT, if one controller is not available,
+	// so check cgroup.controllers first to name the missing controller
+	content, err := os.ReadFile(filepath.Join(parentDir, cgroupControllersFile))
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", cgroupControllersFile, err)
+	}
+	available := strings.Fields(string(content))
+	for _, controller := range jobControllers {
+		if !slices.Contains(available, controller) {
+			return fmt.Errorf("%w: %s is not in %s of %s, enable it in %s of %s",
+				ErrControllerNotAvailable, controller, cgroupControllersFile, parentDir, cgroupSubtreeControlFile, filepath.Dir(parentDir))
+		}
+	}
+
+	controllers := make([]string, 0, len(jobControllers))
+	for _, controller := range jobControllers {
+		controllers = append(controllers, "+"+controller)
 	}
+	subtreeControl := []byte(strings.Join(controllers, " "))
+	if err := os.WriteFile(filepath.Join(parentDir, cgroupSubtreeControlFile), subtreeControl, FileModeWeb); err != nil {
+		return fmt.Errorf("error enabling controllers %v: %w", jobControllers, err)
+	}
+	return nil
+}
+
+// DeleteCGroup deletes a cgroup's directory signalling cgroup to delete the group, child cgroups created by a job
+// with a delegated cgroup are deleted first. The cgroup must not have running processes, see KillCGroup and
+// WaitCGroupEmpty.
+func DeleteCGroup(cgroupName string) error {
+	cgroupDir := GetCGroupPath(cgroupName)
 
 	log.Printf("remove cgroup:%s", cgroupDir)
-	if err := os.RemoveAll(cgroupDir); err != nil {
+	if err := removeCGroupDir(cgroupDir); err != nil && !errors.Is(err, os.ErrNotExist) {
 		log.Printf("error removing cgroup/<UUID>: %s", err)
 		return fmt.Errorf("error removing cgroup/<UUID>: %w", err)
 	}
 	return nil
 }
 
+// removeCGroupDir removes the child cgroups of cgroupDir depth-first and then cgroupDir itself. Files of a cgroup
+// are removed by the kernel with its directory and can't be unlinked, so only directories are removed.
+func removeCGroupDir(cgroupDir string) error {
+	entries, err := os.ReadDir(cgroupDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			if err = removeCGroupDir(filepath.Join(cgroupDir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return syscall.Rmdir(cgroupDir)
+}
+
+// KillCGroup sends SIGKILL to every process in a given cgroup (including processes forked by the job's command)
+// by writing to cgroup.kill. On kernels older than 5.14 without cgroup.kill it falls back to killing
+// every PID listed in cgroup.procs until the list is empty.
+func KillCGroup(cgroupName string) error {
+	cgroupDir := GetCGroupPath(cgroupName)
+
+	// open without O_CREATE, so that a missing cgroup.kill is detected instead of creating a regular file
+	file, err := os.OpenFile(filepath.Join(cgroupDir, cgroupKillFile), os.O_WRONLY, 0)
+	if err == nil {
+		defer file.Close()
+
+		log.Printf("kill cgroup:%s", cgroupDir)
+		if _, err = file.WriteString("1"); err != nil {
+			return fmt.Errorf("error writing %s: %w", cgroupKillFile, err)
+		}
+		return nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error opening %s: %w", cgroupKillFile, err)
+	}
+
+	log.Printf("kill cgroup processes:%s", cgroupDir)
+	for attempt := 0; attempt < killCGroupAttempts; attempt++ {
+		pids, err := readCGroupProcs(cgroupName)
+		if err != nil {
+			return err
+		}
+		if len(pids) == 0 {
+			return nil
+		}
+
+		for _, pid := range pids {
+			if err = syscall.Kill(pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+				return fmt.Errorf("error sending SIGKILL to pid:%d: %w", pid, err)
+			}
+		}
+		time.Sleep(cgroupEventsPollInterval)
+	}
+
+	return ErrCGroupNotEmpty
+}
+
+// WaitCGroupEmpty blocks until cgroup.events of a given cgroup reports "populated 0",
+// meaning that no processes are left in the cgroup and its descendants.
+//
+// ErrCGroupNotEmpty is returned, if the cgroup is still populated after timeout.
+func WaitCGroupEmpty(cgroupName string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		events, err := readFlatKeyed(filepath.Join(GetCGroupPath(cgroupName), cgroupEventsFile))
+		if err != nil {
+			return err
+		}
+		if events["populated"] == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return ErrCGroupNotEmpty
+		}
+		time.Sleep(cgroupEventsPollInterval)
+	}
+}
+
+// CheckCGroupDelegation returns ErrCGroupDelegationNotSupported, unless cgroup2 is mounted with nsdelegate at the
+// cgroup root, so that jobs can manage child cgroups of their cgroups without changing their own limits
+func CheckCGroupDelegation() error {
+	file, err := os.Open(mountInfoPath)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", mountInfoPath, err)
+	}
+	defer file.Close()
+
+	delegated, err := isCGroupDelegated(file, rootCgroupPath)
+	if err != nil {
+		return err
+	}
+	if !delegated {
+		return ErrCGroupDelegationNotSupported
+	}
+	return nil
+}
+
+// isCGroupDelegated returns true, if the last mount on mountPoint is cgroup2 with the nsdelegate superblock option.
+// The filesystem type and superblock options follow the separator of each mountinfo line:
+//
+//	30 23 0:26 / /sys/fs/cgroup rw,nosuid,nodev,noexec,relatime shared:4 - cgroup2 cgroup2 rw,nsdelegate
+func isCGroupDelegated(reader io.Reader, mountPoint string) (bool, error) {
+	delegated := false
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || unescapeMountInfo(fields[4]) != mountPoint {
+			continue
+		}
+
+		separator := slices.Index(fields, "-")
+		if separator < 0 || separator+3 >= len(fields) {
+			continue
+		}
+		// mounts stacked on the same mount point are listed in mount order, so the last one wins
+		delegated = fields[separator+1] == "cgroup2" &&
+			slices.Contains(strings.Split(fields[separator+3], ","), nsDelegateOption)
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("error reading mountinfo: %w", err)
+	}
+	return delegated, nil
+}
+
+// DelegateCGroup makes the user uid and group gid of the host the owners of a given cgroup and the interface files
+// to create child cgroups and move processes between them, but not of the cgroup's resource control files
+func DelegateCGroup(cgroupName string, uid int, gid int) error {
+	cgroupDir := GetCGroupPath(cgroupName)
+	for _, path := range []string{
+		cgroupDir,
+		filepath.Join(cgroupDir, cgroupProcsFile),
+		filepath.Join(cgroupDir, cgroupThreadsFile),
+		filepath.Join(cgroupDir, cgroupSubtreeControlFile),
+	} {
+		if err := os.Chown(path, uid, gid); err != nil {
+			return fmt.Errorf("error delegating cgroup: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReadFlatKeyedFile returns values of a cgroup interface file in the "flat keyed" format of a given cgroup,
+// such as memory.events or pids.events
+func ReadFlatKeyedFile(cgroupName string, file string) (map[string]int64, error) {
+	return readFlatKeyed(filepath.Join(GetCGroupPath(cgroupName), file))
+}
+
+// ReadIntFile returns the value of a single value cgroup interface file of a given cgroup, such as pids.current
+func ReadIntFile(cgroupName string, file string) (int64, error) {
+	content, err := os.ReadFile(filepath.Join(GetCGroupPath(cgroupName), file))
+	if err != nil {
+		return 0, fmt.Errorf("error reading %s: %w", file, err)
+	}
+
+	value, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing %s: %w", file, err)
+	}
+	return value, nil
+}
+
+// readCGroupProcs returns PIDs listed in cgroup.procs of a given cgroup
+func readCGroupProcs(cgroupName string) ([]int, error) {
+	content, err := os.ReadFile(filepath.Join(GetCGroupPath(cgroupName), cgroupProcsFile))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", cgroupProcsFile, err)
+	}
+
+	var pids []int
+	for _, line := range strings.Fields(string(content)) {
+		pid, err := strconv.Atoi(line)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", cgroupProcsFile, err)
+		}
+		pids = append(pids, pid)
+	}
+	return pids, nil
+}
+
+// readFlatKeyed parses cgroup files in the "flat keyed" format, such as cgroup.events or memory.events,
+// where each line is a key followed by an integer value
+func readFlatKeyed(path string) (map[string]int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	defer file.Close()
+
+	values := map[string]int64{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		value, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", path, err)
+		}
+		values[fields[0]] = value
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return values, nil
+}
+
 // AddResourceControl updates the resource control interface file for a given cgroup using JobOpts. The
 // three currently supported are CPU, memory and IO
 func AddResourceControl(cgroupName string, controller string, value string) (err error) {
@@ -98,7 +415,7 @@ func AddResourceControl(cgroupName string, controller string, value string) (err
 
 // GetCGroupPath returns a given cgroup's directory path identified by name
 func GetCGroupPath(cgroupName string) string {
-	return filepath.Join(rootCgroupPath, cgroupName)
+	return filepath.Join(rootCgroupPath, parentCgroupName, cgroupName)
 }
 
 const (
@@ -1,9 +1,16 @@
 package namespaces
 
 import (
+	"errors"
+	"golang.org/x/sys/unix"
 	"log"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -62,6 +69,52 @@ func Test_CGroup(t *testing.T) {
 	time.Sleep(1000)
 }
 
+func Test_AddProcess_expected_process_started_in_cgroup(t *testing.T) {
+	var statfs unix.Statfs_t
+	if err := unix.Statfs(rootCgroupPath, &statfs); err != nil || statfs.Type != unix.CGROUP2_SUPER_MAGIC {
+		t.Skipf("cgroup2 is not mounted at %s", rootCgroupPath)
+	}
+
+	cgroupName := "fakecgroup-procs"
+	if err := CreateCGroup(cgroupName); err != nil {
+		t.Fatalf("could not create cgroup: %v", err)
+	}
+	defer func() { _ = DeleteCGroup(cgroupName) }()
+
+	cmd := exec.Command("sleep", "60")
+	cmd.SysProcAttr = &syscall.SysProcAttr{}
+	cgroupDir, err := AddProcess(cgroupName, cmd)
+	if err != nil {
+		t.Fatalf("could not add process: %v", err)
+	}
+	err = cmd.Start()
+	_ = cgroupDir.Close()
+	if err != nil {
+		t.Fatalf("could not start process: %v", err)
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	pids, err := readCGroupProcs(cgroupName)
+	if err != nil {
+		t.Fatalf("could not read %s: %v", cgroupProcsFile, err)
+	}
+	if !slices.Contains(pids, cmd.Process.Pid) {
+		t.Errorf("expected pid %d in %s, got %v", cmd.Process.Pid, cgroupProcsFile, pids)
+	}
+
+	// the parent cgroup only contains job cgroups, so /proc/<pid>/cgroup shows the job's cgroup below it
+	cgroup, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(cmd.Process.Pid), "cgroup"))
+	if err != nil {
+		t.Fatalf("could not read cgroup of pid %d: %v", cmd.Process.Pid, err)
+	}
+	if expected := "/" + parentCgroupName + "/" + cgroupName; !strings.HasSuffix(strings.TrimSpace(string(cgroup)), expected) {
+		t.Errorf("expected cgroup %s, got %s", expected, cgroup)
+	}
+}
+
 // exists returns whether the given file or directory exists
 func isDirExists(path string) (bool, error) {
 	_, err := os.Stat(path)
@@ -73,3 +126,166 @@ func isDirExists(path string) (bool, error) {
 	}
 	return false, err
 }
+
+func Test_KillCGroup_without_cgroup_kill_expected_kill_processes_from_cgroup_procs(t *testing.T) {
+	// not parallel, because the test replaces rootCgroupPath with a fake cgroup hierarchy
+	defer func(path string) { rootCgroupPath = path }(rootCgroupPath)
+	rootCgroupPath = t.TempDir()
+
+	cgroupName := "fakecgroup"
+	if err := os.MkdirAll(GetCGroupPath(cgroupName), FileModeWeb); err != nil {
+		t.Fatalf("could not create fake cgroup: %v", err)
+	}
+
+	cmd := exec.Command("sleep", "60")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("could not start process: %v", err)
+	}
+
+	procsFile := filepath.Join(GetCGroupPath(cgroupName), cgroupProcsFile)
+	if err := os.WriteFile(procsFile, []byte(strconv.Itoa(cmd.Process.Pid)+"\n"), FileModeWeb); err != nil {
+		t.Fatalf("could not write %s: %v", cgroupProcsFile, err)
+	}
+
+	// a fake cgroup.procs is not updated by the kernel, so empty it once the process has been killed
+	exited := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		_ = os.WriteFile(procsFile, nil, FileModeWeb)
+		exited <- err
+	}()
+
+	if err := KillCGroup(cgroupName); err != nil {
+		t.Errorf("could not kill cgroup: %v", err)
+	}
+
+	if err := <-exited; err == nil || err.Error() != "signal: killed" {
+		t.Errorf("expected process to be killed, got: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(GetCGroupPath(cgroupName), cgroupKillFile)); !os.IsNotExist(err) {
+		t.Errorf("expected %s not to be created, got: %v", cgroupKillFile, err)
+	}
+}
+
+func Test_WaitCGroupEmpty(t *testing.T) {
+	// not parallel, because the test replaces rootCgroupPath with a fake cgroup hierarchy
+	defer func(path string) { rootCgroupPath = path }(rootCgroupPath)
+	rootCgroupPath = t.TempDir()
+
+	cgroupName := "fakecgroup"
+	if err := os.MkdirAll(GetCGroupPath(cgroupName), FileModeWeb); err != nil {
+		t.Fatalf("could not create fake cgroup: %v", err)
+	}
+
+	eventsFile := filepath.Join(GetCGroupPath(cgroupName), cgroupEventsFile)
+	if err := os.WriteFile(eventsFile, []byte("populated 1\nfrozen 0\n"), FileModeWeb); err != nil {
+		t.Fatalf("could not write %s: %v", cgroupEventsFile, err)
+	}
+
+	if err := WaitCGroupEmpty(cgroupName, 50*time.Millisecond); !errors.Is(err, ErrCGroupNotEmpty) {
+		t.Errorf("expected error(ErrCGroupNotEmpty), got: %v", err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = os.WriteFile(eventsFile, []byte("populated 0\nfrozen 0\n"), FileModeWeb)
+	}()
+
+	if err := WaitCGroupEmpty(cgroupName, 5*time.Second); err != nil {
+		t.Errorf("expected cgroup to become empty, got: %v", err)
+	}
+}
+
+func Test_isCGroupDelegated(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		mountInfo string
+		expected  bool
+	}{
+		{
+			name:      "cgroup2 with nsdelegate",
+			mountInfo: "30 23 0:26 / /sys/fs/cgroup rw,nosuid,nodev,noexec,relatime shared:4 - cgroup2 cgroup2 rw,nsdelegate,memory_recursiveprot\n",
+			expected:  true,
+		},
+		{
+			name:      "cgroup2 without nsdelegate",
+			mountInfo: "30 23 0:26 / /sys/fs/cgroup rw,nosuid,nodev,noexec,relatime shared:4 - cgroup2 cgroup2 rw\n",
+		},
+		{
+			name: "hybrid hierarchy with cgroup2 below the cgroup root",
+			mountInfo: "30 23 0:26 / /sys/fs/cgroup ro,nosuid,nodev,noexec shared:4 - tmpfs tmpfs ro,mode=755\n" +
+				"31 30 0:27 / /sys/fs/cgroup/unified rw,nosuid,nodev,noexec,relatime shared:5 - cgroup2 cgroup2 rw,nsdelegate\n",
+		},
+		{
+			name: "last mount stacked on the cgroup root wins",
+			mountInfo: "30 23 0:26 / /sys/fs/cgroup rw,relatime shared:4 - cgroup2 cgroup2 rw,nsdelegate\n" +
+				"31 30 0:27 / /sys/fs/cgroup rw,relatime - cgroup2 cgroup2 rw\n",
+		},
+		{
+			name:      "optional fields before the separator",
+			mountInfo: "30 23 0:26 / /sys/fs/cgroup rw,relatime shared:4 master:1 - cgroup2 cgroup2 rw,nsdelegate\n",
+			expected:  true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		delegated, err := isCGroupDelegated(strings.NewReader(testCase.mountInfo), "/sys/fs/cgroup")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", testCase.name, err)
+			continue
+		}
+		if delegated != testCase.expected {
+			t.Errorf("%s: expected delegated %t, got %t", testCase.name, testCase.expected, delegated)
+		}
+	}
+}
+
+func Test_SetupParentCGroup(t *testing.T) {
+	// not parallel, because the test replaces rootCgroupPath with a fake cgroup hierarchy
+	defer func(path, name string) { rootCgroupPath, parentCgroupName = path, name }(rootCgroupPath, parentCgroupName)
+	rootCgroupPath = t.TempDir()
+
+	parentDir := filepath.Join(rootCgroupPath, "jobs.slice")
+	if err := os.MkdirAll(parentDir, FileModeWeb); err != nil {
+		t.Fatalf("could not create fake parent cgroup: %v", err)
+	}
+	controllersFile := filepath.Join(parentDir, cgroupControllersFile)
+
+	// a fake cgroup.controllers stands in for the controllers the parent's parent enabled
+	if err := os.WriteFile(controllersFile, []byte("cpu io memory pids\n"), FileModeWeb); err != nil {
+		t.Fatalf("could not write %s: %v", cgroupControllersFile, err)
+	}
+	err := SetupParentCGroup(parentDir, nil)
+	if !errors.Is(err, ErrControllerNotAvailable) || !strings.Contains(err.Error(), "cpuset") {
+		t.Errorf("expected error(ErrControllerNotAvailable) naming cpuset, got: %v", err)
+	}
+
+	if err = os.WriteFile(controllersFile, []byte("cpuset cpu io memory hugetlb pids\n"), FileModeWeb); err != nil {
+		t.Fatalf("could not write %s: %v", cgroupControllersFile, err)
+	}
+	if err = SetupParentCGroup(parentDir, map[string]string{MemoryMaxFile: "1000000", PidsMaxFile: "max"}); err != nil {
+		t.Fatalf("could not set up parent cgroup: %v", err)
+	}
+
+	if path := GetCGroupPath("job"); path != filepath.Join(parentDir, "job") {
+		t.Errorf("expected job cgroups in %s, got %s", parentDir, path)
+	}
+	for file, expected := range map[string]string{
+		cgroupSubtreeControlFile: "+cpu +cpuset +io +memory +pids",
+		MemoryMaxFile:            "1000000",
+		PidsMaxFile:              "max",
+	} {
+		if content, err := os.ReadFile(filepath.Join(parentDir, file)); err != nil || string(content) != expected {
+			t.Errorf("expected %s to be %q, got %q, %v", file, expected, content, err)
+		}
+	}
+
+	for _, path := range []string{rootCgroupPath, filepath.Dir(rootCgroupPath), "/tmp/jobs.slice"} {
+		if err = SetupParentCGroup(path, nil); !errors.Is(err, ErrInvalidParentCGroup) {
+			t.Errorf("path:%s, expected error(ErrInvalidParentCGroup), got: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package namespaces
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrMountNotFound  = errors.New("no mount found for path")
+	ErrNotBlockDevice = errors.New("path is not backed by a block device")
+)
+
+var (
+	mountInfoPath = "/proc/self/mountinfo"
+	sysBlockPath  = "/sys/dev/block"
+)
+
+// GetBlockDevice returns the "major:minor" number of the block device backing a given path, as expected by io.max.
+// The device is looked up from /proc/self/mountinfo, partitions are resolved to their whole disk
+// because io.max only accepts whole disks.
+//
+// ErrNotBlockDevice is returned, if the path is on a filesystem without a block device, such as tmpfs, overlayfs or
+// a network filesystem, which have major number 0, or on a device missing from /sys/dev/block.
+func GetBlockDevice(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("error resolving path %s: %w", path, err)
+	}
+
+	file, err := os.Open(mountInfoPath)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %w", mountInfoPath, err)
+	}
+	defer file.Close()
+
+	device, err := parseMountInfo(file, path)
+	if err != nil {
+		return "", err
+	}
+	if !isBlockDevice(device) {
+		return "", fmt.Errorf("%w: %s is on device %s", ErrNotBlockDevice, path, device)
+	}
+
+	return getWholeDisk(device), nil
+}
+
+// isBlockDevice returns true if "major:minor" is a block device io.max accepts, anonymous devices have major number 0
+func isBlockDevice(device string) bool {
+	if strings.HasPrefix(device, "0:") {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(sysBlockPath, device))
+	return err == nil
+}
+
+// parseMountInfo returns the "major:minor" field of the mount with the longest mount point containing path.
+// Each mountinfo line looks like:
+//
+//	36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+func parseMountInfo(reader io.Reader, path string) (string, error) {
+	device := ""
+	longestMountPoint := -1
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+
+		mountPoint := unescapeMountInfo(fields[4])
+		if !isPathUnder(path, mountPoint) {
+			continue
+		}
+
+		// mounts stacked on the same mount point are listed in mount order, so the last one wins
+		if len(mountPoint) >= longestMountPoint {
+			longestMountPoint = len(mountPoint)
+			device = fields[2]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("error reading mountinfo: %w", err)
+	}
+
+	if device == "" {
+		return "", fmt.Errorf("%w: %s", ErrMountNotFound, path)
+	}
+	return device, nil
+}
+
+// getWholeDisk returns "major:minor" of the disk a partition belongs to, or the device itself if it is not a partition
+func getWholeDisk(device string) string {
+	devicePath, err := filepath.EvalSymlinks(filepath.Join(sysBlockPath, device))
+	if err != nil {
+		return device
+	}
+
+	if _, err = os.Stat(filepath.Join(devicePath, "partition")); err != nil {
+		return device
+	}
+
+	disk, err := os.ReadFile(filepath.Join(filepath.Dir(devicePath), "dev"))
+	if err != nil {
+		return device
+	}
+	return strings.TrimSpace(string(disk))
+}
+
+// isPathUnder returns true if path is the mount point itself or is located under it
+func isPathUnder(path, mountPoint string) bool {
+	if mountPoint == "/" || path == mountPoint {
+		return true
+	}
+	return strings.HasPrefix(path, mountPoint+"/")
+}
+
+// unescapeMountInfo decodes octal escapes (such as \040 for space) used by the kernel in mountinfo paths
+func unescapeMountInfo(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+3 < len(value) {
+			var char byte
+			if _, err := fmt.Sscanf(value[i+1:i+4], "%03o", &char); err == nil {
+				builder.WriteByte(char)
+				i += 3
+				continue
+			}
+		}
+		builder.WriteByte(value[i])
+	}
+	return builder.String()
+}
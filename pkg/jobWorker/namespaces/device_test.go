@@ -0,0 +1,81 @@
+package namespaces
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testMountInfo = `22 1 254:1 / / rw,relatime shared:1 - ext4 /dev/vda1 rw
+23 22 0:22 / /proc rw,relatime shared:2 - proc proc rw
+24 22 8:17 / /data rw,relatime shared:3 - xfs /dev/sdb1 rw
+25 24 8:33 / /data/fast\040disk rw,relatime shared:4 - xfs /dev/sdc1 rw
+26 24 8:49 / /data/fast\040disk rw,relatime shared:5 - xfs /dev/sdd1 rw
+27 22 8:1 / /database rw,relatime shared:6 - ext4 /dev/sda1 rw
+`
+
+func Test_parseMountInfo(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		path           string
+		expectedDevice string
+	}{
+		{path: "/", expectedDevice: "254:1"},
+		{path: "/tmp/file1", expectedDevice: "254:1"},
+		{path: "/proc/self", expectedDevice: "0:22"},
+		{path: "/data", expectedDevice: "8:17"},
+		{path: "/data/set", expectedDevice: "8:17"},
+		// the last mount stacked on the same mount point wins
+		{path: "/data/fast disk/set", expectedDevice: "8:49"},
+		// "/database" must not match "/data" mount
+		{path: "/database/db", expectedDevice: "8:1"},
+	}
+
+	for _, testCase := range testCases {
+		device, err := parseMountInfo(strings.NewReader(testMountInfo), testCase.path)
+		if err != nil {
+			t.Errorf("path:%s, unexpected error: %v", testCase.path, err)
+			continue
+		}
+		if device != testCase.expectedDevice {
+			t.Errorf("path:%s, expected device %s, got %s", testCase.path, testCase.expectedDevice, device)
+		}
+	}
+}
+
+func Test_parseMountInfo_without_mounts_expected_ErrMountNotFound(t *testing.T) {
+	t.Parallel()
+
+	if _, err := parseMountInfo(strings.NewReader(""), "/"); !errors.Is(err, ErrMountNotFound) {
+		t.Errorf("expected error(ErrMountNotFound), got: %v", err)
+	}
+}
+
+func Test_isBlockDevice(t *testing.T) {
+	// not parallel, because the test replaces sysBlockPath
+	defer func(path string) { sysBlockPath = path }(sysBlockPath)
+	sysBlockPath = t.TempDir()
+
+	if err := os.Mkdir(filepath.Join(sysBlockPath, "8:1"), 0o755); err != nil {
+		t.Fatalf("could not create device: %v", err)
+	}
+
+	testCases := []struct {
+		device   string
+		expected bool
+	}{
+		{device: "8:1", expected: true},
+		// tmpfs, overlayfs and network filesystems have anonymous devices
+		{device: "0:22", expected: false},
+		{device: "8:17", expected: false},
+	}
+
+	for _, testCase := range testCases {
+		if isBlockDevice(testCase.device) != testCase.expected {
+			t.Errorf("device:%s, expected %t", testCase.device, testCase.expected)
+		}
+	}
+}
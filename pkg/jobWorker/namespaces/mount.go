@@ -1,15 +1,37 @@
 package namespaces
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"syscall"
 )
 
-func PivotRoot(rootfs string) error {
-	putold := filepath.Join(rootfs, "/.pivot_root")
+// Overlay describes an overlay filesystem stacking read-only LowerDirs, the first being the top most layer,
+// with a writable UpperDir receiving all changes
+type Overlay struct {
+	LowerDirs []string
+	UpperDir  string
+	// WorkDir is an empty directory on the same filesystem as UpperDir, used by overlayfs internally.
+	WorkDir string
+}
 
+// devices are the host devices bind mounted into the minimal /dev of a root filesystem
+var devices = []string{"null", "zero", "full", "random", "urandom", "tty"}
+
+// devSymlinks are the symlinks created in the minimal /dev of a root filesystem
+var devSymlinks = map[string]string{
+	"fd":     "/proc/self/fd",
+	"stdin":  "/proc/self/fd/0",
+	"stdout": "/proc/self/fd/1",
+	"stderr": "/proc/self/fd/2",
+}
+
+// PivotRoot changes the root filesystem of the current mount namespace to rootfs and detaches the old root,
+// so that the host filesystem is no longer reachable. Mounts below rootfs are carried over to the new root.
+func PivotRoot(rootfs string) error {
 	// bind mount rootfs to itself - this is a slight hack needed to satisfy the
 	// pivot_root requirement that rootfs and putold must not be on the same
 	// filesystem as the current root
@@ -17,47 +39,118 @@ func PivotRoot(rootfs string) error {
 		return fmt.Errorf("error (syscall.Mount) %s", err)
 	}
 
-	// create rootfs/.pivot_root as path for old_root
-	pivotDir := filepath.Join(rootfs, ".pivot_root")
-	if err := os.Mkdir(pivotDir, FileModeEveryone); err != nil {
-		return fmt.Errorf("error (syscall.MkdirAll) %s", err)
+	// create rootfs/.pivot_root* as path for old_root, the name is unique so that jobs can share a rootfs directory
+	putold, err := os.MkdirTemp(rootfs, ".pivot_root")
+	if err != nil {
+		return fmt.Errorf("error (os.MkdirTemp) %s", err)
 	}
 
 	// call pivot_root
-	if err := syscall.PivotRoot(rootfs, pivotDir); err != nil {
+	if err = syscall.PivotRoot(rootfs, putold); err != nil {
 		return fmt.Errorf("error (syscall.PivotRoot(%s, %s)) - %s", rootfs, putold, err)
 	}
 
 	// ensure current working directory is set to new root
-	if err := os.Chdir("/"); err != nil {
+	if err = os.Chdir("/"); err != nil {
 		return fmt.Errorf("error (syscall.Chdir) %s", err)
+	}
 
+	// path to putold now changed, update
+	putold = filepath.Join("/", filepath.Base(putold))
+	// umount putold with all submounts, now we have only mounts that we mounted ourselves
+	if err = syscall.Unmount(putold, syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("error (syscall.Unmount) %s", err)
 	}
-	// path to pivot root now changed, update
-	pivotDir = filepath.Join("/", ".pivot_root")
-	// umount rootfs/.pivot_root(which is now /.pivot_root) with all submounts
-	// now we have only mounts that we mounted ourselves in `mount`
-	if err := syscall.Unmount(pivotDir, syscall.MNT_DETACH); err != nil {
-		return fmt.Errorf("unmount pivot_root dir %v", err)
+
+	// remove putold, os.Remove fails rather than deleting host files if the old root is somehow still mounted
+	if err = os.Remove(putold); err != nil {
+		return fmt.Errorf("error (os.Remove) %s", err)
 	}
 
-	// umount putold, which now lives at /.pivot_root
-	putold = "/.pivot_root"
-	if err := syscall.Unmount(putold, syscall.MNT_DETACH); err != nil {
-		return fmt.Errorf("error (syscall.Unmount) %s", err)
+	return nil
+}
+
+// MountDev - mount a minimal /dev into rootfs: a tmpfs with the host's null, zero, full, random, urandom and tty
+// devices bind mounted and /dev/fd and /dev/std* symlinks, /dev/shm is mounted by MountShm.
+// MountDev must be called before PivotRoot, while the host devices are still reachable.
+func MountDev(rootfs string) error {
+	dev := filepath.Join(rootfs, "dev")
+	if err := os.MkdirAll(dev, FileModeWeb); err != nil {
+		return fmt.Errorf("error creating %s: %w", dev, err)
+	}
+
+	if err := syscall.Mount("tmpfs", dev, "tmpfs", syscall.MS_NOSUID|syscall.MS_STRICTATIME, "mode=755,size=65536k"); err != nil {
+		return fmt.Errorf("error mounting %s: %w", dev, err)
+	}
+
+	for _, device := range devices {
+		source := filepath.Join("/dev", device)
+		if _, err := os.Stat(source); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		target := filepath.Join(dev, device)
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_RDONLY, FileModeWeb)
+		if err != nil {
+			return fmt.Errorf("error creating %s: %w", target, err)
+		}
+		_ = file.Close()
+
+		if err = syscall.Mount(source, target, "", syscall.MS_BIND, ""); err != nil {
+			return fmt.Errorf("error mounting %s: %w", target, err)
+		}
+	}
+
+	for name, target := range devSymlinks {
+		if err := os.Symlink(target, filepath.Join(dev, name)); err != nil {
+			return fmt.Errorf("error creating %s: %w", filepath.Join(dev, name), err)
+		}
+	}
+	return nil
+}
+
+// MountShm - mount an empty tmpfs of sizeBytes at rootfs/dev/shm for the POSIX shared memory of the job's IPC namespace.
+// Pages of the tmpfs are charged to the memory cgroup of the process writing them, so they count against the job's
+// memory limits. rootfs/dev/shm is only created if rootfs is not the host's root filesystem.
+func MountShm(rootfs string, sizeBytes int64) error {
+	shm := filepath.Join(rootfs, "dev", "shm")
+	if rootfs != "/" {
+		if err := os.MkdirAll(shm, FileModeWeb); err != nil {
+			return fmt.Errorf("error creating %s: %w", shm, err)
+		}
 	}
 
-	// remove putold
-	if err := os.RemoveAll(putold); err != nil {
-		return fmt.Errorf("error (syscall.RemoveAll) %s", err)
+	options := fmt.Sprintf("mode=1777,size=%d", sizeBytes)
+	if err := syscall.Mount("shm", shm, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, options); err != nil {
+		return fmt.Errorf("error mounting %s: %w", shm, err)
 	}
+	return nil
+}
+
+// MountOverlay - mount overlay filesystem at target.
+// Whiteouts of deleted files are character devices 0/0 in UpperDir, opaque directories have the
+// user.overlay.opaque extended attribute.
+func MountOverlay(target string, overlay *Overlay) error {
+	// userxattr keeps overlayfs metadata in user.* extended attributes, trusted.* can't be set in a user namespace
+	options := fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s,userxattr",
+		strings.Join(overlay.LowerDirs, ":"), overlay.UpperDir, overlay.WorkDir)
 
+	if err := syscall.Mount("overlay", target, "overlay", 0, options); err != nil {
+		return fmt.Errorf("error mounting overlay: %w", err)
+	}
 	return nil
 }
 
-// MountProc - mount proc filesystem at /proc.
-func MountProc() error {
-	err := syscall.Mount("proc", "/proc", "proc", 0, "")
+// MountProc - mount proc filesystem at rootfs/proc.
+// Inside a user namespace the kernel only allows proc to be mounted while the host's proc is still visible,
+// so MountProc must be called before PivotRoot.
+func MountProc(rootfs string) error {
+	proc := filepath.Join(rootfs, "proc")
+	if err := os.MkdirAll(proc, FileModeWeb); err != nil {
+		return fmt.Errorf("error creating %s: %w", proc, err)
+	}
+
+	err := syscall.Mount("proc", proc, "proc", 0, "")
 	if err != nil {
 		return fmt.Errorf("error mounting proc: %w", err)
 	}
@@ -73,3 +166,46 @@ func UnmountProc() error {
 
 	return nil
 }
+
+// MountSys - mount a read-only sysfs at rootfs/sys, same as MountProc it must be called before PivotRoot.
+func MountSys(rootfs string) error {
+	sys := filepath.Join(rootfs, "sys")
+	if err := os.MkdirAll(sys, FileModeWeb); err != nil {
+		return fmt.Errorf("error creating %s: %w", sys, err)
+	}
+
+	flags := uintptr(syscall.MS_RDONLY | syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC)
+	if err := syscall.Mount("sysfs", sys, "sysfs", flags, ""); err != nil {
+		return fmt.Errorf("error mounting sysfs: %w", err)
+	}
+	return nil
+}
+
+// MountCGroup - mount the cgroup2 filesystem at rootfs/sys/fs/cgroup, which shows the root of the current cgroup
+// namespace as its root. The mount is read-only unless writable is true. Same as MountProc it must be called
+// before PivotRoot.
+func MountCGroup(rootfs string, writable bool) error {
+	cgroup := filepath.Join(rootfs, "sys", "fs", "cgroup")
+
+	flags := uintptr(syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC)
+	if !writable {
+		flags |= syscall.MS_RDONLY
+	}
+	if err := syscall.Mount("cgroup2", cgroup, "cgroup2", flags, ""); err != nil {
+		return fmt.Errorf("error mounting cgroup2: %w", err)
+	}
+	return nil
+}
+
+// MountTmp - mount an empty tmpfs at rootfs/tmp.
+func MountTmp(rootfs string) error {
+	tmp := filepath.Join(rootfs, "tmp")
+	if err := os.MkdirAll(tmp, FileModeWeb); err != nil {
+		return fmt.Errorf("error creating %s: %w", tmp, err)
+	}
+
+	if err := syscall.Mount("tmpfs", tmp, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "mode=1777"); err != nil {
+		return fmt.Errorf("error mounting tmpfs: %w", err)
+	}
+	return nil
+}
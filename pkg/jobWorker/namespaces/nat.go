@@ -0,0 +1,233 @@
+package namespaces
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/google/nftables"
+	"github.com/google/nftables/binaryutil"
+	"github.com/google/nftables/expr"
+	"golang.org/x/sys/unix"
+	"net"
+)
+
+const (
+	// natTableName is the nftables table of the NAT and port forwarding rules of jobs
+	natTableName = "jobworker"
+	// interfaceNameLength is the length of interface names matched by nftables, including the NUL padding
+	interfaceNameLength = unix.IFNAMSIZ
+)
+
+// natTable is the nftables table of the NAT and port forwarding rules of jobs
+var natTable = &nftables.Table{Name: natTableName, Family: nftables.TableFamilyIPv4}
+
+// natChains are the chains of natTable, prerouting and output forward ports of the host to jobs from other hosts
+// and from the host itself, postrouting masquerades traffic of jobs leaving the bridge
+var natChains = struct {
+	prerouting  *nftables.Chain
+	output      *nftables.Chain
+	postrouting *nftables.Chain
+}{
+	prerouting: &nftables.Chain{
+		Name: "prerouting", Table: natTable, Type: nftables.ChainTypeNAT,
+		Hooknum: nftables.ChainHookPrerouting, Priority: nftables.ChainPriorityNATDest,
+	},
+	output: &nftables.Chain{
+		Name: "output", Table: natTable, Type: nftables.ChainTypeNAT,
+		Hooknum: nftables.ChainHookOutput, Priority: nftables.ChainPriorityNATDest,
+	},
+	postrouting: &nftables.Chain{
+		Name: "postrouting", Table: natTable, Type: nftables.ChainTypeNAT,
+		Hooknum: nftables.ChainHookPostrouting, Priority: nftables.ChainPriorityNATSource,
+	},
+}
+
+// filterChains are the filter chains of natTable isolating the host from jobs on the bridge. Prerouting runs before
+// conntrack translates replies, so that it only sees the addresses jobs sent packets to, and input sees the packets
+// of jobs addressed to the host, the packets to forwarded ports are addressed to jobs once translated by prerouting.
+var filterChains = struct {
+	prerouting *nftables.Chain
+	input      *nftables.Chain
+}{
+	prerouting: &nftables.Chain{
+		Name: "filter-prerouting", Table: natTable, Type: nftables.ChainTypeFilter,
+		Hooknum: nftables.ChainHookPrerouting, Priority: nftables.ChainPriorityRaw,
+	},
+	input: &nftables.Chain{
+		Name: "filter-input", Table: natTable, Type: nftables.ChainTypeFilter,
+		Hooknum: nftables.ChainHookInput, Priority: nftables.ChainPriorityFilter,
+	},
+}
+
+// loopbackSubnet is the subnet of localhost, traffic from localhost forwarded to jobs is masqueraded,
+// so that jobs reply to the bridge instead of their own loopback interface
+var loopbackSubnet = &net.IPNet{IP: net.IPv4(127, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)}
+
+// SetupNAT replaces the nftables table of jobs with empty port forwarding chains and masquerades traffic
+// from localhost to the bridge. Traffic of subnet leaving the host through other interfaces than the bridge
+// is masqueraded too, if masquerade is true.
+//
+// Jobs can't reach services of the host: packets from the bridge to 127.0.0.0/8, which route_localnet would route
+// to services listening on localhost only, and new connections from the bridge to any address of the host, such as
+// the gateway, are dropped. Only ports forwarded to jobs can be reached from the bridge.
+func SetupNAT(bridge string, subnet *net.IPNet, masquerade bool) error {
+	conn, err := nftables.New()
+	if err != nil {
+		return fmt.Errorf("error connecting to nftables: %w", err)
+	}
+
+	// rules of jobs of a previous run of the server are removed with the table
+	if table, err := conn.ListTableOfFamily(natTableName, nftables.TableFamilyIPv4); err == nil {
+		conn.DelTable(table)
+	}
+	conn.AddTable(natTable)
+	conn.AddChain(natChains.prerouting)
+	conn.AddChain(natChains.output)
+	conn.AddChain(natChains.postrouting)
+	conn.AddChain(filterChains.prerouting)
+	conn.AddChain(filterChains.input)
+
+	// iifname bridge ip daddr 127.0.0.0/8 drop
+	conn.AddRule(&nftables.Rule{
+		Table: natTable,
+		Chain: filterChains.prerouting,
+		Exprs: append(append(matchInputInterface(bridge),
+			matchDestinationSubnet(loopbackSubnet)...), &expr.Verdict{Kind: expr.VerdictDrop}),
+	})
+	// iifname bridge ct state != established,related drop
+	conn.AddRule(&nftables.Rule{
+		Table: natTable,
+		Chain: filterChains.input,
+		Exprs: append(matchInputInterface(bridge),
+			&expr.Ct{Register: 1, Key: expr.CtKeySTATE},
+			&expr.Bitwise{
+				SourceRegister: 1, DestRegister: 1, Len: 4,
+				Mask: binaryutil.NativeEndian.PutUint32(expr.CtStateBitESTABLISHED | expr.CtStateBitRELATED),
+				Xor:  binaryutil.NativeEndian.PutUint32(0),
+			},
+			&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: binaryutil.NativeEndian.PutUint32(0)},
+			&expr.Verdict{Kind: expr.VerdictDrop}),
+	})
+
+	// ip saddr 127.0.0.0/8 oifname bridge masquerade
+	conn.AddRule(&nftables.Rule{
+		Table: natTable,
+		Chain: natChains.postrouting,
+		Exprs: append(append(matchSourceSubnet(loopbackSubnet),
+			matchOutputInterface(bridge, expr.CmpOpEq)...), &expr.Masq{}),
+	})
+	if masquerade {
+		// ip saddr subnet oifname != bridge masquerade
+		conn.AddRule(&nftables.Rule{
+			Table: natTable,
+			Chain: natChains.postrouting,
+			Exprs: append(append(matchSourceSubnet(subnet),
+				matchOutputInterface(bridge, expr.CmpOpNeq)...), &expr.Masq{}),
+		})
+	}
+
+	if err = conn.Flush(); err != nil {
+		return fmt.Errorf("error setting up nftables table %s: %w", natTableName, err)
+	}
+	return nil
+}
+
+// AddPortForwarding forwards the host port of mapping on all addresses of the host to the container port
+// of mapping at address, the rules are tagged with id to be deleted by DeletePortForwarding
+func AddPortForwarding(id string, mapping PortMapping, address net.IP) error {
+	conn, err := nftables.New()
+	if err != nil {
+		return fmt.Errorf("error connecting to nftables: %w", err)
+	}
+
+	protocol := byte(unix.IPPROTO_TCP)
+	if mapping.GetProtocol() == ProtocolUDP {
+		protocol = unix.IPPROTO_UDP
+	}
+
+	// meta l4proto protocol th dport host port fib daddr type local dnat to address:container port
+	exprs := []expr.Any{
+		&expr.Meta{Key: expr.MetaKeyL4PROTO, Register: 1},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{protocol}},
+		&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseTransportHeader, Offset: 2, Len: 2},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: binaryutil.BigEndian.PutUint16(mapping.HostPort)},
+		&expr.Fib{Register: 1, FlagDADDR: true, ResultADDRTYPE: true},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: binaryutil.NativeEndian.PutUint32(unix.RTN_LOCAL)},
+		&expr.Immediate{Register: 1, Data: address.To4()},
+		&expr.Immediate{Register: 2, Data: binaryutil.BigEndian.PutUint16(mapping.ContainerPort)},
+		&expr.NAT{Type: expr.NATTypeDestNAT, Family: unix.NFPROTO_IPV4, RegAddrMin: 1, RegProtoMin: 2, Specified: true},
+	}
+	for _, chain := range []*nftables.Chain{natChains.prerouting, natChains.output} {
+		conn.AddRule(&nftables.Rule{Table: natTable, Chain: chain, Exprs: exprs, UserData: []byte(id)})
+	}
+
+	if err = conn.Flush(); err != nil {
+		return fmt.Errorf("error forwarding port %s: %w", &mapping, err)
+	}
+	return nil
+}
+
+// DeletePortForwarding deletes all port forwarding rules tagged with id
+func DeletePortForwarding(id string) error {
+	conn, err := nftables.New()
+	if err != nil {
+		return fmt.Errorf("error connecting to nftables: %w", err)
+	}
+
+	for _, chain := range []*nftables.Chain{natChains.prerouting, natChains.output} {
+		rules, err := conn.GetRules(natTable, chain)
+		if err != nil {
+			return fmt.Errorf("error listing port forwarding rules: %w", err)
+		}
+		for _, rule := range rules {
+			if bytes.Equal(rule.UserData, []byte(id)) {
+				if err = conn.DelRule(rule); err != nil {
+					return fmt.Errorf("error deleting port forwarding rule: %w", err)
+				}
+			}
+		}
+	}
+
+	if err = conn.Flush(); err != nil {
+		return fmt.Errorf("error deleting port forwarding rules: %w", err)
+	}
+	return nil
+}
+
+// matchSourceSubnet returns expressions matching packets from subnet
+func matchSourceSubnet(subnet *net.IPNet) []expr.Any {
+	return matchSubnet(subnet, 12)
+}
+
+// matchDestinationSubnet returns expressions matching packets to subnet
+func matchDestinationSubnet(subnet *net.IPNet) []expr.Any {
+	return matchSubnet(subnet, 16)
+}
+
+// matchSubnet returns expressions matching packets with the IPv4 address at offset of the IP header in subnet
+func matchSubnet(subnet *net.IPNet, offset uint32) []expr.Any {
+	return []expr.Any{
+		&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: offset, Len: 4},
+		&expr.Bitwise{SourceRegister: 1, DestRegister: 1, Len: 4, Mask: subnet.Mask, Xor: make([]byte, 4)},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: subnet.IP.To4()},
+	}
+}
+
+// matchInputInterface returns expressions matching packets arriving on the interface name
+func matchInputInterface(name string) []expr.Any {
+	data := make([]byte, interfaceNameLength)
+	copy(data, name)
+	return []expr.Any{
+		&expr.Meta{Key: expr.MetaKeyIIFNAME, Register: 1},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: data},
+	}
+}
+
+// matchOutputInterface returns expressions comparing the name of the interface packets leave through with name
+func matchOutputInterface(name string, op expr.CmpOp) []expr.Any {
+	data := make([]byte, interfaceNameLength)
+	copy(data, name)
+	return []expr.Any{
+		&expr.Meta{Key: expr.MetaKeyOIFNAME, Register: 1},
+		&expr.Cmp{Op: op, Register: 1, Data: data},
+	}
+}
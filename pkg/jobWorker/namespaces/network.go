@@ -0,0 +1,284 @@
+package namespaces
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/vishvananda/netlink"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// NetworkNone runs the job in a network namespace without any interface up
+	NetworkNone = "none"
+	// NetworkLoopback runs the job in a network namespace with only the loopback interface up
+	NetworkLoopback = "loopback"
+	// NetworkBridge connects the job's network namespace to a bridge of the host with a veth pair
+	NetworkBridge = "bridge"
+	// ProtocolTCP and ProtocolUDP are the protocols of port mappings
+	ProtocolTCP = "tcp"
+	ProtocolUDP = "udp"
+	// loopbackInterface is the name of the loopback interface
+	loopbackInterface = "lo"
+	// routeLocalnetFile allows routing 127.0.0.0/8 to an interface, so that ports of jobs can be forwarded from localhost
+	routeLocalnetFile = "/proc/sys/net/ipv4/conf/%s/route_localnet"
+	// ipForwardFile enables forwarding of packets between interfaces of the host
+	ipForwardFile = "/proc/sys/net/ipv4/ip_forward"
+	// networkNamespaceFile is the network namespace of a process
+	networkNamespaceFile = "/proc/%d/ns/net"
+)
+
+var (
+	ErrInvalidNetworkMode = errors.New("NetworkMode must be none, loopback or bridge")
+	ErrInvalidPortMapping = errors.New("port mapping must be tcp or udp with HostPort and ContainerPort greater than 0")
+	ErrInvalidSubnet      = errors.New("subnet must be an IPv4 CIDR with at least two host addresses, such as 10.88.0.0/16")
+	ErrNoAddressAvailable = errors.New("no address available in the subnet")
+)
+
+// PortMapping forwards HostPort of the host to ContainerPort of a job
+type PortMapping struct {
+	// Protocol is ProtocolTCP or ProtocolUDP (optional, ProtocolTCP by default).
+	Protocol      string
+	HostPort      uint16
+	ContainerPort uint16
+}
+
+// IsValid returns ErrInvalidPortMapping, if the port mapping is not complete
+func (mapping *PortMapping) IsValid() error {
+	if mapping.HostPort == 0 || mapping.ContainerPort == 0 {
+		return ErrInvalidPortMapping
+	}
+
+	switch mapping.Protocol {
+	case "", ProtocolTCP, ProtocolUDP:
+		return nil
+	}
+	return ErrInvalidPortMapping
+}
+
+// GetProtocol returns the protocol of the port mapping, ProtocolTCP if not set
+func (mapping *PortMapping) GetProtocol() string {
+	if mapping.Protocol == "" {
+		return ProtocolTCP
+	}
+	return mapping.Protocol
+}
+
+// String returns the port mapping as <host port>:<container port>/<protocol>
+func (mapping *PortMapping) String() string {
+	return fmt.Sprintf("%d:%d/%s", mapping.HostPort, mapping.ContainerPort, mapping.GetProtocol())
+}
+
+// IsValidNetworkMode returns ErrInvalidNetworkMode, if mode is not empty, NetworkNone, NetworkLoopback or NetworkBridge
+func IsValidNetworkMode(mode string) error {
+	switch mode {
+	case "", NetworkNone, NetworkLoopback, NetworkBridge:
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidNetworkMode, mode)
+}
+
+// NetworkInterface is the interface a job's network namespace is connected to the bridge with
+type NetworkInterface struct {
+	// Name is the name of the interface once it has been moved into the job's network namespace.
+	Name string
+	// Address is the address of the job in CIDR notation, such as 10.88.0.2/16.
+	Address string
+	// Gateway is the address of the bridge, the default route of the job.
+	Gateway string
+}
+
+// SetupLoopback brings the loopback interface of the current network namespace up
+func SetupLoopback() error {
+	link, err := netlink.LinkByName(loopbackInterface)
+	if err != nil {
+		return fmt.Errorf("error finding loopback interface: %w", err)
+	}
+	if err = netlink.LinkSetUp(link); err != nil {
+		return fmt.Errorf("error setting loopback interface up: %w", err)
+	}
+	return nil
+}
+
+// Setup renames the interface to name in the current network namespace, adds its address, brings it up
+// and routes all traffic through its gateway
+func (networkInterface *NetworkInterface) Setup(name string) error {
+	link, err := netlink.LinkByName(networkInterface.Name)
+	if err != nil {
+		return fmt.Errorf("error finding interface %s: %w", networkInterface.Name, err)
+	}
+	if err = netlink.LinkSetName(link, name); err != nil {
+		return fmt.Errorf("error renaming interface %s: %w", networkInterface.Name, err)
+	}
+
+	address, err := netlink.ParseAddr(networkInterface.Address)
+	if err != nil {
+		return fmt.Errorf("error parsing address %s: %w", networkInterface.Address, err)
+	}
+	if err = netlink.AddrAdd(link, address); err != nil {
+		return fmt.Errorf("error adding address %s: %w", networkInterface.Address, err)
+	}
+	if err = netlink.LinkSetUp(link); err != nil {
+		return fmt.Errorf("error setting interface %s up: %w", name, err)
+	}
+
+	route := &netlink.Route{LinkIndex: link.Attrs().Index, Gw: net.ParseIP(networkInterface.Gateway)}
+	if err = netlink.RouteAdd(route); err != nil {
+		return fmt.Errorf("error adding default route via %s: %w", networkInterface.Gateway, err)
+	}
+	return nil
+}
+
+// SetupBridge creates the bridge name with address gateway, unless it exists already, and brings it up.
+// Ports forwarded to jobs on the bridge can be reached from localhost of the host, which requires route_localnet,
+// so SetupNAT must drop packets of jobs to 127.0.0.0/8 arriving on the bridge.
+func SetupBridge(name string, gateway *net.IPNet) error {
+	link, err := netlink.LinkByName(name)
+	if errors.As(err, &netlink.LinkNotFoundError{}) {
+		link = &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: name}}
+		err = netlink.LinkAdd(link)
+	}
+	if err != nil {
+		return fmt.Errorf("error creating bridge %s: %w", name, err)
+	}
+
+	if err = netlink.AddrReplace(link, &netlink.Addr{IPNet: gateway}); err != nil {
+		return fmt.Errorf("error adding address %s to bridge %s: %w", gateway, name, err)
+	}
+	if err = netlink.LinkSetUp(link); err != nil {
+		return fmt.Errorf("error setting bridge %s up: %w", name, err)
+	}
+
+	if err = os.WriteFile(fmt.Sprintf(routeLocalnetFile, filepath.Base(name)), []byte("1"), 0o644); err != nil {
+		return fmt.Errorf("error enabling route_localnet of bridge %s: %w", name, err)
+	}
+	return nil
+}
+
+// EnableIPForwarding enables forwarding of packets between interfaces of the host, so that jobs can reach
+// other networks through NAT
+func EnableIPForwarding() error {
+	if err := os.WriteFile(ipForwardFile, []byte("1"), 0o644); err != nil {
+		return fmt.Errorf("error enabling IP forwarding: %w", err)
+	}
+	return nil
+}
+
+// AddVeth creates a veth pair with the host end hostName attached to bridge and moves the peer peerName
+// into the network namespace of the process pid
+func AddVeth(bridge string, hostName string, peerName string, pid int) error {
+	bridgeLink, err := netlink.LinkByName(bridge)
+	if err != nil {
+		return fmt.Errorf("error finding bridge %s: %w", bridge, err)
+	}
+
+	veth := &netlink.Veth{
+		LinkAttrs:     netlink.LinkAttrs{Name: hostName, MasterIndex: bridgeLink.Attrs().Index},
+		PeerName:      peerName,
+		PeerNamespace: netlink.NsPid(pid),
+	}
+	if err = netlink.LinkAdd(veth); err != nil {
+		return fmt.Errorf("error creating veth pair %s: %w", hostName, err)
+	}
+	if err = netlink.LinkSetUp(veth); err != nil {
+		_ = DeleteLink(hostName)
+		return fmt.Errorf("error setting veth %s up: %w", hostName, err)
+	}
+	return nil
+}
+
+// OpenNetworkNamespace returns the network namespace of the process pid, which is kept alive with its interfaces
+// until the returned file is closed, even if all processes in the namespace have exited
+func OpenNetworkNamespace(pid int) (*os.File, error) {
+	file, err := os.Open(fmt.Sprintf(networkNamespaceFile, pid))
+	if err != nil {
+		return nil, fmt.Errorf("error opening network namespace: %w", err)
+	}
+	return file, nil
+}
+
+// DeleteLink deletes the interface name of the host, if it exists. Deleting either end of a veth pair deletes both.
+func DeleteLink(name string) error {
+	link, err := netlink.LinkByName(name)
+	if errors.As(err, &netlink.LinkNotFoundError{}) {
+		return nil
+	}
+	if err == nil {
+		err = netlink.LinkDel(link)
+	}
+	if err != nil {
+		return fmt.Errorf("error deleting interface %s: %w", name, err)
+	}
+	return nil
+}
+
+// IPAllocator hands out the addresses of an IPv4 subnet, the first address of the subnet is the gateway
+type IPAllocator struct {
+	mutex     sync.Mutex
+	subnet    *net.IPNet
+	allocated map[uint32]bool
+}
+
+// NewIPAllocator returns an allocator of the addresses of subnet, such as 10.88.0.0/16
+func NewIPAllocator(subnet string) (*IPAllocator, error) {
+	_, ipNet, err := net.ParseCIDR(subnet)
+	if err != nil || ipNet.IP.To4() == nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSubnet, subnet)
+	}
+	if ones, bits := ipNet.Mask.Size(); bits-ones < 2 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSubnet, subnet)
+	}
+	return &IPAllocator{subnet: ipNet, allocated: map[uint32]bool{}}, nil
+}
+
+// Subnet returns the subnet the addresses are allocated from
+func (allocator *IPAllocator) Subnet() *net.IPNet {
+	return allocator.subnet
+}
+
+// Gateway returns the first address of the subnet with the subnet's mask
+func (allocator *IPAllocator) Gateway() *net.IPNet {
+	return allocator.toIPNet(allocator.first())
+}
+
+// Allocate returns the lowest address of the subnet which is not the gateway or allocated already
+// with the subnet's mask, ErrNoAddressAvailable is returned, if all addresses are allocated
+func (allocator *IPAllocator) Allocate() (*net.IPNet, error) {
+	allocator.mutex.Lock()
+	defer allocator.mutex.Unlock()
+
+	ones, bits := allocator.subnet.Mask.Size()
+	broadcast := allocator.first() - 1 + 1<<(bits-ones) - 1
+	for address := allocator.first() + 1; address < broadcast; address++ {
+		if !allocator.allocated[address] {
+			allocator.allocated[address] = true
+			return allocator.toIPNet(address), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNoAddressAvailable, allocator.subnet)
+}
+
+// Release returns an allocated address, so that it can be allocated again
+func (allocator *IPAllocator) Release(address net.IP) {
+	allocator.mutex.Lock()
+	defer allocator.mutex.Unlock()
+
+	if address = address.To4(); address != nil {
+		delete(allocator.allocated, binary.BigEndian.Uint32(address))
+	}
+}
+
+// first returns the first host address of the subnet
+func (allocator *IPAllocator) first() uint32 {
+	return binary.BigEndian.Uint32(allocator.subnet.IP.To4()) + 1
+}
+
+// toIPNet returns address with the subnet's mask
+func (allocator *IPAllocator) toIPNet(address uint32) *net.IPNet {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, address)
+	return &net.IPNet{IP: ip, Mask: allocator.subnet.Mask}
+}
@@ -0,0 +1,70 @@
+package namespaces
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func Test_IPAllocator(t *testing.T) {
+	t.Parallel()
+
+	allocator, err := NewIPAllocator("10.88.0.0/29")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gateway := allocator.Gateway().String(); gateway != "10.88.0.1/29" {
+		t.Errorf("expected gateway 10.88.0.1/29, got %s", gateway)
+	}
+
+	// the network, gateway and broadcast addresses are never allocated
+	for _, expected := range []string{"10.88.0.2/29", "10.88.0.3/29", "10.88.0.4/29", "10.88.0.5/29", "10.88.0.6/29"} {
+		address, err := allocator.Allocate()
+		if err != nil {
+			t.Fatalf("expected %s, got error %v", expected, err)
+		}
+		if address.String() != expected {
+			t.Errorf("expected %s, got %s", expected, address)
+		}
+	}
+	if _, err = allocator.Allocate(); !errors.Is(err, ErrNoAddressAvailable) {
+		t.Errorf("expected error %v, got %v", ErrNoAddressAvailable, err)
+	}
+
+	allocator.Release(net.ParseIP("10.88.0.4"))
+	if address, err := allocator.Allocate(); err != nil || address.String() != "10.88.0.4/29" {
+		t.Errorf("expected released address 10.88.0.4/29, got %s, %v", address, err)
+	}
+}
+
+func Test_NewIPAllocator_invalid_subnet(t *testing.T) {
+	t.Parallel()
+
+	for _, subnet := range []string{"", "10.88.0.1", "10.88.0.0/31", "fd00::/64"} {
+		if _, err := NewIPAllocator(subnet); !errors.Is(err, ErrInvalidSubnet) {
+			t.Errorf("subnet:%s, expected error %v, got %v", subnet, ErrInvalidSubnet, err)
+		}
+	}
+}
+
+func Test_PortMapping_IsValid(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		mapping     PortMapping
+		expectedErr error
+	}{
+		{mapping: PortMapping{HostPort: 8080, ContainerPort: 80}},
+		{mapping: PortMapping{Protocol: ProtocolUDP, HostPort: 5353, ContainerPort: 53}},
+		{mapping: PortMapping{HostPort: 8080}, expectedErr: ErrInvalidPortMapping},
+		{mapping: PortMapping{ContainerPort: 80}, expectedErr: ErrInvalidPortMapping},
+		{mapping: PortMapping{Protocol: "sctp", HostPort: 8080, ContainerPort: 80}, expectedErr: ErrInvalidPortMapping},
+	}
+
+	for _, testCase := range testCases {
+		if err := testCase.mapping.IsValid(); !errors.Is(err, testCase.expectedErr) {
+			t.Errorf("mapping:%+v, expected error %v, got %v", testCase.mapping, testCase.expectedErr, err)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package namespaces
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	CpuPressureFile    = "cpu.pressure"
+	MemoryPressureFile = "memory.pressure"
+	IoPressureFile     = "io.pressure"
+)
+
+var (
+	ErrInvalidPressureTrigger = errors.New("pressure trigger must have resource cpu, memory or io, window between 500ms and 10s and threshold within window")
+)
+
+const (
+	pressureMinWindow = 500 * time.Millisecond
+	pressureMaxWindow = 10 * time.Second
+)
+
+// PressureStats represents a single line of a PSI file: the share of time in percent tasks were stalled on
+// a resource over the last 10, 60 and 300 seconds, and the total stall time
+type PressureStats struct {
+	Avg10     float64
+	Avg60     float64
+	Avg300    float64
+	TotalUsec int64
+}
+
+// Pressure represents Pressure Stall Information (PSI) of a resource of a cgroup.
+// Some is the time at least one task was stalled, Full is the time all non-idle tasks were stalled at once.
+type Pressure struct {
+	Some PressureStats
+	Full PressureStats
+}
+
+// ReadPressure returns Pressure Stall Information from a PSI file of a given cgroup, such as memory.pressure
+func ReadPressure(cgroupName string, file string) (*Pressure, error) {
+	pressureFile, err := os.Open(filepath.Join(GetCGroupPath(cgroupName), file))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", file, err)
+	}
+	defer pressureFile.Close()
+
+	return parsePressure(pressureFile)
+}
+
+// parsePressure parses PSI files, where each line is "some" or "full" followed by key=value pairs:
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func parsePressure(reader io.Reader) (*Pressure, error) {
+	pressure := &Pressure{}
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		var stats *PressureStats
+		switch fields[0] {
+		case "some":
+			stats = &pressure.Some
+		case "full":
+			stats = &pressure.Full
+		default:
+			continue
+		}
+
+		for _, field := range fields[1:] {
+			key, value, found := strings.Cut(field, "=")
+			if !found {
+				continue
+			}
+
+			var err error
+			switch key {
+			case "avg10":
+				stats.Avg10, err = strconv.ParseFloat(value, 64)
+			case "avg60":
+				stats.Avg60, err = strconv.ParseFloat(value, 64)
+			case "avg300":
+				stats.Avg300, err = strconv.ParseFloat(value, 64)
+			case "total":
+				stats.TotalUsec, err = strconv.ParseInt(value, 10, 64)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error parsing pressure: %w", err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading pressure: %w", err)
+	}
+	return pressure, nil
+}
+
+// PressureTrigger describes a PSI trigger firing when tasks are stalled on a resource longer than Threshold within Window
+type PressureTrigger struct {
+	// Resource is "cpu", "memory" or "io".
+	Resource string
+	// Full selects the time all non-idle tasks were stalled at once instead of the time at least one task was stalled.
+	Full bool
+	// Threshold is the stall time within Window that fires the trigger.
+	Threshold time.Duration
+	// Window is the time window between 500ms and 10s, the trigger fires at most once per window.
+	Window time.Duration
+}
+
+// IsValid returns ErrInvalidPressureTrigger, if the trigger is not accepted by the kernel
+func (trigger *PressureTrigger) IsValid() error {
+	if _, err := trigger.getFile(); err != nil {
+		return err
+	}
+
+	if trigger.Window < pressureMinWindow || trigger.Window > pressureMaxWindow ||
+		trigger.Threshold <= 0 || trigger.Threshold > trigger.Window {
+		return ErrInvalidPressureTrigger
+	}
+	return nil
+}
+
+func (trigger *PressureTrigger) String() string {
+	kind := "some"
+	if trigger.Full {
+		kind = "full"
+	}
+	return fmt.Sprintf("%s %s %s/%s", trigger.Resource, kind, trigger.Threshold, trigger.Window)
+}
+
+func (trigger *PressureTrigger) getFile() (string, error) {
+	switch trigger.Resource {
+	case "cpu":
+		return CpuPressureFile, nil
+	case "memory":
+		return MemoryPressureFile, nil
+	case "io":
+		return IoPressureFile, nil
+	}
+	return "", ErrInvalidPressureTrigger
+}
+
+// PressureWatcher waits for a PSI trigger registered on a cgroup's PSI file, see WatchPressure
+type PressureWatcher struct {
+	Trigger PressureTrigger
+	file    *os.File
+	epollFd int
+}
+
+// WatchPressure registers a PSI trigger on a given cgroup, the trigger is active until the PressureWatcher is closed
+func WatchPressure(cgroupName string, trigger PressureTrigger) (*PressureWatcher, error) {
+	if err := trigger.IsValid(); err != nil {
+		return nil, err
+	}
+	pressureFile, _ := trigger.getFile()
+
+	file, err := os.OpenFile(filepath.Join(GetCGroupPath(cgroupName), pressureFile), os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %w", pressureFile, err)
+	}
+
+	kind := "some"
+	if trigger.Full {
+		kind = "full"
+	}
+	// the kernel expects "<some|full> <threshold us> <window us>" written at once, including the terminating zero
+	triggerSpec := fmt.Sprintf("%s %d %d\x00", kind, trigger.Threshold.Microseconds(), trigger.Window.Microseconds())
+	if _, err = file.Write([]byte(triggerSpec)); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error registering pressure trigger %s: %w", triggerSpec, err)
+	}
+
+	epollFd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error creating epoll: %w", err)
+	}
+
+	event := syscall.EpollEvent{Events: syscall.EPOLLPRI, Fd: int32(file.Fd())}
+	if err = syscall.EpollCtl(epollFd, syscall.EPOLL_CTL_ADD, int(file.Fd()), &event); err != nil {
+		syscall.Close(epollFd)
+		file.Close()
+		return nil, fmt.Errorf("error adding pressure trigger to epoll: %w", err)
+	}
+
+	return &PressureWatcher{
+		Trigger: trigger,
+		file:    file,
+		epollFd: epollFd,
+	}, nil
+}
+
+// Wait blocks until the trigger fires or timeout expires, it returns true if the trigger has fired
+func (watcher *PressureWatcher) Wait(timeout time.Duration) (bool, error) {
+	events := make([]syscall.EpollEvent, 1)
+
+	count, err := syscall.EpollWait(watcher.epollFd, events, int(timeout.Milliseconds()))
+	if err != nil {
+		if errors.Is(err, syscall.EINTR) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error waiting for pressure trigger: %w", err)
+	}
+
+	if count > 0 && events[0].Events&syscall.EPOLLERR != 0 {
+		// the cgroup has been removed
+		return false, fmt.Errorf("error waiting for pressure trigger: %w", syscall.ENODEV)
+	}
+	return count > 0, nil
+}
+
+// Close unregisters the trigger
+func (watcher *PressureWatcher) Close() error {
+	return errors.Join(syscall.Close(watcher.epollFd), watcher.file.Close())
+}
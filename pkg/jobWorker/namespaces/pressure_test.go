@@ -0,0 +1,54 @@
+package namespaces
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_parsePressure(t *testing.T) {
+	t.Parallel()
+
+	content := "some avg10=12.50 avg60=3.10 avg300=0.75 total=1234567\n" +
+		"full avg10=1.00 avg60=0.20 avg300=0.05 total=4567\n"
+
+	pressure, err := parsePressure(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("could not parse pressure: %v", err)
+	}
+
+	expected := Pressure{
+		Some: PressureStats{Avg10: 12.5, Avg60: 3.1, Avg300: 0.75, TotalUsec: 1234567},
+		Full: PressureStats{Avg10: 1, Avg60: 0.2, Avg300: 0.05, TotalUsec: 4567},
+	}
+	if *pressure != expected {
+		t.Errorf("expected %+v, got %+v", expected, *pressure)
+	}
+}
+
+func Test_PressureTrigger_IsValid(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		trigger PressureTrigger
+		isValid bool
+	}{
+		{trigger: PressureTrigger{Resource: "memory", Threshold: 150 * time.Millisecond, Window: time.Second}, isValid: true},
+		{trigger: PressureTrigger{Resource: "io", Full: true, Threshold: time.Second, Window: time.Second}, isValid: true},
+		{trigger: PressureTrigger{Resource: "network", Threshold: 150 * time.Millisecond, Window: time.Second}},
+		{trigger: PressureTrigger{Resource: "cpu", Threshold: 100 * time.Millisecond, Window: 100 * time.Millisecond}},
+		{trigger: PressureTrigger{Resource: "cpu", Threshold: time.Second, Window: 20 * time.Second}},
+		{trigger: PressureTrigger{Resource: "cpu", Threshold: 2 * time.Second, Window: time.Second}},
+		{trigger: PressureTrigger{Resource: "cpu", Window: time.Second}},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.trigger.IsValid()
+		if testCase.isValid && err != nil {
+			t.Errorf("trigger:%s, expected to be valid, got %v", &testCase.trigger, err)
+		}
+		if !testCase.isValid && err != ErrInvalidPressureTrigger {
+			t.Errorf("trigger:%s, expected error(ErrInvalidPressureTrigger), got %v", &testCase.trigger, err)
+		}
+	}
+}
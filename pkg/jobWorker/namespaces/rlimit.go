@@ -0,0 +1,103 @@
+package namespaces
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/sys/unix"
+	"strings"
+	"syscall"
+)
+
+const (
+	// RlimitInfinity is the unlimited value of a resource limit
+	RlimitInfinity = unix.RLIM_INFINITY
+	// rlimitPrefix is the prefix of resource limit names, which may be omitted
+	rlimitPrefix = "RLIMIT_"
+)
+
+var (
+	ErrInvalidRlimit = errors.New("rlimit must be a POSIX resource limit such as RLIMIT_NOFILE or NOFILE with Soft not greater than Hard")
+)
+
+// rlimits maps the names of resource limits to their numbers
+var rlimits = map[string]int{
+	"RLIMIT_AS":         unix.RLIMIT_AS,
+	"RLIMIT_CORE":       unix.RLIMIT_CORE,
+	"RLIMIT_CPU":        unix.RLIMIT_CPU,
+	"RLIMIT_DATA":       unix.RLIMIT_DATA,
+	"RLIMIT_FSIZE":      unix.RLIMIT_FSIZE,
+	"RLIMIT_LOCKS":      unix.RLIMIT_LOCKS,
+	"RLIMIT_MEMLOCK":    unix.RLIMIT_MEMLOCK,
+	"RLIMIT_MSGQUEUE":   unix.RLIMIT_MSGQUEUE,
+	"RLIMIT_NICE":       unix.RLIMIT_NICE,
+	"RLIMIT_NOFILE":     unix.RLIMIT_NOFILE,
+	"RLIMIT_NPROC":      unix.RLIMIT_NPROC,
+	"RLIMIT_RSS":        unix.RLIMIT_RSS,
+	"RLIMIT_RTPRIO":     unix.RLIMIT_RTPRIO,
+	"RLIMIT_RTTIME":     unix.RLIMIT_RTTIME,
+	"RLIMIT_SIGPENDING": unix.RLIMIT_SIGPENDING,
+	"RLIMIT_STACK":      unix.RLIMIT_STACK,
+}
+
+// Rlimit is the soft and hard value of a resource limit, such as the number of open files or CPU seconds,
+// RlimitInfinity is unlimited
+type Rlimit struct {
+	Soft uint64 `json:"soft"`
+	Hard uint64 `json:"hard"`
+}
+
+// IsValid returns ErrInvalidRlimit, if the soft limit is greater than the hard limit
+func (rlimit Rlimit) IsValid() error {
+	if rlimit.Soft > rlimit.Hard {
+		return ErrInvalidRlimit
+	}
+	return nil
+}
+
+// NormalizeRlimit returns the name of a resource limit with the RLIMIT_ prefix in upper case
+func NormalizeRlimit(name string) (string, error) {
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, rlimitPrefix) {
+		name = rlimitPrefix + name
+	}
+	if _, ok := rlimits[name]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrInvalidRlimit, name)
+	}
+	return name, nil
+}
+
+// NormalizeRlimits returns the resource limits with normalized names
+func NormalizeRlimits(limits map[string]Rlimit) (map[string]Rlimit, error) {
+	if limits == nil {
+		return nil, nil
+	}
+
+	normalized := make(map[string]Rlimit, len(limits))
+	for name, rlimit := range limits {
+		name, err := NormalizeRlimit(name)
+		if err != nil {
+			return nil, err
+		}
+		if err = rlimit.IsValid(); err != nil {
+			return nil, fmt.Errorf("%w: %s", err, name)
+		}
+		normalized[name] = rlimit
+	}
+	return normalized, nil
+}
+
+// SetRlimits sets the resource limits of the calling process, which are inherited across exec.
+// Raising a hard limit above the current one needs CAP_SYS_RESOURCE of the initial user namespace.
+func SetRlimits(limits map[string]Rlimit) error {
+	for name, rlimit := range limits {
+		resource, ok := rlimits[name]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrInvalidRlimit, name)
+		}
+		// syscall.Setrlimit keeps Go from restoring its original RLIMIT_NOFILE on exec
+		if err := syscall.Setrlimit(resource, &syscall.Rlimit{Cur: rlimit.Soft, Max: rlimit.Hard}); err != nil {
+			return fmt.Errorf("error setting %s: %w", name, err)
+		}
+	}
+	return nil
+}
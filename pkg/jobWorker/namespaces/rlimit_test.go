@@ -0,0 +1,40 @@
+package namespaces
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_NormalizeRlimits(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		limits         map[string]Rlimit
+		expectedLimits map[string]Rlimit
+		expectedErr    error
+	}{
+		{},
+		{
+			limits:         map[string]Rlimit{"nofile": {Soft: 1024, Hard: 4096}, "RLIMIT_CORE": {}},
+			expectedLimits: map[string]Rlimit{"RLIMIT_NOFILE": {Soft: 1024, Hard: 4096}, "RLIMIT_CORE": {}},
+		},
+		{
+			limits:         map[string]Rlimit{"CPU": {Soft: 10, Hard: RlimitInfinity}},
+			expectedLimits: map[string]Rlimit{"RLIMIT_CPU": {Soft: 10, Hard: RlimitInfinity}},
+		},
+		{limits: map[string]Rlimit{"RLIMIT_FLY": {}}, expectedErr: ErrInvalidRlimit},
+		{limits: map[string]Rlimit{"STACK": {Soft: 2, Hard: 1}}, expectedErr: ErrInvalidRlimit},
+	}
+
+	for _, testCase := range testCases {
+		result, err := NormalizeRlimits(testCase.limits)
+		if !errors.Is(err, testCase.expectedErr) {
+			t.Errorf("limits:%v, expected error %v, got %v", testCase.limits, testCase.expectedErr, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, testCase.expectedLimits) {
+			t.Errorf("limits:%v, expected %v, got %v", testCase.limits, testCase.expectedLimits, result)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package namespaces
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/elastic/go-seccomp-bpf/arch"
+	"golang.org/x/net/bpf"
+	"os"
+	"runtime"
+	"slices"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	ErrInvalidSeccompProfile = errors.New("seccomp profile must have a supported defaultAction and syscall actions and args")
+)
+
+const (
+	// seccomp_data offsets, see include/uapi/linux/seccomp.h
+	seccompNrOffset   = 0
+	seccompArchOffset = 4
+	seccompArgsOffset = 16
+	// x32SyscallBit marks syscalls of the x32 ABI, which shares AUDIT_ARCH_X86_64 with x86_64
+	x32SyscallBit = 0x40000000
+	// seccompModeFilter is SECCOMP_MODE_FILTER of prctl(PR_SET_SECCOMP)
+	seccompModeFilter = 2
+)
+
+// seccompActions maps Docker seccomp actions to the SECCOMP_RET_* values returned by the filter
+var seccompActions = map[string]uint32{
+	"SCMP_ACT_KILL":         0x00000000,
+	"SCMP_ACT_KILL_THREAD":  0x00000000,
+	"SCMP_ACT_KILL_PROCESS": 0x80000000,
+	"SCMP_ACT_TRAP":         0x00030000,
+	"SCMP_ACT_ERRNO":        0x00050000,
+	"SCMP_ACT_TRACE":        0x7ff00000,
+	"SCMP_ACT_LOG":          0x7ffc0000,
+	"SCMP_ACT_ALLOW":        0x7fff0000,
+}
+
+// SeccompProfile is a seccomp profile in the format of Docker's seccomp profiles, such as
+// https://github.com/moby/moby/blob/master/profiles/seccomp/default.json.
+// Syscalls are matched in the given order, the action of the first matching rule is taken.
+type SeccompProfile struct {
+	DefaultAction   string           `json:"defaultAction"`
+	DefaultErrnoRet *uint32          `json:"defaultErrnoRet,omitempty"`
+	Syscalls        []SeccompSyscall `json:"syscalls"`
+}
+
+// SeccompSyscall is a rule of a SeccompProfile, which matches any of Names, if all Args match.
+// Rules which don't apply to the current architecture or capabilities are ignored, so are syscalls
+// unknown on the current architecture.
+type SeccompSyscall struct {
+	Names []string `json:"names"`
+	// Name is the single syscall name of older profiles.
+	Name     string        `json:"name,omitempty"`
+	Action   string        `json:"action"`
+	ErrnoRet *uint32       `json:"errnoRet,omitempty"`
+	Args     []SeccompArg  `json:"args,omitempty"`
+	Includes SeccompFilter `json:"includes,omitempty"`
+	Excludes SeccompFilter `json:"excludes,omitempty"`
+}
+
+// SeccompArg compares the syscall argument Index with Value, SCMP_CMP_MASKED_EQ compares the argument
+// masked by Value with ValueTwo
+type SeccompArg struct {
+	Index    uint   `json:"index"`
+	Value    uint64 `json:"value"`
+	ValueTwo uint64 `json:"valueTwo"`
+	Op       string `json:"op"`
+}
+
+// SeccompFilter limits a SeccompSyscall to architectures, such as "amd64", and capabilities, such as "CAP_SYS_ADMIN"
+type SeccompFilter struct {
+	Arches []string `json:"arches,omitempty"`
+	Caps   []string `json:"caps,omitempty"`
+}
+
+// ReadSeccompProfile reads a seccomp profile in Docker's JSON format from path
+func ReadSeccompProfile(path string) (*SeccompProfile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading seccomp profile: %w", err)
+	}
+
+	profile := &SeccompProfile{}
+	if err = json.Unmarshal(content, profile); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSeccompProfile, err)
+	}
+	return profile, nil
+}
+
+// Assemble compiles the profile into a BPF program for the current architecture, rules including any capability
+// not in capabilities are ignored. Syscalls of other architectures, such as x32 or 32-bit syscalls on x86_64,
+// kill the process.
+func (profile *SeccompProfile) Assemble(capabilities []string) ([]bpf.RawInstruction, error) {
+	info, err := arch.GetInfo("")
+	if err != nil {
+		return nil, err
+	}
+
+	defaultAction, err := getSeccompAction(profile.DefaultAction, profile.DefaultErrnoRet)
+	if err != nil {
+		return nil, err
+	}
+
+	program := []bpf.Instruction{
+		bpf.LoadAbsolute{Off: seccompArchOffset, Size: 4},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: uint32(info.ID), SkipTrue: 1},
+		bpf.RetConstant{Val: seccompActions["SCMP_ACT_KILL_PROCESS"]},
+	}
+	if info == arch.X86_64 {
+		program = append(program,
+			bpf.LoadAbsolute{Off: seccompNrOffset, Size: 4},
+			bpf.JumpIf{Cond: bpf.JumpBitsSet, Val: x32SyscallBit, SkipFalse: 1},
+			bpf.RetConstant{Val: seccompActions["SCMP_ACT_KILL_PROCESS"]},
+		)
+	}
+
+	for _, rule := range profile.Syscalls {
+		if !rule.appliesTo(capabilities) {
+			continue
+		}
+
+		action, err := getSeccompAction(rule.Action, rule.ErrnoRet)
+		if err != nil {
+			return nil, err
+		}
+
+		names := rule.Names
+		if rule.Name != "" {
+			names = append([]string{rule.Name}, names...)
+		}
+		for _, name := range names {
+			nr, ok := info.SyscallNames[name]
+			if !ok {
+				continue
+			}
+			block, err := assembleSeccompRule(uint32(nr), rule.Args, action)
+			if err != nil {
+				return nil, err
+			}
+			program = append(program, block...)
+		}
+	}
+	program = append(program, bpf.RetConstant{Val: defaultAction})
+
+	return bpf.Assemble(program)
+}
+
+// IsAllowAll returns true, if the profile allows all syscalls, so no filter needs to be loaded
+func (profile *SeccompProfile) IsAllowAll() bool {
+	return profile.DefaultAction == "SCMP_ACT_ALLOW" && len(profile.Syscalls) == 0
+}
+
+func (rule *SeccompSyscall) appliesTo(capabilities []string) bool {
+	if len(rule.Includes.Arches) > 0 && !slices.Contains(rule.Includes.Arches, runtime.GOARCH) {
+		return false
+	}
+	if slices.Contains(rule.Excludes.Arches, runtime.GOARCH) {
+		return false
+	}
+	for _, capability := range rule.Includes.Caps {
+		if !slices.Contains(capabilities, capability) {
+			return false
+		}
+	}
+	for _, capability := range rule.Excludes.Caps {
+		if slices.Contains(capabilities, capability) {
+			return false
+		}
+	}
+	return true
+}
+
+// getSeccompAction returns the SECCOMP_RET_* value of a Docker seccomp action, SCMP_ACT_ERRNO and SCMP_ACT_TRACE
+// return errnoRet or EPERM by default
+func getSeccompAction(name string, errnoRet *uint32) (uint32, error) {
+	action, ok := seccompActions[name]
+	if !ok {
+		return 0, fmt.Errorf("%w: unsupported action %q", ErrInvalidSeccompProfile, name)
+	}
+
+	if name == "SCMP_ACT_ERRNO" || name == "SCMP_ACT_TRACE" {
+		errno := uint32(syscall.EPERM)
+		if errnoRet != nil {
+			errno = *errnoRet
+		}
+		action |= errno & 0xffff
+	}
+	return action, nil
+}
+
+// assembleSeccompRule returns the instructions returning action, if the syscall number is nr and all args match,
+// or continuing with the next instruction otherwise
+func assembleSeccompRule(nr uint32, args []SeccompArg, action uint32) ([]bpf.Instruction, error) {
+	if len(args) > 6 {
+		return nil, fmt.Errorf("%w: more than 6 args", ErrInvalidSeccompProfile)
+	}
+
+	// jumps holds the indexes of the jumps to the end of the rule, which are set once the rule is complete
+	var jumps []int
+	block := []bpf.Instruction{
+		bpf.LoadAbsolute{Off: seccompNrOffset, Size: 4},
+		bpf.JumpIf{Cond: bpf.JumpNotEqual, Val: nr},
+	}
+	jumps = append(jumps, len(block)-1)
+
+	for _, arg := range args {
+		if arg.Index > 5 {
+			return nil, fmt.Errorf("%w: invalid arg index %d", ErrInvalidSeccompProfile, arg.Index)
+		}
+		argBlock, argJumps, err := assembleSeccompArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		for _, jump := range argJumps {
+			jumps = append(jumps, len(block)+jump)
+		}
+		block = append(block, argBlock...)
+	}
+	block = append(block, bpf.RetConstant{Val: action})
+
+	// a rule compares at most 6 args with at most 6 instructions each, so the jumps are always short
+	for _, jump := range jumps {
+		instruction := block[jump].(bpf.JumpIf)
+		instruction.SkipTrue = uint8(len(block) - jump - 1)
+		block[jump] = instruction
+	}
+	return block, nil
+}
+
+// assembleSeccompArg returns the instructions comparing the 64-bit arg as two 32-bit halves, which continue with
+// the next instruction if arg matches, and the indexes of the jumps, which have to skip to the end of the rule
+// if arg doesn't match
+func assembleSeccompArg(arg SeccompArg) ([]bpf.Instruction, []int, error) {
+	hiOffset, loOffset := seccompArgsOffset+8*uint32(arg.Index)+4, seccompArgsOffset+8*uint32(arg.Index)
+	if binary.NativeEndian.Uint16([]byte{0, 1}) == 1 {
+		hiOffset, loOffset = loOffset, hiOffset
+	}
+	hi, lo := uint32(arg.Value>>32), uint32(arg.Value)
+
+	loadHi := bpf.LoadAbsolute{Off: hiOffset, Size: 4}
+	loadLo := bpf.LoadAbsolute{Off: loOffset, Size: 4}
+	// SkipTrue of mismatch jumps is set by assembleSeccompRule
+	mismatch := func(cond bpf.JumpTest, val uint32) bpf.JumpIf {
+		return bpf.JumpIf{Cond: cond, Val: val}
+	}
+
+	switch arg.Op {
+	case "SCMP_CMP_EQ":
+		return []bpf.Instruction{loadHi, mismatch(bpf.JumpNotEqual, hi), loadLo, mismatch(bpf.JumpNotEqual, lo)}, []int{1, 3}, nil
+	case "SCMP_CMP_NE":
+		// matches if the high halves differ, so skip comparing the low halves
+		return []bpf.Instruction{
+			loadHi, bpf.JumpIf{Cond: bpf.JumpNotEqual, Val: hi, SkipTrue: 2}, loadLo, mismatch(bpf.JumpEqual, lo),
+		}, []int{3}, nil
+	case "SCMP_CMP_GT", "SCMP_CMP_GE", "SCMP_CMP_LT", "SCMP_CMP_LE":
+		// the high halves decide, unless they are equal
+		higher, lowMismatch := bpf.JumpGreaterThan, map[string]bpf.JumpTest{
+			"SCMP_CMP_GT": bpf.JumpLessOrEqual, "SCMP_CMP_GE": bpf.JumpLessThan,
+			"SCMP_CMP_LT": bpf.JumpGreaterOrEqual, "SCMP_CMP_LE": bpf.JumpGreaterThan,
+		}[arg.Op]
+		if arg.Op == "SCMP_CMP_LT" || arg.Op == "SCMP_CMP_LE" {
+			higher = bpf.JumpLessThan
+		}
+		return []bpf.Instruction{
+			loadHi, bpf.JumpIf{Cond: higher, Val: hi, SkipTrue: 3}, mismatch(bpf.JumpNotEqual, hi),
+			loadLo, mismatch(lowMismatch, lo),
+		}, []int{2, 4}, nil
+	case "SCMP_CMP_MASKED_EQ":
+		valueHi, valueLo := uint32(arg.ValueTwo>>32), uint32(arg.ValueTwo)
+		return []bpf.Instruction{
+			loadHi, bpf.ALUOpConstant{Op: bpf.ALUOpAnd, Val: hi}, mismatch(bpf.JumpNotEqual, valueHi),
+			loadLo, bpf.ALUOpConstant{Op: bpf.ALUOpAnd, Val: lo}, mismatch(bpf.JumpNotEqual, valueLo),
+		}, []int{2, 5}, nil
+	}
+	return nil, nil, fmt.Errorf("%w: unsupported op %q", ErrInvalidSeccompProfile, arg.Op)
+}
+
+// LoadSeccompFilter installs the BPF program as seccomp filter of the calling thread, which is inherited
+// by the process it execs. The thread must have CAP_SYS_ADMIN in its user namespace or no_new_privs set.
+func LoadSeccompFilter(program []bpf.RawInstruction) error {
+	if len(program) == 0 {
+		return nil
+	}
+
+	filter := make([]syscall.SockFilter, len(program))
+	for i, instruction := range program {
+		filter[i] = syscall.SockFilter{Code: instruction.Op, Jt: instruction.Jt, Jf: instruction.Jf, K: instruction.K}
+	}
+	fprog := syscall.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+
+	_, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, syscall.PR_SET_SECCOMP, seccompModeFilter, uintptr(unsafe.Pointer(&fprog)))
+	if errno != 0 {
+		return fmt.Errorf("error loading seccomp filter: %w", errno)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package namespaces
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/elastic/go-seccomp-bpf/arch"
+	"golang.org/x/net/bpf"
+	"testing"
+)
+
+// newSeccompVM returns a VM running the assembled profile on seccomp_data built by seccompData
+func newSeccompVM(t *testing.T, profile *SeccompProfile) *bpf.VM {
+	t.Helper()
+
+	if binary.NativeEndian.Uint16([]byte{0, 1}) == 1 {
+		t.Skip("seccompData only builds seccomp_data of little-endian architectures")
+	}
+
+	raw, err := profile.Assemble(nil)
+	if err != nil {
+		t.Fatalf("could not assemble profile: %v", err)
+	}
+	instructions, ok := bpf.Disassemble(raw)
+	if !ok {
+		t.Fatalf("could not disassemble profile")
+	}
+	vm, err := bpf.NewVM(instructions)
+	if err != nil {
+		t.Fatalf("could not create VM: %v", err)
+	}
+	return vm
+}
+
+// seccompData returns seccomp_data as the VM loads it: the VM loads words big-endian, the kernel native-endian
+func seccompData(auditArch uint32, nr uint32, args ...uint64) []byte {
+	data := make([]byte, seccompArgsOffset+6*8)
+	binary.BigEndian.PutUint32(data[seccompNrOffset:], nr)
+	binary.BigEndian.PutUint32(data[seccompArchOffset:], auditArch)
+	for i, arg := range args {
+		binary.BigEndian.PutUint32(data[seccompArgsOffset+8*i:], uint32(arg))
+		binary.BigEndian.PutUint32(data[seccompArgsOffset+8*i+4:], uint32(arg>>32))
+	}
+	return data
+}
+
+func Test_SeccompProfile_Assemble(t *testing.T) {
+	t.Parallel()
+
+	info, err := arch.GetInfo("")
+	if err != nil {
+		t.Skipf("architecture not supported: %v", err)
+	}
+	errnoRet := uint32(13)
+	profile := &SeccompProfile{
+		DefaultAction: "SCMP_ACT_ALLOW",
+		Syscalls: []SeccompSyscall{
+			{Names: []string{"mount", "unknown_syscall"}, Action: "SCMP_ACT_ERRNO"},
+			{Names: []string{"kill"}, Action: "SCMP_ACT_ERRNO", ErrnoRet: &errnoRet, Args: []SeccompArg{{Index: 1, Value: 0, Op: "SCMP_CMP_EQ"}}},
+			{Names: []string{"read"}, Action: "SCMP_ACT_ERRNO", Args: []SeccompArg{{Index: 2, Value: 1 << 32, Op: "SCMP_CMP_GT"}}},
+			{Names: []string{"write"}, Action: "SCMP_ACT_ERRNO", Args: []SeccompArg{{Index: 0, Value: 2, Op: "SCMP_CMP_LE"}}},
+			{Names: []string{"close"}, Action: "SCMP_ACT_ERRNO", Args: []SeccompArg{{Index: 0, Value: 7, Op: "SCMP_CMP_NE"}}},
+			{Names: []string{"clone"}, Action: "SCMP_ACT_KILL_PROCESS", Args: []SeccompArg{{Index: 0, Value: 0x7e020000, ValueTwo: 0x10000000, Op: "SCMP_CMP_MASKED_EQ"}}},
+			{Names: []string{"uname"}, Action: "SCMP_ACT_ERRNO", Includes: SeccompFilter{Caps: []string{"CAP_SYS_ADMIN"}}},
+		},
+	}
+	vm := newSeccompVM(t, profile)
+
+	allow, eperm, eacces, kill := seccompActions["SCMP_ACT_ALLOW"], seccompActions["SCMP_ACT_ERRNO"]|1,
+		seccompActions["SCMP_ACT_ERRNO"]|13, seccompActions["SCMP_ACT_KILL_PROCESS"]
+	nr := func(name string) uint32 { return uint32(info.SyscallNames[name]) }
+	testCases := []struct {
+		name           string
+		data           []byte
+		expectedAction uint32
+	}{
+		{name: "mount", data: seccompData(uint32(info.ID), nr("mount")), expectedAction: eperm},
+		{name: "kill 0", data: seccompData(uint32(info.ID), nr("kill"), 1, 0), expectedAction: eacces},
+		{name: "kill SIGTERM", data: seccompData(uint32(info.ID), nr("kill"), 1, 15), expectedAction: allow},
+		{name: "read 1<<32+1", data: seccompData(uint32(info.ID), nr("read"), 0, 0, 1<<32+1), expectedAction: eperm},
+		{name: "read 1<<32", data: seccompData(uint32(info.ID), nr("read"), 0, 0, 1<<32), expectedAction: allow},
+		{name: "read 2", data: seccompData(uint32(info.ID), nr("read"), 0, 0, 2), expectedAction: allow},
+		{name: "write 2", data: seccompData(uint32(info.ID), nr("write"), 2), expectedAction: eperm},
+		{name: "write 3", data: seccompData(uint32(info.ID), nr("write"), 3), expectedAction: allow},
+		{name: "write 1<<32", data: seccompData(uint32(info.ID), nr("write"), 1<<32), expectedAction: allow},
+		{name: "close 7", data: seccompData(uint32(info.ID), nr("close"), 7), expectedAction: allow},
+		{name: "close 1<<32+7", data: seccompData(uint32(info.ID), nr("close"), 1<<32+7), expectedAction: eperm},
+		{name: "clone NEWUSER", data: seccompData(uint32(info.ID), nr("clone"), 0x10000011), expectedAction: kill},
+		{name: "clone", data: seccompData(uint32(info.ID), nr("clone"), 0x00000011), expectedAction: allow},
+		{name: "uname", data: seccompData(uint32(info.ID), nr("uname")), expectedAction: allow},
+		{name: "other arch", data: seccompData(uint32(info.ID)+1, nr("read")), expectedAction: kill},
+	}
+
+	for _, testCase := range testCases {
+		action, err := vm.Run(testCase.data)
+		if err != nil || uint32(action) != testCase.expectedAction {
+			t.Errorf("syscall:%s, expected action %#x, got %#x, error: %v", testCase.name, testCase.expectedAction, action, err)
+		}
+	}
+}
+
+func Test_SeccompProfile_Assemble_invalid_expected_ErrInvalidSeccompProfile(t *testing.T) {
+	t.Parallel()
+
+	profiles := []SeccompProfile{
+		{DefaultAction: "SCMP_ACT_NOTIFY"},
+		{DefaultAction: "SCMP_ACT_ALLOW", Syscalls: []SeccompSyscall{{Names: []string{"read"}, Action: "allow"}}},
+		{DefaultAction: "SCMP_ACT_ALLOW", Syscalls: []SeccompSyscall{
+			{Names: []string{"read"}, Action: "SCMP_ACT_ERRNO", Args: []SeccompArg{{Index: 6, Op: "SCMP_CMP_EQ"}}},
+		}},
+		{DefaultAction: "SCMP_ACT_ALLOW", Syscalls: []SeccompSyscall{
+			{Names: []string{"read"}, Action: "SCMP_ACT_ERRNO", Args: []SeccompArg{{Index: 0, Op: "SCMP_CMP_SOME"}}},
+		}},
+	}
+
+	for _, profile := range profiles {
+		if _, err := profile.Assemble(nil); !errors.Is(err, ErrInvalidSeccompProfile) {
+			t.Errorf("profile:%+v, expected error(ErrInvalidSeccompProfile), got %v", profile, err)
+		}
+	}
+}
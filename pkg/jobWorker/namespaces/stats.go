@@ -0,0 +1,218 @@
+package namespaces
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	CpuStatFile       = "cpu.stat"
+	MemoryCurrentFile = "memory.current"
+	MemoryPeakFile    = "memory.peak"
+	MemoryStatFile    = "memory.stat"
+	IoStatFile        = "io.stat"
+)
+
+// CPUStats represents cpu.stat of a cgroup, all times are in microseconds
+type CPUStats struct {
+	UsageUsec  int64
+	UserUsec   int64
+	SystemUsec int64
+	// NrPeriods is the number of cpu.max periods, NrThrottled is the number of periods the cgroup has been throttled in
+	NrPeriods     int64
+	NrThrottled   int64
+	ThrottledUsec int64
+}
+
+// MemoryStats represents memory usage of a cgroup in bytes
+type MemoryStats struct {
+	Current int64
+	// Peak is the highest memory usage, 0 on kernels without memory.peak (before 5.19).
+	Peak int64
+	// Stat is the breakdown of memory usage from memory.stat, such as "anon", "file" or "kernel".
+	Stat map[string]int64
+}
+
+// IOStats represents io.stat of a cgroup for a single block device
+type IOStats struct {
+	// Device is "major:minor" of the block device.
+	Device     string
+	ReadBytes  int64
+	WriteBytes int64
+	ReadIOs    int64
+	WriteIOs   int64
+}
+
+// PidsStats represents the number of processes in a cgroup
+type PidsStats struct {
+	// Current is the number of processes currently in the cgroup.
+	Current int64
+	// Peak is the highest number of processes seen in the cgroup, 0 on kernels without pids.peak (before 6.1).
+	Peak int64
+	// LimitHits is the number of times a fork failed because pids.max was reached.
+	LimitHits int64
+}
+
+// Usage represents resource usage of a cgroup
+type Usage struct {
+	CPU    CPUStats
+	Memory MemoryStats
+	IO     []IOStats
+	Pids   PidsStats
+	// CPUPressure, MemoryPressure and IOPressure are zero on kernels without PSI support.
+	CPUPressure    Pressure
+	MemoryPressure Pressure
+	IOPressure     Pressure
+	// Network is the traffic of the job's veth pair, zero unless the job is connected to the bridge.
+	Network NetworkStats
+}
+
+// ReadUsage returns resource usage of a given cgroup from cpu.stat, memory.current, memory.peak, memory.stat,
+// io.stat, pids.* and *.pressure interface files
+func ReadUsage(cgroupName string) (*Usage, error) {
+	cpuStat, err := ReadFlatKeyedFile(cgroupName, CpuStatFile)
+	if err != nil {
+		return nil, err
+	}
+
+	memoryCurrent, err := ReadIntFile(cgroupName, MemoryCurrentFile)
+	if err != nil {
+		return nil, err
+	}
+
+	memoryPeak, err := ReadIntFile(cgroupName, MemoryPeakFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	memoryStat, err := ReadFlatKeyedFile(cgroupName, MemoryStatFile)
+	if err != nil {
+		return nil, err
+	}
+
+	ioStats, err := readIOStat(cgroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	pidsStats, err := ReadPidsStats(cgroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	pressures := map[string]*Pressure{}
+	for _, file := range []string{CpuPressureFile, MemoryPressureFile, IoPressureFile} {
+		pressure, err := ReadPressure(cgroupName, file)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if pressure == nil {
+			pressure = &Pressure{}
+		}
+		pressures[file] = pressure
+	}
+
+	return &Usage{
+		CPU: CPUStats{
+			UsageUsec:     cpuStat["usage_usec"],
+			UserUsec:      cpuStat["user_usec"],
+			SystemUsec:    cpuStat["system_usec"],
+			NrPeriods:     cpuStat["nr_periods"],
+			NrThrottled:   cpuStat["nr_throttled"],
+			ThrottledUsec: cpuStat["throttled_usec"],
+		},
+		Memory: MemoryStats{
+			Current: memoryCurrent,
+			Peak:    memoryPeak,
+			Stat:    memoryStat,
+		},
+		IO:             ioStats,
+		Pids:           *pidsStats,
+		CPUPressure:    *pressures[CpuPressureFile],
+		MemoryPressure: *pressures[MemoryPressureFile],
+		IOPressure:     *pressures[IoPressureFile],
+	}, nil
+}
+
+func readIOStat(cgroupName string) ([]IOStats, error) {
+	file, err := os.Open(filepath.Join(GetCGroupPath(cgroupName), IoStatFile))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", IoStatFile, err)
+	}
+	defer file.Close()
+
+	return parseIOStat(file)
+}
+
+// parseIOStat parses io.stat in the "nested keyed" format, each line is a device followed by key=value pairs:
+//
+//	8:16 rbytes=1459200 wbytes=314773504 rios=192 wios=353 dbytes=0 dios=0
+func parseIOStat(reader io.Reader) ([]IOStats, error) {
+	var ioStats []IOStats
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		stats := IOStats{Device: fields[0]}
+		for _, field := range fields[1:] {
+			key, value, found := strings.Cut(field, "=")
+			if !found {
+				continue
+			}
+			number, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing %s: %w", IoStatFile, err)
+			}
+
+			switch key {
+			case "rbytes":
+				stats.ReadBytes = number
+			case "wbytes":
+				stats.WriteBytes = number
+			case "rios":
+				stats.ReadIOs = number
+			case "wios":
+				stats.WriteIOs = number
+			}
+		}
+		ioStats = append(ioStats, stats)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", IoStatFile, err)
+	}
+	return ioStats, nil
+}
+
+// ReadPidsStats returns pids.current, pids.peak and the "max" counter of pids.events of a given cgroup
+func ReadPidsStats(cgroupName string) (*PidsStats, error) {
+	current, err := ReadIntFile(cgroupName, PidsCurrentFile)
+	if err != nil {
+		return nil, err
+	}
+
+	peak, err := ReadIntFile(cgroupName, PidsPeakFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	events, err := ReadFlatKeyedFile(cgroupName, PidsEventsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PidsStats{
+		Current:   current,
+		Peak:      peak,
+		LimitHits: events["max"],
+	}, nil
+}
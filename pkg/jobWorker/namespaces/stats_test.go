@@ -0,0 +1,80 @@
+package namespaces
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parseIOStat(t *testing.T) {
+	t.Parallel()
+
+	ioStat := "8:16 rbytes=1459200 wbytes=314773504 rios=192 wios=353 dbytes=0 dios=0\n" +
+		"254:0 rbytes=4096 wbytes=0 rios=1 wios=0 dbytes=0 dios=0\n"
+
+	ioStats, err := parseIOStat(strings.NewReader(ioStat))
+	if err != nil {
+		t.Fatalf("could not parse %s: %v", IoStatFile, err)
+	}
+
+	expected := []IOStats{
+		{Device: "8:16", ReadBytes: 1459200, WriteBytes: 314773504, ReadIOs: 192, WriteIOs: 353},
+		{Device: "254:0", ReadBytes: 4096, ReadIOs: 1},
+	}
+	if !reflect.DeepEqual(ioStats, expected) {
+		t.Errorf("expected %+v, got %+v", expected, ioStats)
+	}
+}
+
+func Test_ReadUsage(t *testing.T) {
+	// not parallel, because the test replaces rootCgroupPath with a fake cgroup hierarchy
+	defer func(path string) { rootCgroupPath = path }(rootCgroupPath)
+	rootCgroupPath = t.TempDir()
+
+	cgroupName := "fakecgroup"
+	if err := os.MkdirAll(GetCGroupPath(cgroupName), FileModeWeb); err != nil {
+		t.Fatalf("could not create fake cgroup: %v", err)
+	}
+
+	// pids.peak and memory.peak are missing like on older kernels
+	files := map[string]string{
+		CpuStatFile:       "usage_usec 2500000\nuser_usec 2000000\nsystem_usec 500000\nnr_periods 40\nnr_throttled 10\nthrottled_usec 300000\n",
+		MemoryCurrentFile: "1048576\n",
+		MemoryStatFile:    "anon 524288\nfile 262144\n",
+		IoStatFile:        "8:0 rbytes=1024 wbytes=2048 rios=1 wios=2 dbytes=0 dios=0\n",
+		PidsCurrentFile:   "3\n",
+		PidsEventsFile:    "max 2\n",
+	}
+	for file, content := range files {
+		if err := os.WriteFile(filepath.Join(GetCGroupPath(cgroupName), file), []byte(content), FileModeWeb); err != nil {
+			t.Fatalf("could not write %s: %v", file, err)
+		}
+	}
+
+	usage, err := ReadUsage(cgroupName)
+	if err != nil {
+		t.Fatalf("could not read usage: %v", err)
+	}
+
+	expected := &Usage{
+		CPU: CPUStats{
+			UsageUsec:     2500000,
+			UserUsec:      2000000,
+			SystemUsec:    500000,
+			NrPeriods:     40,
+			NrThrottled:   10,
+			ThrottledUsec: 300000,
+		},
+		Memory: MemoryStats{
+			Current: 1048576,
+			Stat:    map[string]int64{"anon": 524288, "file": 262144},
+		},
+		IO:   []IOStats{{Device: "8:0", ReadBytes: 1024, WriteBytes: 2048, ReadIOs: 1, WriteIOs: 2}},
+		Pids: PidsStats{Current: 3, LimitHits: 2},
+	}
+	if !reflect.DeepEqual(usage, expected) {
+		t.Errorf("expected %+v, got %+v", expected, usage)
+	}
+}
@@ -0,0 +1,67 @@
+package namespaces
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// GetIDMappings returns the UID or GID mappings of a user namespace, which map root to hostID and the IDs from 1
+// to the first subordinate ID range of the user with the given name or UID in subIDFile, such as /etc/subuid
+func GetIDMappings(subIDFile string, name string, uid int, hostID int) ([]syscall.SysProcIDMap, error) {
+	mappings := []syscall.SysProcIDMap{{ContainerID: 0, HostID: hostID, Size: 1}}
+
+	file, err := os.Open(subIDFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return mappings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", subIDFile, err)
+	}
+	defer file.Close()
+
+	// each line is <user name or UID>:<first subordinate ID>:<number of IDs>
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Split(strings.TrimSpace(scanner.Text()), ":")
+		if len(fields) != 3 || (fields[0] != name && fields[0] != strconv.Itoa(uid)) {
+			continue
+		}
+
+		start, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", subIDFile, err)
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", subIDFile, err)
+		}
+		if size > 0 {
+			return append(mappings, syscall.SysProcIDMap{ContainerID: 1, HostID: start, Size: size}), nil
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", subIDFile, err)
+	}
+	return mappings, nil
+}
+
+// IsIDMapped returns true, if id of the user namespace is mapped to a host ID by mappings
+func IsIDMapped(mappings []syscall.SysProcIDMap, id uint32) bool {
+	_, ok := GetHostID(mappings, id)
+	return ok
+}
+
+// GetHostID returns the host ID id of the user namespace is mapped to by mappings, false if id is not mapped
+func GetHostID(mappings []syscall.SysProcIDMap, id uint32) (int, bool) {
+	for _, mapping := range mappings {
+		if int64(id) >= int64(mapping.ContainerID) && int64(id) < int64(mapping.ContainerID)+int64(mapping.Size) {
+			return mapping.HostID + int(id) - mapping.ContainerID, true
+		}
+	}
+	return 0, false
+}
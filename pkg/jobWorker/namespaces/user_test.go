@@ -0,0 +1,71 @@
+package namespaces
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+func Test_GetIDMappings(t *testing.T) {
+	t.Parallel()
+
+	subIDFile := filepath.Join(t.TempDir(), "subuid")
+	content := "other:100000:65536\njobworker:165536:65536\n1001:231072:1000\nempty:296608:0\n"
+	if err := os.WriteFile(subIDFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("could not write subuid: %v", err)
+	}
+
+	testCases := []struct {
+		subIDFile        string
+		name             string
+		uid              int
+		expectedMappings []syscall.SysProcIDMap
+	}{
+		{subIDFile: subIDFile, name: "jobworker", uid: 1000, expectedMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: 5000, Size: 1}, {ContainerID: 1, HostID: 165536, Size: 65536},
+		}},
+		{subIDFile: subIDFile, name: "unknown", uid: 1001, expectedMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: 5000, Size: 1}, {ContainerID: 1, HostID: 231072, Size: 1000},
+		}},
+		{subIDFile: subIDFile, name: "empty", uid: 1002, expectedMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: 5000, Size: 1},
+		}},
+		{subIDFile: filepath.Join(t.TempDir(), "missing"), name: "jobworker", uid: 1000, expectedMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: 5000, Size: 1},
+		}},
+	}
+
+	for _, testCase := range testCases {
+		mappings, err := GetIDMappings(testCase.subIDFile, testCase.name, testCase.uid, 5000)
+		if err != nil || !reflect.DeepEqual(mappings, testCase.expectedMappings) {
+			t.Errorf("name:%s, expected %v, got %v, error: %v", testCase.name, testCase.expectedMappings, mappings, err)
+		}
+	}
+}
+
+func Test_IsIDMapped(t *testing.T) {
+	t.Parallel()
+
+	mappings := []syscall.SysProcIDMap{{ContainerID: 0, HostID: 5000, Size: 1}, {ContainerID: 1, HostID: 165536, Size: 1000}}
+	for id, expected := range map[uint32]bool{0: true, 1: true, 1000: true, 1001: false, 65534: false} {
+		if IsIDMapped(mappings, id) != expected {
+			t.Errorf("id:%d, expected mapped %t", id, expected)
+		}
+	}
+}
+
+func Test_GetHostID(t *testing.T) {
+	t.Parallel()
+
+	mappings := []syscall.SysProcIDMap{{ContainerID: 0, HostID: 5000, Size: 1}, {ContainerID: 1, HostID: 165536, Size: 1000}}
+	for id, expected := range map[uint32]int{0: 5000, 1: 165536, 1000: 166535} {
+		if hostID, ok := GetHostID(mappings, id); !ok || hostID != expected {
+			t.Errorf("id:%d, expected host id %d, got %d", id, expected, hostID)
+		}
+	}
+	if _, ok := GetHostID(mappings, 1001); ok {
+		t.Errorf("id:1001, expected not mapped")
+	}
+}
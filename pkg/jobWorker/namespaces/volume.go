@@ -0,0 +1,187 @@
+package namespaces
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+const (
+	// VolumeBind mounts the host directory or file Source at Target
+	VolumeBind = "bind"
+	// VolumeTmpfs mounts an empty tmpfs at Target
+	VolumeTmpfs = "tmpfs"
+	// VolumeReadOnly remounts Target of the job's root filesystem read-only
+	VolumeReadOnly = "ro"
+)
+
+var (
+	ErrInvalidVolume = errors.New("volume must be bind with absolute Source, tmpfs or ro with absolute Target and SizeBytes not negative")
+)
+
+// statfsMountFlags maps statfs flags to mount flags, which must be kept when a mount is remounted in a user namespace
+var statfsMountFlags = map[int64]uintptr{
+	0x0002: syscall.MS_NOSUID,
+	0x0004: syscall.MS_NODEV,
+	0x0008: syscall.MS_NOEXEC,
+	0x0400: syscall.MS_NOATIME,
+	0x0800: syscall.MS_NODIRATIME,
+	0x1000: syscall.MS_RELATIME,
+}
+
+// Volume describes a mount set up in the job's mount namespace
+type Volume struct {
+	// Type is VolumeBind, VolumeTmpfs or VolumeReadOnly.
+	Type string
+	// Source is the absolute path of the host directory or file to bind mount, only used by VolumeBind.
+	Source string
+	// Target is the absolute path in the job's root filesystem.
+	Target string
+	// ReadOnly mounts VolumeBind and VolumeTmpfs read-only.
+	ReadOnly bool
+	// SizeBytes limits the size of VolumeTmpfs (optional, half of the memory by default).
+	SizeBytes int64
+}
+
+// IsValid returns ErrInvalidVolume, if the volume is not complete
+func (volume *Volume) IsValid() error {
+	if !filepath.IsAbs(volume.Target) || volume.SizeBytes < 0 {
+		return ErrInvalidVolume
+	}
+
+	switch volume.Type {
+	case VolumeBind:
+		if !filepath.IsAbs(volume.Source) {
+			return ErrInvalidVolume
+		}
+	case VolumeTmpfs, VolumeReadOnly:
+	default:
+		return ErrInvalidVolume
+	}
+	return nil
+}
+
+func (volume *Volume) String() string {
+	mode := "rw"
+	if volume.ReadOnly || volume.Type == VolumeReadOnly {
+		mode = "ro"
+	}
+	return fmt.Sprintf("%s %s:%s (%s)", volume.Type, volume.Source, volume.Target, mode)
+}
+
+// MountVolume - mount a volume at its target below rootfs, creating the target if it doesn't exist.
+// If rootfs is "/", the job runs on the host's root filesystem, which is never changed, so the target must exist.
+// MountVolume must be called before PivotRoot, while the host paths of bind mounts are still reachable.
+func MountVolume(rootfs string, volume *Volume) error {
+	if err := volume.IsValid(); err != nil {
+		return err
+	}
+	target := filepath.Join(rootfs, volume.Target)
+	if err := checkNoSymlinks(rootfs, target); err != nil {
+		return err
+	}
+	if rootfs == "/" {
+		if _, err := os.Stat(target); err != nil {
+			return fmt.Errorf("%w: %s must exist on the host without a root filesystem", ErrInvalidVolume, target)
+		}
+	}
+
+	switch volume.Type {
+	case VolumeBind:
+		info, err := os.Stat(volume.Source)
+		if err != nil {
+			return fmt.Errorf("error reading volume source: %w", err)
+		}
+		if err = createMountPoint(target, info.IsDir()); err != nil {
+			return err
+		}
+
+		if err = syscall.Mount(volume.Source, target, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+			return fmt.Errorf("error mounting volume %s: %w", volume, err)
+		}
+	case VolumeTmpfs:
+		if err := createMountPoint(target, true); err != nil {
+			return err
+		}
+
+		options := "mode=1777"
+		if volume.SizeBytes > 0 {
+			options += fmt.Sprintf(",size=%d", volume.SizeBytes)
+		}
+		if err := syscall.Mount("tmpfs", target, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, options); err != nil {
+			return fmt.Errorf("error mounting volume %s: %w", volume, err)
+		}
+	case VolumeReadOnly:
+		// only a mount can be remounted, so bind the target onto itself first
+		if err := syscall.Mount(target, target, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+			return fmt.Errorf("error mounting volume %s: %w", volume, err)
+		}
+	}
+
+	if volume.ReadOnly || volume.Type == VolumeReadOnly {
+		if err := remountReadOnly(target); err != nil {
+			return fmt.Errorf("error mounting volume %s: %w", volume, err)
+		}
+	}
+	return nil
+}
+
+// remountReadOnly remounts a mount point read-only, keeping its nosuid, nodev, noexec and atime flags,
+// because a user namespace can't clear flags of mounts it has inherited
+func remountReadOnly(target string) error {
+	var statfs syscall.Statfs_t
+	if err := syscall.Statfs(target, &statfs); err != nil {
+		return err
+	}
+
+	flags := uintptr(syscall.MS_REMOUNT | syscall.MS_BIND | syscall.MS_RDONLY)
+	for statfsFlag, mountFlag := range statfsMountFlags {
+		if statfs.Flags&statfsFlag != 0 {
+			flags |= mountFlag
+		}
+	}
+	return syscall.Mount("", target, "", flags, "")
+}
+
+// checkNoSymlinks returns ErrInvalidVolume, if any existing element of target below rootfs is a symlink,
+// which would be resolved against the host's root filesystem before PivotRoot
+func checkNoSymlinks(rootfs string, target string) error {
+	for path := target; path != rootfs && path != filepath.Dir(path); path = filepath.Dir(path) {
+		info, err := os.Lstat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error reading volume target: %w", err)
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%w: %s is a symlink", ErrInvalidVolume, path)
+		}
+	}
+	return nil
+}
+
+// createMountPoint creates an empty directory or file to mount on, if target doesn't exist
+func createMountPoint(target string, isDir bool) error {
+	if _, err := os.Stat(target); err == nil {
+		return nil
+	}
+
+	if isDir {
+		if err := os.MkdirAll(target, FileModeWeb); err != nil {
+			return fmt.Errorf("error creating %s: %w", target, err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), FileModeWeb); err != nil {
+		return fmt.Errorf("error creating %s: %w", filepath.Dir(target), err)
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_RDONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", target, err)
+	}
+	return file.Close()
+}
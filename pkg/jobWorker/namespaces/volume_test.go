@@ -0,0 +1,76 @@
+package namespaces
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Volume_IsValid(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		volume  Volume
+		isValid bool
+	}{
+		{volume: Volume{Type: VolumeBind, Source: "/data", Target: "/data", ReadOnly: true}, isValid: true},
+		{volume: Volume{Type: VolumeTmpfs, Target: "/scratch", SizeBytes: 100_000_000}, isValid: true},
+		{volume: Volume{Type: VolumeReadOnly, Target: "/usr"}, isValid: true},
+		{volume: Volume{Type: VolumeBind, Source: "data", Target: "/data"}},
+		{volume: Volume{Type: VolumeBind, Target: "/data"}},
+		{volume: Volume{Type: VolumeTmpfs, Target: "scratch"}},
+		{volume: Volume{Type: VolumeTmpfs, Target: "/scratch", SizeBytes: -1}},
+		{volume: Volume{Type: "nfs", Source: "/data", Target: "/data"}},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.volume.IsValid()
+		if testCase.isValid && err != nil {
+			t.Errorf("volume:%s, expected to be valid, got %v", &testCase.volume, err)
+		}
+		if !testCase.isValid && err != ErrInvalidVolume {
+			t.Errorf("volume:%s, expected error(ErrInvalidVolume), got %v", &testCase.volume, err)
+		}
+	}
+}
+
+func Test_checkNoSymlinks_expected_ErrInvalidVolume(t *testing.T) {
+	t.Parallel()
+
+	rootfs := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(rootfs, "usr/lib"), FileModeWeb); err != nil {
+		t.Fatalf("could not create rootfs: %v", err)
+	}
+	// absolute symlinks in a root filesystem point to the host before pivot_root
+	if err := os.Symlink("/etc", filepath.Join(rootfs, "data")); err != nil {
+		t.Fatalf("could not create rootfs: %v", err)
+	}
+
+	if err := checkNoSymlinks(rootfs, filepath.Join(rootfs, "usr/lib/new")); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err := checkNoSymlinks(rootfs, filepath.Join(rootfs, "data/passwd")); !errors.Is(err, ErrInvalidVolume) {
+		t.Errorf("expected error(ErrInvalidVolume), got %v", err)
+	}
+}
+
+func Test_MountVolume_host_rootfs_expected_ErrInvalidVolume(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.Symlink(dir, filepath.Join(dir, "link")); err != nil {
+		t.Fatalf("could not create symlink: %v", err)
+	}
+
+	// neither creates a mount point on the host nor mounts anything
+	for _, target := range []string{filepath.Join(dir, "missing"), filepath.Join(dir, "link")} {
+		volume := &Volume{Type: VolumeTmpfs, Target: target}
+		if err := MountVolume("/", volume); !errors.Is(err, ErrInvalidVolume) {
+			t.Errorf("target:%s, expected error(ErrInvalidVolume), got %v", target, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected no mount point created on the host, got %v", err)
+	}
+}
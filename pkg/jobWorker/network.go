@@ -0,0 +1,297 @@
+package jobWorker
+
+import (
+	"errors"
+	"fmt"
+	ns "github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker/namespaces"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sync"
+)
+
+var (
+	ErrInvalidPortMappings = errors.New("PortMappings require NetworkMode bridge and each host port can only be mapped once")
+	ErrPortAllocated       = errors.New("host port is already forwarded to another job")
+	ErrHostPortInUse       = errors.New("host port is already in use on the host")
+	ErrInvalidNetworkLimit = errors.New("network bandwidth limits require NetworkMode bridge")
+)
+
+const (
+	// jobInterfaceName is the name of the job's end of the veth pair in the job's network namespace
+	jobInterfaceName = "eth0"
+	// hostVethPrefix and peerVethPrefix prefix the job's short ID in the names of the veth pair on the host
+	hostVethPrefix = "vj"
+	peerVethPrefix = "vp"
+	// ifbPrefix prefixes the job's short ID in the name of the IFB device shaping the job's egress
+	ifbPrefix = "vb"
+)
+
+var (
+	// BridgeName is the bridge of the host jobs with NetworkMode bridge are connected to, created if it doesn't exist
+	BridgeName = "jobworker0"
+	// BridgeSubnet is the IPv4 subnet addresses of jobs are allocated from, the first address is the bridge's
+	BridgeSubnet = "10.88.0.0/16"
+	// BridgeNAT masquerades traffic of jobs leaving the host, so that jobs can reach other networks
+	BridgeNAT = false
+)
+
+// bridgeNetwork is the state of the bridge shared by all jobs, set up by the first job with NetworkMode bridge
+var bridgeNetwork = struct {
+	sync.Mutex
+	allocator *ns.IPAllocator
+	// hostPorts are the listeners reserving the host ports forwarded to jobs by protocol and port
+	hostPorts map[string]io.Closer
+}{hostPorts: map[string]io.Closer{}}
+
+// jobNetwork is the part of the bridge a job holds while it runs
+type jobNetwork struct {
+	hostVeth string
+	address  *net.IPNet
+	// ifb is the IFB device shaping the job's egress, empty if the egress is not limited
+	ifb string
+	// namespace keeps the job's network namespace and veth pair alive once the job has exited,
+	// so that the final traffic of the job can be recorded
+	namespace *os.File
+}
+
+// getNetworkMode returns the network mode of the job, ns.NetworkNone if not set
+func (jobConfig *JobConfig) getNetworkMode() string {
+	if jobConfig.NetworkMode == "" {
+		return ns.NetworkNone
+	}
+	return jobConfig.NetworkMode
+}
+
+// isValidPortMappings returns ErrInvalidPortMappings, if the job maps ports without NetworkMode bridge
+// or maps a host port twice
+func (jobConfig *JobConfig) isValidPortMappings() error {
+	if len(jobConfig.PortMappings) > 0 && jobConfig.getNetworkMode() != ns.NetworkBridge {
+		return ErrInvalidPortMappings
+	}
+
+	hostPorts := map[string]bool{}
+	for _, mapping := range jobConfig.PortMappings {
+		if err := mapping.IsValid(); err != nil {
+			return err
+		}
+		if hostPorts[getHostPortKey(mapping)] {
+			return ErrInvalidPortMappings
+		}
+		hostPorts[getHostPortKey(mapping)] = true
+	}
+	return nil
+}
+
+// isValidNetworkLimits returns ErrInvalidNetworkLimit, if the job limits bandwidth without NetworkMode bridge,
+// and ns.ErrInvalidBandwidth, if the limits are out of range
+func (jobConfig *JobConfig) isValidNetworkLimits() error {
+	if (jobConfig.NetworkIngressBytesPerSecond != 0 || jobConfig.NetworkEgressBytesPerSecond != 0) &&
+		jobConfig.getNetworkMode() != ns.NetworkBridge {
+		return ErrInvalidNetworkLimit
+	}
+	return ns.IsValidBandwidth(jobConfig.NetworkIngressBytesPerSecond, jobConfig.NetworkEgressBytesPerSecond)
+}
+
+// getHostPortKey returns the protocol and host port of mapping, such as "tcp/8080"
+func getHostPortKey(mapping ns.PortMapping) string {
+	return fmt.Sprintf("%s/%d", mapping.GetProtocol(), mapping.HostPort)
+}
+
+// setupBridge creates the bridge and the NAT table, unless the bridge has been set up already.
+// Must be called with bridgeNetwork held.
+func setupBridge() error {
+	if bridgeNetwork.allocator != nil {
+		return nil
+	}
+
+	allocator, err := ns.NewIPAllocator(BridgeSubnet)
+	if err != nil {
+		return err
+	}
+	if err = ns.SetupBridge(BridgeName, allocator.Gateway()); err != nil {
+		return err
+	}
+	if err = ns.SetupNAT(BridgeName, allocator.Subnet(), BridgeNAT); err != nil {
+		return err
+	}
+	if BridgeNAT {
+		if err = ns.EnableIPForwarding(); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("bridge:%s set up with subnet:%s", BridgeName, allocator.Subnet())
+	bridgeNetwork.allocator = allocator
+	return nil
+}
+
+// setupNetwork connects the network namespace of the started init shim to the bridge and forwards the job's ports,
+// if the job's NetworkMode is bridge. The returned interface is configured by the init shim.
+func (job *Job) setupNetwork(pid int) (*ns.NetworkInterface, error) {
+	if job.config.getNetworkMode() != ns.NetworkBridge {
+		return nil, nil
+	}
+
+	bridgeNetwork.Lock()
+	defer bridgeNetwork.Unlock()
+
+	if err := setupBridge(); err != nil {
+		return nil, fmt.Errorf("error setting up bridge: %w", err)
+	}
+
+	address, err := bridgeNetwork.allocator.Allocate()
+	if err != nil {
+		return nil, err
+	}
+	network := &jobNetwork{hostVeth: hostVethPrefix + job.getShortID(), address: address}
+	peerVeth := peerVethPrefix + job.getShortID()
+
+	if network.namespace, err = ns.OpenNetworkNamespace(pid); err != nil {
+		bridgeNetwork.allocator.Release(address.IP)
+		return nil, err
+	}
+	if err = ns.AddVeth(BridgeName, network.hostVeth, peerVeth, pid); err != nil {
+		_ = network.namespace.Close()
+		bridgeNetwork.allocator.Release(address.IP)
+		return nil, err
+	}
+	job.network = network
+
+	if job.config.NetworkEgressBytesPerSecond > 0 {
+		network.ifb = ifbPrefix + job.getShortID()
+	}
+	if err = ns.SetBandwidth(network.hostVeth, network.ifb, job.config.NetworkIngressBytesPerSecond, job.config.NetworkEgressBytesPerSecond); err != nil {
+		job.removeNetworkLocked()
+		return nil, err
+	}
+
+	for _, mapping := range job.config.PortMappings {
+		if err = ns.AddPortForwarding(job.UUID.String(), mapping, address.IP); err != nil {
+			job.removeNetworkLocked()
+			return nil, err
+		}
+	}
+
+	return &ns.NetworkInterface{
+		Name:    peerVeth,
+		Address: address.String(),
+		Gateway: bridgeNetwork.allocator.Gateway().IP.String(),
+	}, nil
+}
+
+// readNetworkStats adds the traffic of the job's veth pair to usage, if network connects the job to the bridge
+func readNetworkStats(network *jobNetwork, usage *ns.Usage) error {
+	if network == nil {
+		return nil
+	}
+
+	stats, err := ns.ReadNetworkStats(network.hostVeth, network.ifb)
+	if err != nil {
+		return err
+	}
+	usage.Network = *stats
+	return nil
+}
+
+// removeNetwork deletes the job's port forwarding rules, veth pair and IFB device and releases its address
+func (job *Job) removeNetwork() error {
+	bridgeNetwork.Lock()
+	defer bridgeNetwork.Unlock()
+
+	return job.removeNetworkLocked()
+}
+
+// removeNetworkLocked is removeNetwork with bridgeNetwork held
+func (job *Job) removeNetworkLocked() error {
+	network := job.network
+	if network == nil {
+		return nil
+	}
+	job.network = nil
+
+	var err error
+	if len(job.config.PortMappings) > 0 {
+		err = ns.DeletePortForwarding(job.UUID.String())
+	}
+	// the veth pair is deleted with the job's network namespace too, unless processes of the job are still running
+	err = errors.Join(err, ns.DeleteLink(network.hostVeth))
+	if network.ifb != "" {
+		err = errors.Join(err, ns.DeleteLink(network.ifb))
+	}
+	_ = network.namespace.Close()
+
+	bridgeNetwork.allocator.Release(network.address.IP)
+	return err
+}
+
+// reserveHostPorts reserves the host ports of the job's PortMappings until releaseHostPorts is called by listening on
+// them, so that neither services of the host nor other jobs can use them while they are forwarded to the job.
+//
+// ErrPortAllocated is returned, if a host port is forwarded to another job, and ErrHostPortInUse, if a service of the
+// host uses it.
+func (job *Job) reserveHostPorts() error {
+	bridgeNetwork.Lock()
+	defer bridgeNetwork.Unlock()
+
+	for _, mapping := range job.config.PortMappings {
+		hostPort := getHostPortKey(mapping)
+		if _, ok := bridgeNetwork.hostPorts[hostPort]; ok {
+			job.releaseHostPortsLocked()
+			return fmt.Errorf("%w: %s", ErrPortAllocated, hostPort)
+		}
+
+		listener, err := listenHostPort(mapping)
+		if err != nil {
+			job.releaseHostPortsLocked()
+			return fmt.Errorf("%w: %s, %v", ErrHostPortInUse, hostPort, err)
+		}
+		bridgeNetwork.hostPorts[hostPort] = listener
+		job.hostPorts = append(job.hostPorts, hostPort)
+	}
+	return nil
+}
+
+// releaseHostPorts stops listening on the host ports reserved by reserveHostPorts
+func (job *Job) releaseHostPorts() {
+	bridgeNetwork.Lock()
+	defer bridgeNetwork.Unlock()
+
+	job.releaseHostPortsLocked()
+}
+
+// releaseHostPortsLocked is releaseHostPorts with bridgeNetwork held
+func (job *Job) releaseHostPortsLocked() {
+	for _, hostPort := range job.hostPorts {
+		if listener, ok := bridgeNetwork.hostPorts[hostPort]; ok {
+			_ = listener.Close()
+			delete(bridgeNetwork.hostPorts, hostPort)
+		}
+	}
+	job.hostPorts = nil
+}
+
+// listenHostPort listens on the host port of mapping on all addresses of the host. The port forwarding rules forward
+// IPv4 traffic to the job before it reaches the listener, other connections are closed right away.
+func listenHostPort(mapping ns.PortMapping) (io.Closer, error) {
+	address := fmt.Sprintf(":%d", mapping.HostPort)
+	if mapping.GetProtocol() == ns.ProtocolUDP {
+		return net.ListenPacket("udp", address)
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	return listener, nil
+}
@@ -0,0 +1,108 @@
+package jobWorker
+
+import (
+	"errors"
+	"fmt"
+	ns "github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker/namespaces"
+	"net"
+	"testing"
+)
+
+func Test_JobConfig_isValid_network(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		networkMode  string
+		portMappings []ns.PortMapping
+		ingress      int64
+		egress       int64
+		expectedErr  error
+	}{
+		{},
+		{networkMode: ns.NetworkLoopback},
+		{networkMode: ns.NetworkBridge, portMappings: []ns.PortMapping{{HostPort: 8080, ContainerPort: 80}}},
+		{
+			networkMode:  ns.NetworkBridge,
+			portMappings: []ns.PortMapping{{HostPort: 8080, ContainerPort: 80}, {Protocol: ns.ProtocolUDP, HostPort: 8080, ContainerPort: 80}},
+		},
+		{networkMode: "host", expectedErr: ns.ErrInvalidNetworkMode},
+		{networkMode: ns.NetworkLoopback, portMappings: []ns.PortMapping{{HostPort: 8080, ContainerPort: 80}}, expectedErr: ErrInvalidPortMappings},
+		{
+			networkMode:  ns.NetworkBridge,
+			portMappings: []ns.PortMapping{{HostPort: 8080, ContainerPort: 80}, {HostPort: 8080, ContainerPort: 81}},
+			expectedErr:  ErrInvalidPortMappings,
+		},
+		{networkMode: ns.NetworkBridge, portMappings: []ns.PortMapping{{HostPort: 8080}}, expectedErr: ns.ErrInvalidPortMapping},
+		{networkMode: ns.NetworkBridge, ingress: 1_000_000, egress: 1_000_000},
+		{networkMode: ns.NetworkLoopback, ingress: 1_000_000, expectedErr: ErrInvalidNetworkLimit},
+		{egress: 1_000_000, expectedErr: ErrInvalidNetworkLimit},
+		{networkMode: ns.NetworkBridge, ingress: -1, expectedErr: ns.ErrInvalidBandwidth},
+		{networkMode: ns.NetworkBridge, egress: -1, expectedErr: ns.ErrInvalidBandwidth},
+	}
+
+	for _, testCase := range testCases {
+		config := JobConfig{
+			Command:          "ls",
+			CPU:              0.5,
+			MemBytes:         1_000_000,
+			IOBytesPerSecond: 1_000_000,
+			NetworkMode:      testCase.networkMode,
+			PortMappings:     testCase.portMappings,
+
+			NetworkIngressBytesPerSecond: testCase.ingress,
+			NetworkEgressBytesPerSecond:  testCase.egress,
+		}
+		if err := config.isValid(); !errors.Is(err, testCase.expectedErr) {
+			t.Errorf("networkMode:%s portMappings:%v ingress:%d egress:%d, expected error %v, got %v",
+				testCase.networkMode, testCase.portMappings, testCase.ingress, testCase.egress, testCase.expectedErr, err)
+		}
+	}
+}
+
+func Test_Job_reserveHostPorts(t *testing.T) {
+	// not parallel, because the test reserves host ports of all jobs
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer listener.Close()
+	usedPort := uint16(listener.Addr().(*net.TCPAddr).Port)
+
+	freeListener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	freePort := uint16(freeListener.Addr().(*net.TCPAddr).Port)
+	_ = freeListener.Close()
+
+	newJob := func(hostPort uint16) *Job {
+		return NewJob(&JobConfig{
+			NetworkMode:  ns.NetworkBridge,
+			PortMappings: []ns.PortMapping{{HostPort: hostPort, ContainerPort: 80}},
+		})
+	}
+
+	// a service of the host listens on the port
+	if err = newJob(usedPort).reserveHostPorts(); !errors.Is(err, ErrHostPortInUse) {
+		t.Errorf("expected error(ErrHostPortInUse), got %v", err)
+	}
+
+	job := newJob(freePort)
+	if err = job.reserveHostPorts(); err != nil {
+		t.Fatalf("could not reserve host port: %v", err)
+	}
+	// the port is in use for services of the host and forwarded to the job only
+	if _, err = net.Listen("tcp", fmt.Sprintf(":%d", freePort)); err == nil {
+		t.Errorf("expected host port %d to be reserved", freePort)
+	}
+	if err = newJob(freePort).reserveHostPorts(); !errors.Is(err, ErrPortAllocated) {
+		t.Errorf("expected error(ErrPortAllocated), got %v", err)
+	}
+
+	job.releaseHostPorts()
+	otherJob := newJob(freePort)
+	if err = otherJob.reserveHostPorts(); err != nil {
+		t.Errorf("expected released host port to be reserved again, got %v", err)
+	}
+	otherJob.releaseHostPorts()
+}
@@ -0,0 +1,583 @@
+package jobWorker
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	ns "github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker/namespaces"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInvalidRootFS             = errors.New("RootFS must be an absolute path to a directory or a tarball")
+	ErrInvalidRootFSOverlay      = errors.New("RootFSOverlay requires RootFS and KeepRootFSChanges requires RootFSOverlay or Image")
+	ErrInvalidTarballPath        = errors.New("tarball entry points outside of the root filesystem")
+	ErrRootFSChangesNotAvailable = errors.New("rootfs changes are only kept for completed jobs with KeepRootFSChanges")
+	ErrUnsupportedCompression    = errors.New("only uncompressed and gzip compressed tarballs are supported")
+)
+
+const (
+	// whiteoutPrefix marks deleted files in OCI image layers
+	whiteoutPrefix = ".wh."
+	// opaqueWhiteout marks directories whose content of lower layers is hidden in OCI image layers
+	opaqueWhiteout = ".wh..wh..opq"
+	// overlayOpaqueXattr marks opaque directories in an overlay upper directory mounted with userxattr
+	overlayOpaqueXattr = "user.overlay.opaque"
+)
+
+// StateDir is the directory the server keeps jobs' state in, such as unpacked root filesystems
+var StateDir = "/var/lib/jobworker"
+
+// RootFSChangesTTL is how long the overlay upper layer of a completed job with KeepRootFSChanges is kept for export
+var RootFSChangesTTL = 24 * time.Hour
+
+// gzipMagic are the first bytes of a gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// zstdMagic are the first bytes of a zstd stream
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// getStateDir returns the directory of the job's state
+func (job *Job) getStateDir() string {
+	return filepath.Join(StateDir, "jobs", job.getCGroupName())
+}
+
+// getLayersDir returns the directory of unpacked tarballs shared between jobs
+func getLayersDir() string {
+	return filepath.Join(StateDir, "layers")
+}
+
+// PrepareRootFS unpacks the job's RootFS or Image, which takes long for large tarballs and images, so that callers
+// can do it without holding their own locks. Start prepares the root filesystem itself, unless PrepareRootFS has been
+// called before, and removes it, if the job fails to start.
+func (job *Job) PrepareRootFS() error {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	if err := job.config.isValid(); err != nil {
+		return err
+	}
+	if job.isStarted {
+		return ErrJobAlreadyStarted
+	}
+	return job.prepareRootFSOnce()
+}
+
+// prepareRootFSOnce sets rootfs and overlay of the job by prepareRootFS, unless they have been prepared before
+func (job *Job) prepareRootFSOnce() error {
+	if job.isRootFSPrepared {
+		return nil
+	}
+
+	rootfs, overlay, err := job.prepareRootFS()
+	if err != nil {
+		return err
+	}
+	job.rootfs, job.overlay, job.isRootFSPrepared = rootfs, overlay, true
+	return nil
+}
+
+// prepareRootFS returns the directory the job pivots into, unpacking RootFS or Image first.
+// An empty string is returned if the job runs on the host's root filesystem.
+// With a RootFS directory, RootFSOverlay or Image the returned directory is the mount point of the overlay, mounted by
+// the init shim.
+func (job *Job) prepareRootFS() (string, *ns.Overlay, error) {
+	var lowerDir string
+
+	switch {
+	case job.config.Image != "":
+		image, err := loadImage(job.config.Image)
+		if err != nil {
+			return "", nil, err
+		}
+		if job.config.Command == "" && len(image.config.getCommand(job.config.Arguments)) == 0 {
+			return "", nil, ErrInvalidCommand
+		}
+		job.imageConfig = &image.config
+		job.layers = append(job.layers, image.rootfs)
+		// the unpacked image is shared by all jobs using it, so it is always the read-only lower layer of an overlay
+		lowerDir = image.rootfs
+	case job.config.RootFS == "":
+		return "", nil, nil
+	default:
+		info, err := os.Stat(job.config.RootFS)
+		if err != nil {
+			return "", nil, fmt.Errorf("error reading rootfs: %w", err)
+		}
+
+		if !job.config.RootFSOverlay && !info.IsDir() {
+			rootfs := filepath.Join(job.getStateDir(), "rootfs")
+			if err = os.MkdirAll(rootfs, 0o755); err != nil {
+				return "", nil, fmt.Errorf("error creating rootfs: %w", err)
+			}
+			if err = unpackRootFS(job.config.RootFS, rootfs); err != nil {
+				_ = job.removeState(false)
+				return "", nil, err
+			}
+			return rootfs, nil, nil
+		}
+
+		// the lower layer is never written, so a tarball is unpacked once and shared by all jobs using it, and a directory
+		// of the host is always an overlay's lower layer, so that neither the job nor the init shim change it
+		lowerDir = job.config.RootFS
+		if !info.IsDir() {
+			if lowerDir, err = unpackSharedRootFS(job.config.RootFS); err != nil {
+				return "", nil, err
+			}
+			job.layers = append(job.layers, lowerDir)
+		}
+	}
+
+	overlay := &ns.Overlay{
+		LowerDirs: []string{lowerDir},
+		UpperDir:  filepath.Join(job.getStateDir(), "upper"),
+		WorkDir:   filepath.Join(job.getStateDir(), "work"),
+	}
+	rootfs := filepath.Join(job.getStateDir(), "merged")
+	for _, dir := range []string{overlay.UpperDir, overlay.WorkDir, rootfs} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			_ = job.removeState(false)
+			return "", nil, fmt.Errorf("error creating overlay directories: %w", err)
+		}
+	}
+	return rootfs, overlay, nil
+}
+
+// removeState removes the job's state directory such as the unpacked root filesystem, if any, and releases the
+// shared layers of the job. The overlay upper directory is kept if keepRootFSChanges is true.
+func (job *Job) removeState(keepRootFSChanges bool) error {
+	for _, layer := range job.layers {
+		releaseLayer(layer)
+	}
+	job.layers = nil
+
+	if !keepRootFSChanges {
+		if err := os.RemoveAll(job.getStateDir()); err != nil {
+			return fmt.Errorf("error removing job state: %w", err)
+		}
+		return nil
+	}
+
+	for _, dir := range []string{"rootfs", "work", "merged", "hosts"} {
+		if err := os.RemoveAll(filepath.Join(job.getStateDir(), dir)); err != nil {
+			return fmt.Errorf("error removing job state: %w", err)
+		}
+	}
+	return nil
+}
+
+// ExportRootFSChanges writes the files the job has changed in its root filesystem as a tar layer, deleted files are
+// marked with OCI whiteouts.
+//
+// The changes are not removed while they are exported, RemoveRootFSChanges and RootFSChangesTTL wait for the export.
+//
+// ErrRootFSChangesNotAvailable is returned, if the job has not completed, doesn't keep its root filesystem changes
+// or they have been removed.
+func (job *Job) ExportRootFSChanges(writer io.Writer) error {
+	job.rootFSChangesLock.RLock()
+	defer job.rootFSChangesLock.RUnlock()
+
+	job.mutex.Lock()
+	isAvailable := job.hasRootFSChanges
+	job.mutex.Unlock()
+
+	if !isAvailable {
+		return ErrRootFSChangesNotAvailable
+	}
+
+	if err := packOverlayUpper(filepath.Join(job.getStateDir(), "upper"), writer); err != nil {
+		return fmt.Errorf("error exporting rootfs changes: %w", err)
+	}
+	return nil
+}
+
+// RemoveRootFSChanges removes the overlay upper layer a completed job has kept, before RootFSChangesTTL has passed.
+// It waits for running exports of ExportRootFSChanges to finish.
+//
+// ErrRootFSChangesNotAvailable is returned, if the job has not completed, doesn't keep its root filesystem changes
+// or they have been removed already.
+func (job *Job) RemoveRootFSChanges() error {
+	// exports hold the read lock without job.mutex, so it must be taken first
+	job.rootFSChangesLock.Lock()
+	defer job.rootFSChangesLock.Unlock()
+
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	if !job.hasRootFSChanges {
+		return ErrRootFSChangesNotAvailable
+	}
+	job.hasRootFSChanges = false
+	job.rootFSChangesTimer.Stop()
+
+	return job.removeState(false)
+}
+
+// unpackSharedRootFS unpacks a tarball into a directory named after the tarball's SHA-256 digest,
+// unless it has been unpacked already, and returns the directory, which must be released with releaseLayer
+func unpackSharedRootFS(tarball string) (string, error) {
+	digest, err := getFileDigest(tarball)
+	if err != nil {
+		return "", err
+	}
+
+	layer := filepath.Join(getLayersDir(), digest)
+	err = unpackOnce(layer, func(unpackDir string) error {
+		return unpackRootFS(tarball, unpackDir)
+	})
+	if err != nil {
+		return "", err
+	}
+	return layer, nil
+}
+
+// unpackOnce calls unpack with a temporary directory and renames it to dir, unless dir exists already.
+// Jobs sharing dir never see it partially unpacked. Once unpackOnce succeeds, dir is acquired and the caller must
+// release it with releaseLayer.
+func unpackOnce(dir string, unpack func(unpackDir string) error) (err error) {
+	acquireLayer(dir)
+	defer func() {
+		if err != nil {
+			releaseLayer(dir)
+		}
+	}()
+
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return fmt.Errorf("error creating %s: %w", filepath.Dir(dir), err)
+	}
+	unpackDir, err := os.MkdirTemp(filepath.Dir(dir), ".unpack-")
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", dir, err)
+	}
+	if err = os.Chmod(unpackDir, 0o755); err != nil {
+		_ = os.RemoveAll(unpackDir)
+		return fmt.Errorf("error creating %s: %w", dir, err)
+	}
+	if err = unpack(unpackDir); err != nil {
+		_ = os.RemoveAll(unpackDir)
+		return err
+	}
+
+	if err = os.Rename(unpackDir, dir); err != nil {
+		_ = os.RemoveAll(unpackDir)
+		// another job has unpacked the same content in the meantime
+		if _, statErr := os.Stat(dir); statErr == nil {
+			return nil
+		}
+		return fmt.Errorf("error creating %s: %w", dir, err)
+	}
+	return nil
+}
+
+func getFileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("error opening %s: %w", path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// unpackRootFS unpacks a tar or gzip compressed tar tarball into the rootfs directory
+func unpackRootFS(tarball string, rootfs string) error {
+	file, err := os.Open(tarball)
+	if err != nil {
+		return fmt.Errorf("error opening rootfs tarball: %w", err)
+	}
+	defer file.Close()
+
+	stream, err := newTarStream(file)
+	if err != nil {
+		return fmt.Errorf("error reading rootfs tarball: %w", err)
+	}
+	defer stream.Close()
+
+	if err = unpackTar(stream, rootfs, false); err != nil {
+		return fmt.Errorf("error unpacking rootfs tarball: %w", err)
+	}
+	return nil
+}
+
+// newTarStream returns a reader of the uncompressed tar, detecting gzip compression from the first bytes
+func newTarStream(reader io.Reader) (io.ReadCloser, error) {
+	bufferedReader := bufio.NewReader(reader)
+	magic, _ := bufferedReader.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(bufferedReader)
+	case bytes.Equal(magic, zstdMagic):
+		return nil, ErrUnsupportedCompression
+	}
+	return io.NopCloser(bufferedReader), nil
+}
+
+// unpackTar writes regular files, directories, symlinks and hard links of a tar stream into the root directory.
+// Device nodes are skipped, the job gets a minimal /dev from the host.
+// With whiteouts, the tar stream is an OCI image layer applied on top of the layers unpacked into root before, so
+// whiteout files remove files of those layers.
+func unpackTar(stream io.Reader, root string, whiteouts bool) error {
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	// layerPaths are the paths unpacked from this layer, which an opaque whiteout doesn't hide
+	layerPaths := map[string]bool{}
+
+	tarReader := tar.NewReader(stream)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		path, err := getTarEntryPath(root, header.Name)
+		if err != nil {
+			return err
+		}
+		if path == root {
+			continue
+		}
+
+		if whiteouts {
+			name := filepath.Base(path)
+			if name == opaqueWhiteout {
+				if err = removeLowerLayers(filepath.Dir(path), layerPaths); err != nil {
+					return err
+				}
+				continue
+			}
+			if strings.HasPrefix(name, whiteoutPrefix) {
+				if err = os.RemoveAll(filepath.Join(filepath.Dir(path), strings.TrimPrefix(name, whiteoutPrefix))); err != nil {
+					return err
+				}
+				continue
+			}
+			layerPaths[path] = true
+		}
+
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		mode := os.FileMode(header.Mode).Perm() | os.FileMode(header.Mode)&(os.ModeSetuid|os.ModeSetgid|os.ModeSticky)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			// replace anything but a directory, so that chmod and chown below never follow a symlink
+			if info, err := os.Lstat(path); err == nil && !info.IsDir() {
+				_ = os.Remove(path)
+			}
+			if err = os.Mkdir(path, mode); err != nil && !errors.Is(err, os.ErrExist) {
+				return err
+			}
+		case tar.TypeReg:
+			if err = writeTarFile(tarReader, path, mode); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			_ = os.Remove(path)
+			if err = os.Symlink(header.Linkname, path); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			target, err := getTarEntryPath(root, header.Linkname)
+			if err != nil {
+				return err
+			}
+			_ = os.Remove(path)
+			if err = os.Link(target, path); err != nil {
+				return err
+			}
+			// a hard link shares ownership and mode with its target
+			continue
+		default:
+			continue
+		}
+
+		if os.Geteuid() == 0 {
+			if err = os.Lchown(path, header.Uid, header.Gid); err != nil {
+				return err
+			}
+		}
+		if header.Typeflag != tar.TypeSymlink {
+			// chmod again, the mode set on creation is masked by umask
+			if err = os.Chmod(path, mode); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// removeLowerLayers removes the content of a directory, except for paths unpacked from the current layer
+func removeLowerLayers(dir string, layerPaths map[string]bool) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !layerPaths[path] {
+			if err = os.RemoveAll(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getTarEntryPath returns the path of a tarball entry within root.
+// ErrInvalidTarballPath is returned if the entry escapes root by ".." or through a symlink unpacked earlier.
+func getTarEntryPath(root string, name string) (string, error) {
+	path := filepath.Join(root, filepath.Clean("/"+name))
+
+	// the last element is never followed, it is replaced or created with O_EXCL, so check the closest existing parent
+	parent := filepath.Dir(path)
+	for {
+		resolved, err := filepath.EvalSymlinks(parent)
+		if errors.Is(err, os.ErrNotExist) && parent != root {
+			parent = filepath.Dir(parent)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if resolved != root && !strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+			return "", fmt.Errorf("%w: %s", ErrInvalidTarballPath, name)
+		}
+		return path, nil
+	}
+}
+
+func writeTarFile(reader io.Reader, path string, mode os.FileMode) error {
+	_ = os.Remove(path)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(file, reader); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// packOverlayUpper writes an overlay upper directory as a tar layer, converting overlay whiteouts and
+// opaque directories to OCI whiteout files
+func packOverlayUpper(upper string, writer io.Writer) error {
+	tarWriter := tar.NewWriter(writer)
+
+	err := filepath.WalkDir(upper, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == upper {
+			return nil
+		}
+
+		name, err := filepath.Rel(upper, path)
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		if isOverlayWhiteout(info) {
+			return tarWriter.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     filepath.Join(filepath.Dir(name), whiteoutPrefix+entry.Name()),
+				Mode:     0o644,
+				ModTime:  info.ModTime(),
+			})
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			header.Uid, header.Gid = int(stat.Uid), int(stat.Gid)
+		}
+		if err = tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+
+		switch {
+		case info.IsDir():
+			if isOverlayOpaque(path) {
+				return tarWriter.WriteHeader(&tar.Header{
+					Typeflag: tar.TypeReg,
+					Name:     filepath.Join(name, opaqueWhiteout),
+					Mode:     0o644,
+					ModTime:  info.ModTime(),
+				})
+			}
+		case info.Mode().IsRegular():
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			if _, err = io.Copy(tarWriter, file); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return tarWriter.Close()
+}
+
+// isOverlayWhiteout returns true for the character device 0/0 overlayfs creates for deleted files
+func isOverlayWhiteout(info fs.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && info.Mode()&os.ModeCharDevice != 0 && stat.Rdev == 0
+}
+
+func isOverlayOpaque(path string) bool {
+	value := make([]byte, 1)
+	size, err := syscall.Getxattr(path, overlayOpaqueXattr, value)
+	return err == nil && size == 1 && value[0] == 'y'
+}
@@ -0,0 +1,248 @@
+package jobWorker
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+type tarEntry struct {
+	header  tar.Header
+	content string
+}
+
+func newTarball(t *testing.T, entries []tarEntry) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, entry := range entries {
+		entry.header.Size = int64(len(entry.content))
+		if err := tarWriter.WriteHeader(&entry.header); err != nil {
+			t.Fatalf("could not write tar header: %v", err)
+		}
+		if _, err := tarWriter.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("could not write tar entry: %v", err)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatalf("could not close tar: %v", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatalf("could not close gzip: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func writeTarball(t *testing.T, path string, entries []tarEntry) {
+	t.Helper()
+
+	if err := os.WriteFile(path, newTarball(t, entries), 0o644); err != nil {
+		t.Fatalf("could not write tarball: %v", err)
+	}
+}
+
+func Test_Job_prepareRootFS_expected_unpacked_tarball(t *testing.T) {
+	// not parallel, because the test replaces StateDir
+	defer func(path string) { StateDir = path }(StateDir)
+	StateDir = t.TempDir()
+
+	tarball := filepath.Join(t.TempDir(), "rootfs.tar.gz")
+	writeTarball(t, tarball, []tarEntry{
+		{header: tar.Header{Typeflag: tar.TypeDir, Name: "bin/", Mode: 0o755}},
+		{header: tar.Header{Typeflag: tar.TypeReg, Name: "bin/hello", Mode: 0o755}, content: "#!/bin/sh\necho hello\n"},
+		{header: tar.Header{Typeflag: tar.TypeSymlink, Name: "bin/sh", Linkname: "hello"}},
+		{header: tar.Header{Typeflag: tar.TypeLink, Name: "bin/hello2", Linkname: "bin/hello"}},
+		{header: tar.Header{Typeflag: tar.TypeReg, Name: "../../etc/escaped", Mode: 0o644}, content: "inside"},
+		{header: tar.Header{Typeflag: tar.TypeChar, Name: "dev/null", Mode: 0o666, Devmajor: 1, Devminor: 3}},
+	})
+
+	job := NewJob(&JobConfig{Command: "/bin/hello", RootFS: tarball})
+	rootfs, _, err := job.prepareRootFS()
+	if err != nil {
+		t.Fatalf("could not prepare rootfs: %v", err)
+	}
+	if rootfs != filepath.Join(job.getStateDir(), "rootfs") {
+		t.Errorf("expected rootfs in the job's state directory, got %s", rootfs)
+	}
+
+	content, err := os.ReadFile(filepath.Join(rootfs, "bin/sh"))
+	if err != nil || string(content) != "#!/bin/sh\necho hello\n" {
+		t.Errorf("expected bin/sh to link bin/hello, got %q, error: %v", content, err)
+	}
+	if info, err := os.Stat(filepath.Join(rootfs, "bin/hello2")); err != nil || info.Mode().Perm() != 0o755 {
+		t.Errorf("expected bin/hello2 with mode 0755, got %v, error: %v", info, err)
+	}
+	if _, err = os.Stat(filepath.Join(rootfs, "etc/escaped")); err != nil {
+		t.Errorf("expected ../../etc/escaped to be unpacked within rootfs, got %v", err)
+	}
+	if _, err = os.Lstat(filepath.Join(rootfs, "dev/null")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected device nodes to be skipped, got %v", err)
+	}
+
+	if err = job.removeState(false); err != nil {
+		t.Fatalf("could not remove job state: %v", err)
+	}
+	if _, err = os.Stat(job.getStateDir()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected job state to be removed, got %v", err)
+	}
+}
+
+func Test_Job_PrepareRootFS_Start_fails_expected_removed_state(t *testing.T) {
+	// not parallel, because the test replaces StateDir and SeccompProfileDir
+	defer func(path string) { StateDir = path }(StateDir)
+	StateDir = t.TempDir()
+	defer func(path string) { SeccompProfileDir = path }(SeccompProfileDir)
+	SeccompProfileDir = t.TempDir()
+
+	tarball := filepath.Join(t.TempDir(), "rootfs.tar.gz")
+	writeTarball(t, tarball, []tarEntry{
+		{header: tar.Header{Typeflag: tar.TypeReg, Name: "bin/hello", Mode: 0o755}, content: "#!/bin/sh\necho hello\n"},
+	})
+
+	job := NewJob(&JobConfig{
+		Command:          "/bin/hello",
+		CPU:              0.5,
+		IOBytesPerSecond: 100_000_000,
+		MemBytes:         1_000_000_000,
+		RootFS:           tarball,
+		SeccompProfile:   "missing",
+	})
+	if err := job.PrepareRootFS(); err != nil {
+		t.Fatalf("could not prepare rootfs: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(job.getStateDir(), "rootfs/bin/hello")); err != nil {
+		t.Fatalf("expected unpacked rootfs, got %v", err)
+	}
+
+	if err := job.Start(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected missing seccomp profile, got %v", err)
+	}
+	if _, err := os.Stat(job.getStateDir()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected job state to be removed, got %v", err)
+	}
+}
+
+func Test_Job_prepareRootFS_directory_expected_overlay_lower_layer(t *testing.T) {
+	// not parallel, because the test replaces StateDir
+	defer func(path string) { StateDir = path }(StateDir)
+	StateDir = t.TempDir()
+
+	directory := t.TempDir()
+	job := NewJob(&JobConfig{Command: "/bin/sh", RootFS: directory})
+
+	rootfs, overlay, err := job.prepareRootFS()
+	if err != nil {
+		t.Fatalf("could not prepare rootfs: %v", err)
+	}
+	// the directory of the host is never written, the init shim creates mount points in the job's upper layer
+	if overlay == nil || !reflect.DeepEqual(overlay.LowerDirs, []string{directory}) || rootfs == directory {
+		t.Errorf("expected %s as overlay lower layer, got rootfs %s, overlay %+v", directory, rootfs, overlay)
+	}
+}
+
+func Test_unpackRootFS_symlink_escape_expected_ErrInvalidTarballPath(t *testing.T) {
+	t.Parallel()
+
+	outside := t.TempDir()
+	tarball := filepath.Join(t.TempDir(), "rootfs.tar.gz")
+	writeTarball(t, tarball, []tarEntry{
+		{header: tar.Header{Typeflag: tar.TypeSymlink, Name: "etc", Linkname: outside}},
+		{header: tar.Header{Typeflag: tar.TypeReg, Name: "etc/passwd", Mode: 0o644}, content: "root::0:0::/:/bin/sh\n"},
+	})
+
+	err := unpackRootFS(tarball, t.TempDir())
+	if !errors.Is(err, ErrInvalidTarballPath) {
+		t.Errorf("expected error(ErrInvalidTarballPath), got %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(outside, "passwd")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected nothing written outside of rootfs, got %v", err)
+	}
+}
+
+func Test_Job_prepareRootFS_overlay_expected_shared_lower_layer(t *testing.T) {
+	// not parallel, because the test replaces StateDir
+	defer func(path string) { StateDir = path }(StateDir)
+	StateDir = t.TempDir()
+
+	tarball := filepath.Join(t.TempDir(), "rootfs.tar")
+	writeTarball(t, tarball, []tarEntry{
+		{header: tar.Header{Typeflag: tar.TypeReg, Name: "bin/hello", Mode: 0o755}, content: "hello"},
+	})
+
+	var lowerDirs []string
+	for range 2 {
+		job := NewJob(&JobConfig{Command: "/bin/hello", RootFS: tarball, RootFSOverlay: true, KeepRootFSChanges: true})
+		rootfs, overlay, err := job.prepareRootFS()
+		if err != nil {
+			t.Fatalf("could not prepare rootfs: %v", err)
+		}
+		if overlay == nil || rootfs != filepath.Join(job.getStateDir(), "merged") {
+			t.Fatalf("expected overlay mounted in the job's state directory, got %s, %+v", rootfs, overlay)
+		}
+		lowerDirs = append(lowerDirs, overlay.LowerDirs...)
+
+		if err = job.removeState(true); err != nil {
+			t.Fatalf("could not remove job state: %v", err)
+		}
+		entries, err := os.ReadDir(job.getStateDir())
+		if err != nil || len(entries) != 1 || entries[0].Name() != "upper" {
+			t.Errorf("expected only the upper layer to be kept, got %v, error: %v", entries, err)
+		}
+	}
+
+	if len(lowerDirs) != 2 || lowerDirs[0] != lowerDirs[1] {
+		t.Errorf("expected jobs to share one lower layer, got %v", lowerDirs)
+	}
+	if content, err := os.ReadFile(filepath.Join(lowerDirs[0], "bin/hello")); err != nil || string(content) != "hello" {
+		t.Errorf("expected unpacked lower layer, got %q, error: %v", content, err)
+	}
+}
+
+func Test_packOverlayUpper_expected_OCI_whiteouts(t *testing.T) {
+	t.Parallel()
+
+	upper := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(upper, "etc"), 0o755); err != nil {
+		t.Fatalf("could not create upper: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(upper, "etc/hostname"), []byte("job"), 0o644); err != nil {
+		t.Fatalf("could not create upper: %v", err)
+	}
+	// overlayfs marks deleted files with a character device 0/0
+	if err := syscall.Mknod(filepath.Join(upper, "etc/motd"), syscall.S_IFCHR, 0); err != nil {
+		t.Skipf("could not create whiteout: %v", err)
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		_ = writer.CloseWithError(packOverlayUpper(upper, writer))
+	}()
+
+	entries := map[string]string{}
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("could not read layer: %v", err)
+		}
+		content, _ := io.ReadAll(tarReader)
+		entries[header.Name] = string(content)
+	}
+
+	expected := map[string]string{"etc/": "", "etc/hostname": "job", "etc/.wh.motd": ""}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("expected %v, got %v", expected, entries)
+	}
+}
@@ -0,0 +1,127 @@
+package jobWorker
+
+import (
+	"fmt"
+	ns "github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker/namespaces"
+	"golang.org/x/net/bpf"
+	"path/filepath"
+	"syscall"
+)
+
+const (
+	// SeccompProfileDefault blocks syscalls jobs must not use to change the host or escape their namespaces,
+	// such as mount, ptrace, kexec_load, bpf and clone with namespace flags, unless the job has the capability
+	// they need, all other syscalls are allowed
+	SeccompProfileDefault = "default"
+	// SeccompProfilePermissive doesn't filter syscalls
+	SeccompProfilePermissive = "permissive"
+	// seccompProfileExtension is the file extension of custom profiles in SeccompProfileDir
+	seccompProfileExtension = ".json"
+)
+
+// SeccompProfileDir is the directory of custom seccomp profiles in Docker's JSON format, see JobConfig.SeccompProfile
+var SeccompProfileDir = "/etc/jobworker/seccomp"
+
+// seccompCloneNamespaceFlags are the clone flags creating namespaces, clone3 passes them in a struct the filter
+// can't inspect
+const seccompCloneNamespaceFlags = syscall.CLONE_NEWNS | syscall.CLONE_NEWUTS | syscall.CLONE_NEWIPC |
+	syscall.CLONE_NEWUSER | syscall.CLONE_NEWPID | syscall.CLONE_NEWNET | syscall.CLONE_NEWCGROUP
+
+// seccompProfiles are the built-in profiles, syscalls blocked by the default profile are allowed, if the job has
+// the capability they need, same as Docker's default profile
+var seccompProfiles = map[string]*ns.SeccompProfile{
+	SeccompProfileDefault: {
+		DefaultAction: "SCMP_ACT_ALLOW",
+		Syscalls: []ns.SeccompSyscall{
+			newSeccompDenyRule("CAP_SYS_ADMIN",
+				// mounts, namespaces, the kernel keyring and file handles, which bypass the mount namespace
+				"mount", "umount", "umount2", "pivot_root", "move_mount", "open_tree", "fsopen", "fsconfig",
+				"fsmount", "fspick", "mount_setattr", "unshare", "setns", "quotactl", "quotactl_fd", "syslog",
+				"lookup_dcookie", "_sysctl", "sysfs", "nfsservctl", "add_key", "keyctl", "request_key",
+				"open_by_handle_at", "perf_event_open", "userfaultfd", "bpf"),
+			// the first matching rule wins, so clone without namespace flags is allowed before others are blocked,
+			// s390x passes the flags as second arg
+			{
+				Names:    []string{"clone"},
+				Action:   "SCMP_ACT_ALLOW",
+				Args:     []ns.SeccompArg{{Index: 0, Value: seccompCloneNamespaceFlags, Op: "SCMP_CMP_MASKED_EQ"}},
+				Excludes: ns.SeccompFilter{Arches: []string{"s390x"}, Caps: []string{"CAP_SYS_ADMIN"}},
+			},
+			{
+				Names:    []string{"clone"},
+				Action:   "SCMP_ACT_ALLOW",
+				Args:     []ns.SeccompArg{{Index: 1, Value: seccompCloneNamespaceFlags, Op: "SCMP_CMP_MASKED_EQ"}},
+				Includes: ns.SeccompFilter{Arches: []string{"s390x"}},
+				Excludes: ns.SeccompFilter{Caps: []string{"CAP_SYS_ADMIN"}},
+			},
+			newSeccompDenyRule("CAP_SYS_ADMIN", "clone"),
+			// ENOSYS makes libc fall back to clone, which the filter can check
+			{
+				Names:    []string{"clone3"},
+				Action:   "SCMP_ACT_ERRNO",
+				ErrnoRet: &enosys,
+				Excludes: ns.SeccompFilter{Caps: []string{"CAP_SYS_ADMIN"}},
+			},
+			newSeccompDenyRule("CAP_SYS_CHROOT", "chroot"),
+			newSeccompDenyRule("CAP_SYS_PTRACE", "ptrace", "process_vm_readv", "process_vm_writev", "kcmp"),
+			newSeccompDenyRule("CAP_SYS_BOOT", "kexec_load", "kexec_file_load", "reboot"),
+			newSeccompDenyRule("CAP_SYS_MODULE",
+				"init_module", "finit_module", "delete_module", "create_module", "get_kernel_syms", "query_module"),
+			newSeccompDenyRule("CAP_SYS_RAWIO", "iopl", "ioperm"),
+			newSeccompDenyRule("CAP_SYS_PACCT", "acct"),
+			newSeccompDenyRule("CAP_SYS_TIME", "settimeofday", "clock_settime", "clock_adjtime", "adjtimex", "stime"),
+			newSeccompDenyRule("CAP_SYS_TTY_CONFIG", "vhangup"),
+			// swap and uselib are never allowed
+			newSeccompDenyRule("", "swapon", "swapoff", "uselib"),
+		},
+	},
+	SeccompProfilePermissive: {
+		DefaultAction: "SCMP_ACT_ALLOW",
+	},
+}
+
+// enosys is the errno clone3 fails with in the default profile
+var enosys = uint32(syscall.ENOSYS)
+
+// newSeccompDenyRule returns a rule failing names with EPERM, unless the job has capability
+func newSeccompDenyRule(capability string, names ...string) ns.SeccompSyscall {
+	rule := ns.SeccompSyscall{Names: names, Action: "SCMP_ACT_ERRNO"}
+	if capability != "" {
+		rule.Excludes.Caps = []string{capability}
+	}
+	return rule
+}
+
+// getSeccompProfile returns the built-in profile name or reads the custom profile name.json of SeccompProfileDir
+func getSeccompProfile(name string) (*ns.SeccompProfile, error) {
+	if name == "" {
+		name = SeccompProfileDefault
+	}
+	if profile, ok := seccompProfiles[name]; ok {
+		return profile, nil
+	}
+
+	if !filepath.IsLocal(name) {
+		return nil, fmt.Errorf("%w: %s", ns.ErrInvalidSeccompProfile, name)
+	}
+	return ns.ReadSeccompProfile(filepath.Join(SeccompProfileDir, name+seccompProfileExtension))
+}
+
+// getSeccompFilter returns the BPF program of the job's seccomp profile the init shim loads before exec,
+// nil if the profile allows all syscalls
+func (job *Job) getSeccompFilter() ([]bpf.RawInstruction, error) {
+	profile, err := getSeccompProfile(job.config.SeccompProfile)
+	if err != nil {
+		return nil, err
+	}
+	if profile.IsAllowAll() {
+		return nil, nil
+	}
+
+	// rules of the profile depending on capabilities apply to the capabilities of the command
+	filter, err := profile.Assemble(job.getCapabilities())
+	if err != nil {
+		return nil, fmt.Errorf("error assembling seccomp profile %s: %w", job.config.SeccompProfile, err)
+	}
+	return filter, nil
+}
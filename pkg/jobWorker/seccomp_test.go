@@ -0,0 +1,101 @@
+package jobWorker
+
+import (
+	"errors"
+	"fmt"
+	ns "github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker/namespaces"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func Test_Job_getSeccompFilter(t *testing.T) {
+	// not parallel, because the test replaces SeccompProfileDir
+	defer func(path string) { SeccompProfileDir = path }(SeccompProfileDir)
+	SeccompProfileDir = t.TempDir()
+
+	profile := `{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"names": ["read", "write"], "action": "SCMP_ACT_ALLOW"}]}`
+	if err := os.WriteFile(filepath.Join(SeccompProfileDir, "custom.json"), []byte(profile), 0o644); err != nil {
+		t.Fatalf("could not write profile: %v", err)
+	}
+
+	testCases := []struct {
+		profile        string
+		expectedFilter bool
+		expectedErr    error
+	}{
+		{profile: "", expectedFilter: true},
+		{profile: SeccompProfileDefault, expectedFilter: true},
+		{profile: SeccompProfilePermissive},
+		{profile: "custom", expectedFilter: true},
+		{profile: "missing", expectedErr: os.ErrNotExist},
+		{profile: "../custom", expectedErr: ns.ErrInvalidSeccompProfile},
+	}
+
+	for _, testCase := range testCases {
+		filter, err := NewJob(&JobConfig{SeccompProfile: testCase.profile}).getSeccompFilter()
+		if !errors.Is(err, testCase.expectedErr) {
+			t.Errorf("profile:%s, expected error %v, got %v", testCase.profile, testCase.expectedErr, err)
+			continue
+		}
+		if (len(filter) > 0) != testCase.expectedFilter {
+			t.Errorf("profile:%s, expected filter %t, got %d instructions", testCase.profile, testCase.expectedFilter, len(filter))
+		}
+	}
+}
+
+// cloneHelperEnv makes Test_clone_helper clone processes with and without CLONE_NEWUSER, when the test binary is run
+// as command of a job
+const cloneHelperEnv = "JOBWORKER_TEST_CLONE_HELPER"
+
+func Test_clone_helper(t *testing.T) {
+	if os.Getenv(cloneHelperEnv) == "" {
+		t.Skip("only run as command of Test_Job_Seccomp_Default_expected_clone_of_namespaces_blocked")
+	}
+
+	// os/exec clones with SysProcAttr.Cloneflags
+	for _, flags := range []uintptr{0, syscall.CLONE_NEWUSER} {
+		cmd := exec.Command("/bin/true")
+		cmd.SysProcAttr = &syscall.SysProcAttr{Cloneflags: flags}
+		fmt.Printf("clone %#x: %t\n", flags, errors.Is(cmd.Run(), syscall.EPERM))
+	}
+}
+
+func Test_Job_Seccomp_Default_expected_clone_of_namespaces_blocked(t *testing.T) {
+	//t.Parallel()
+
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("could not get test binary: %v", err)
+	}
+
+	config := JobConfig{
+		Command:          executable,
+		Arguments:        []string{"-test.run=^Test_clone_helper$"},
+		Env:              map[string]string{cloneHelperEnv: "1"},
+		CPU:              0.5,           // half a CPU core
+		IOBytesPerSecond: 100_000_000,   // 100 MB/s
+		MemBytes:         1_000_000_000, // 1 GB
+	}
+
+	testJob := NewJob(&config)
+
+	err = testJob.Start()
+	if err != nil {
+		t.Fatalf("error starting job: %v", err)
+	}
+
+	output, err := io.ReadAll(testJob.Stream())
+	if err != nil {
+		t.Fatalf("error reading output: %v", err)
+	}
+
+	// a plain clone must still work, clone with CLONE_NEWUSER must fail with EPERM
+	expectedOutput := fmt.Sprintf("clone 0x0: false\nclone %#x: true\nPASS\n", syscall.CLONE_NEWUSER)
+	if string(output) != expectedOutput {
+		t.Errorf("expected output to be %q, got %q", expectedOutput, output)
+	}
+}
@@ -0,0 +1,70 @@
+package jobWorker
+
+import (
+	"errors"
+	"fmt"
+	ns "github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker/namespaces"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+var (
+	ErrIDNotMapped = errors.New("UID, GID and Groups other than 0 must be in the subordinate ID ranges of the current user")
+)
+
+var (
+	// SubUIDFile and SubGIDFile are the subordinate ID ranges of users, the range of the current user is mapped
+	// from ID 1 in the job's user namespace, so jobs can run as UID, GID and Groups other than 0
+	SubUIDFile = "/etc/subuid"
+	SubGIDFile = "/etc/subgid"
+)
+
+// hasCredentials returns true, if the job doesn't run as root of its user namespace with the init shim's groups
+func (jobConfig *JobConfig) hasCredentials() bool {
+	return jobConfig.UID != 0 || jobConfig.GID != 0 || len(jobConfig.Groups) > 0
+}
+
+// getIDMappings returns the UID and GID mappings of the job's user namespace, root is mapped to the current user
+// and the IDs from 1 to the subordinate ID ranges of the current user, if any.
+//
+// ErrIDNotMapped is returned, if UID, GID or Groups aren't mapped.
+func (job *Job) getIDMappings() ([]syscall.SysProcIDMap, []syscall.SysProcIDMap, error) {
+	return getIDMappings(job.config.UID, append([]uint32{job.config.GID}, job.config.Groups...)...)
+}
+
+// CheckIDMapped returns ErrIDNotMapped, if jobs can't run as uid and gid, because the subordinate ID ranges of
+// the current user in SubUIDFile and SubGIDFile don't include them
+func CheckIDMapped(uid uint32, gid uint32) error {
+	_, _, err := getIDMappings(uid, gid)
+	return err
+}
+
+// getIDMappings returns the UID and GID mappings of a user namespace, ErrIDNotMapped if uid or gids aren't mapped
+func getIDMappings(uid uint32, gids ...uint32) ([]syscall.SysProcIDMap, []syscall.SysProcIDMap, error) {
+	currentUID := os.Getuid()
+	userName := strconv.Itoa(currentUID)
+	if currentUser, err := user.LookupId(userName); err == nil {
+		userName = currentUser.Username
+	}
+
+	uidMappings, err := ns.GetIDMappings(SubUIDFile, userName, currentUID, currentUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	gidMappings, err := ns.GetIDMappings(SubGIDFile, userName, currentUID, os.Getgid())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !ns.IsIDMapped(uidMappings, uid) {
+		return nil, nil, fmt.Errorf("%w: UID %d", ErrIDNotMapped, uid)
+	}
+	for _, id := range gids {
+		if !ns.IsIDMapped(gidMappings, id) {
+			return nil, nil, fmt.Errorf("%w: GID %d", ErrIDNotMapped, id)
+		}
+	}
+	return uidMappings, gidMappings, nil
+}
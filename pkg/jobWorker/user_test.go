@@ -0,0 +1,43 @@
+package jobWorker
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func Test_Job_getIDMappings_expected_ErrIDNotMapped(t *testing.T) {
+	// not parallel, because the test replaces SubUIDFile and SubGIDFile
+	defer func(subUIDFile, subGIDFile string) { SubUIDFile, SubGIDFile = subUIDFile, subGIDFile }(SubUIDFile, SubGIDFile)
+
+	SubUIDFile = filepath.Join(t.TempDir(), "subuid")
+	SubGIDFile = filepath.Join(t.TempDir(), "subgid")
+	// only UIDs are mapped from 1 to 1000
+	if err := os.WriteFile(SubUIDFile, []byte(strconv.Itoa(os.Getuid())+":100000:1000\n"), 0o644); err != nil {
+		t.Fatalf("could not write subuid: %v", err)
+	}
+
+	testCases := []struct {
+		config      JobConfig
+		expectedErr error
+	}{
+		{config: JobConfig{}},
+		{config: JobConfig{UID: 1000}},
+		{config: JobConfig{UID: 1001}, expectedErr: ErrIDNotMapped},
+		{config: JobConfig{UID: 1000, GID: 1000}, expectedErr: ErrIDNotMapped},
+		{config: JobConfig{Groups: []uint32{10}}, expectedErr: ErrIDNotMapped},
+	}
+
+	for _, testCase := range testCases {
+		uidMappings, gidMappings, err := NewJob(&testCase.config).getIDMappings()
+		if !errors.Is(err, testCase.expectedErr) {
+			t.Errorf("config:%+v, expected error %v, got %v", testCase.config, testCase.expectedErr, err)
+			continue
+		}
+		if err == nil && (uidMappings[0].HostID != os.Getuid() || gidMappings[0].HostID != os.Getgid()) {
+			t.Errorf("config:%+v, expected root mapped to the current user, got %v %v", testCase.config, uidMappings, gidMappings)
+		}
+	}
+}
@@ -29,6 +29,7 @@ const (
 	Status_STOPPED     Status = 3
 	Status_TERMINATED  Status = 4
 	Status_COMPLETED   Status = 5
+	Status_STOPPING    Status = 6
 )
 
 // Enum value maps for Status.
@@ -40,6 +41,7 @@ var (
 		3: "STOPPED",
 		4: "TERMINATED",
 		5: "COMPLETED",
+		6: "STOPPING",
 	}
 	Status_value = map[string]int32{
 		"UNSPECIFIED": 0,
@@ -48,6 +50,7 @@ var (
 		"STOPPED":     3,
 		"TERMINATED":  4,
 		"COMPLETED":   5,
+		"STOPPING":    6,
 	}
 )
 
@@ -84,11 +87,87 @@ type JobCreateRequest struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	CPU              float64  `protobuf:"fixed64,1,opt,name=CPU,proto3" json:"CPU,omitempty"`
-	MemBytes         int64    `protobuf:"varint,2,opt,name=MemBytes,proto3" json:"MemBytes,omitempty"`
-	IoBytesPerSecond int64    `protobuf:"varint,3,opt,name=IoBytesPerSecond,proto3" json:"IoBytesPerSecond,omitempty"`
-	Command          string   `protobuf:"bytes,4,opt,name=Command,proto3" json:"Command,omitempty"`
-	Args             []string `protobuf:"bytes,5,rep,name=Args,proto3" json:"Args,omitempty"`
+	CPU               float64  `protobuf:"fixed64,1,opt,name=CPU,proto3" json:"CPU,omitempty"`
+	MemBytes          int64    `protobuf:"varint,2,opt,name=MemBytes,proto3" json:"MemBytes,omitempty"`
+	IoBytesPerSecond  int64    `protobuf:"varint,3,opt,name=IoBytesPerSecond,proto3" json:"IoBytesPerSecond,omitempty"`
+	Command           string   `protobuf:"bytes,4,opt,name=Command,proto3" json:"Command,omitempty"`
+	Args              []string `protobuf:"bytes,5,rep,name=Args,proto3" json:"Args,omitempty"`
+	StopGracePeriodMs int64    `protobuf:"varint,6,opt,name=StopGracePeriodMs,proto3" json:"StopGracePeriodMs,omitempty"`
+	// Read/Write limits override IoBytesPerSecond, if greater than 0
+	ReadBytesPerSecond  int64 `protobuf:"varint,7,opt,name=ReadBytesPerSecond,proto3" json:"ReadBytesPerSecond,omitempty"`
+	WriteBytesPerSecond int64 `protobuf:"varint,8,opt,name=WriteBytesPerSecond,proto3" json:"WriteBytesPerSecond,omitempty"`
+	ReadIoPerSecond     int64 `protobuf:"varint,9,opt,name=ReadIoPerSecond,proto3" json:"ReadIoPerSecond,omitempty"`
+	WriteIoPerSecond    int64 `protobuf:"varint,10,opt,name=WriteIoPerSecond,proto3" json:"WriteIoPerSecond,omitempty"`
+	// IoDevicePath is a path on the block device IO limits are applied to, the job's root filesystem by default
+	IoDevicePath string `protobuf:"bytes,11,opt,name=IoDevicePath,proto3" json:"IoDevicePath,omitempty"`
+	// MemMaxBytes is the hard memory limit, the job is OOM killed above it
+	MemMaxBytes int64 `protobuf:"varint,12,opt,name=MemMaxBytes,proto3" json:"MemMaxBytes,omitempty"`
+	// SwapMaxBytes limits swap usage, -1 disables swap and 0 keeps the system default
+	SwapMaxBytes int64 `protobuf:"varint,13,opt,name=SwapMaxBytes,proto3" json:"SwapMaxBytes,omitempty"`
+	// CpuWeight is the relative CPU share between 1 and 10000, CPU is enforced as an absolute quota
+	CpuWeight uint64 `protobuf:"varint,14,opt,name=CpuWeight,proto3" json:"CpuWeight,omitempty"`
+	// CpusetCpus and CpusetMems pin the job to CPU cores and memory nodes, such as "0-3,6"
+	CpusetCpus string `protobuf:"bytes,15,opt,name=CpusetCpus,proto3" json:"CpusetCpus,omitempty"`
+	CpusetMems string `protobuf:"bytes,16,opt,name=CpusetMems,proto3" json:"CpusetMems,omitempty"`
+	// MaxProcesses limits the number of processes and threads the job can run at once
+	MaxProcesses int64 `protobuf:"varint,17,opt,name=MaxProcesses,proto3" json:"MaxProcesses,omitempty"`
+	// PressureTriggers write a line into the job output when the job is stalled on a resource
+	PressureTriggers []*PressureTrigger `protobuf:"bytes,18,rep,name=PressureTriggers,proto3" json:"PressureTriggers,omitempty"`
+	// RootFS is a directory or tarball on the server the job runs in as its root filesystem, the host's by default
+	RootFS string `protobuf:"bytes,19,opt,name=RootFS,proto3" json:"RootFS,omitempty"`
+	// RootFSOverlay runs the job on a writable overlay of RootFS, KeepRootFSChanges keeps the overlay's changes
+	// for ExportRootFSChanges once the job has completed
+	RootFSOverlay     bool `protobuf:"varint,20,opt,name=RootFSOverlay,proto3" json:"RootFSOverlay,omitempty"`
+	KeepRootFSChanges bool `protobuf:"varint,21,opt,name=KeepRootFSChanges,proto3" json:"KeepRootFSChanges,omitempty"`
+	// Volumes are mounted in the job's mount namespace in the given order, bind sources must be allowed by the server
+	Volumes []*Volume `protobuf:"bytes,22,rep,name=Volumes,proto3" json:"Volumes,omitempty"`
+	// Image is an OCI image layout or docker save tarball in the server's image directory, such as "alpine:3.20",
+	// the job runs the image's entrypoint, environment and working directory, if Command is empty
+	Image string `protobuf:"bytes,23,opt,name=Image,proto3" json:"Image,omitempty"`
+	// Env are environment variables of the command, they override the image's environment
+	Env map[string]string `protobuf:"bytes,24,rep,name=Env,proto3" json:"Env,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// ClearEnv starts the command with an empty environment with a default PATH (true if not set),
+	// the server's environment is only inherited if allowed by the server
+	ClearEnv *bool `protobuf:"varint,25,opt,name=ClearEnv,proto3,oneof" json:"ClearEnv,omitempty"`
+	// WorkingDir is the absolute working directory of the command, the image's one by default
+	WorkingDir string `protobuf:"bytes,26,opt,name=WorkingDir,proto3" json:"WorkingDir,omitempty"`
+	// Uid and Gid are the IDs the command runs as in the job's user namespace, the first ones the server allows the
+	// user by default, Groups are supplementary group IDs, all of them must be allowed by the server
+	Uid    *uint32  `protobuf:"varint,27,opt,name=Uid,proto3,oneof" json:"Uid,omitempty"`
+	Gid    *uint32  `protobuf:"varint,28,opt,name=Gid,proto3,oneof" json:"Gid,omitempty"`
+	Groups []uint32 `protobuf:"varint,29,rep,packed,name=Groups,proto3" json:"Groups,omitempty"`
+	// SeccompProfile filters syscalls of the job: "default", "permissive" or the name of a Docker JSON profile
+	// in the server's seccomp profile directory, "default" if empty
+	SeccompProfile string `protobuf:"bytes,30,opt,name=SeccompProfile,proto3" json:"SeccompProfile,omitempty"`
+	// AddCapabilities are the capabilities of the job's user namespace the command runs with, such as
+	// CAP_NET_BIND_SERVICE or ALL, DropCapabilities removes some of them, all must be allowed by the server
+	AddCapabilities  []string `protobuf:"bytes,31,rep,name=AddCapabilities,proto3" json:"AddCapabilities,omitempty"`
+	DropCapabilities []string `protobuf:"bytes,32,rep,name=DropCapabilities,proto3" json:"DropCapabilities,omitempty"`
+	// NoNewPrivs prevents setuid binaries from raising privileges (true if not set)
+	NoNewPrivs *bool `protobuf:"varint,33,opt,name=NoNewPrivs,proto3,oneof" json:"NoNewPrivs,omitempty"`
+	// Rlimits are POSIX resource limits of the command by name, such as "RLIMIT_NOFILE" or "CPU" in seconds,
+	// the server's defaults apply to limits not set and all limits must be below the server's maximums
+	Rlimits map[string]*Rlimit `protobuf:"bytes,34,rep,name=Rlimits,proto3" json:"Rlimits,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// NetworkMode is "none" without any interface up, "loopback" with only the loopback interface up or "bridge"
+	// connected to the server's bridge with an address of its subnet, "none" if empty
+	NetworkMode string `protobuf:"bytes,35,opt,name=NetworkMode,proto3" json:"NetworkMode,omitempty"`
+	// PortMappings forward ports of the server to ports of the job, NetworkMode must be "bridge"
+	PortMappings []*PortMapping `protobuf:"bytes,36,rep,name=PortMappings,proto3" json:"PortMappings,omitempty"`
+	// NetworkIngressBytesPerSecond and NetworkEgressBytesPerSecond limit the traffic the job receives and sends,
+	// NetworkMode must be "bridge" (optional)
+	NetworkIngressBytesPerSecond int64 `protobuf:"varint,37,opt,name=NetworkIngressBytesPerSecond,proto3" json:"NetworkIngressBytesPerSecond,omitempty"`
+	NetworkEgressBytesPerSecond  int64 `protobuf:"varint,38,opt,name=NetworkEgressBytesPerSecond,proto3" json:"NetworkEgressBytesPerSecond,omitempty"`
+	// Hostname is the hostname of the job, a short form of the job's id if empty
+	Hostname string `protobuf:"bytes,39,opt,name=Hostname,proto3" json:"Hostname,omitempty"`
+	// DomainName is the NIS domain name of the job, the hostname resolves to Hostname.DomainName in /etc/hosts
+	// of jobs with their own root filesystem (optional)
+	DomainName string `protobuf:"bytes,40,opt,name=DomainName,proto3" json:"DomainName,omitempty"`
+	// ShmSizeBytes is the size of the job's /dev/shm, which counts against the job's memory,
+	// 64 MB or the memory limit if lower by default
+	ShmSizeBytes int64 `protobuf:"varint,41,opt,name=ShmSizeBytes,proto3" json:"ShmSizeBytes,omitempty"`
+	// DelegateCGroup makes the job's user the owner of the job's cgroup, so that the job can create child cgroups
+	// below /sys/fs/cgroup, requires cgroup2 mounted with nsdelegate on the server
+	DelegateCGroup bool `protobuf:"varint,42,opt,name=DelegateCGroup,proto3" json:"DelegateCGroup,omitempty"`
 }
 
 func (x *JobCreateRequest) Reset() {
@@ -158,31 +237,1175 @@ func (x *JobCreateRequest) GetArgs() []string {
 	return nil
 }
 
+func (x *JobCreateRequest) GetStopGracePeriodMs() int64 {
+	if x != nil {
+		return x.StopGracePeriodMs
+	}
+	return 0
+}
+
+func (x *JobCreateRequest) GetReadBytesPerSecond() int64 {
+	if x != nil {
+		return x.ReadBytesPerSecond
+	}
+	return 0
+}
+
+func (x *JobCreateRequest) GetWriteBytesPerSecond() int64 {
+	if x != nil {
+		return x.WriteBytesPerSecond
+	}
+	return 0
+}
+
+func (x *JobCreateRequest) GetReadIoPerSecond() int64 {
+	if x != nil {
+		return x.ReadIoPerSecond
+	}
+	return 0
+}
+
+func (x *JobCreateRequest) GetWriteIoPerSecond() int64 {
+	if x != nil {
+		return x.WriteIoPerSecond
+	}
+	return 0
+}
+
+func (x *JobCreateRequest) GetIoDevicePath() string {
+	if x != nil {
+		return x.IoDevicePath
+	}
+	return ""
+}
+
+func (x *JobCreateRequest) GetMemMaxBytes() int64 {
+	if x != nil {
+		return x.MemMaxBytes
+	}
+	return 0
+}
+
+func (x *JobCreateRequest) GetSwapMaxBytes() int64 {
+	if x != nil {
+		return x.SwapMaxBytes
+	}
+	return 0
+}
+
+func (x *JobCreateRequest) GetCpuWeight() uint64 {
+	if x != nil {
+		return x.CpuWeight
+	}
+	return 0
+}
+
+func (x *JobCreateRequest) GetCpusetCpus() string {
+	if x != nil {
+		return x.CpusetCpus
+	}
+	return ""
+}
+
+func (x *JobCreateRequest) GetCpusetMems() string {
+	if x != nil {
+		return x.CpusetMems
+	}
+	return ""
+}
+
+func (x *JobCreateRequest) GetMaxProcesses() int64 {
+	if x != nil {
+		return x.MaxProcesses
+	}
+	return 0
+}
+
+func (x *JobCreateRequest) GetPressureTriggers() []*PressureTrigger {
+	if x != nil {
+		return x.PressureTriggers
+	}
+	return nil
+}
+
+func (x *JobCreateRequest) GetRootFS() string {
+	if x != nil {
+		return x.RootFS
+	}
+	return ""
+}
+
+func (x *JobCreateRequest) GetRootFSOverlay() bool {
+	if x != nil {
+		return x.RootFSOverlay
+	}
+	return false
+}
+
+func (x *JobCreateRequest) GetKeepRootFSChanges() bool {
+	if x != nil {
+		return x.KeepRootFSChanges
+	}
+	return false
+}
+
+func (x *JobCreateRequest) GetVolumes() []*Volume {
+	if x != nil {
+		return x.Volumes
+	}
+	return nil
+}
+
+func (x *JobCreateRequest) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+func (x *JobCreateRequest) GetEnv() map[string]string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *JobCreateRequest) GetClearEnv() bool {
+	if x != nil && x.ClearEnv != nil {
+		return *x.ClearEnv
+	}
+	return false
+}
+
+func (x *JobCreateRequest) GetWorkingDir() string {
+	if x != nil {
+		return x.WorkingDir
+	}
+	return ""
+}
+
+func (x *JobCreateRequest) GetUid() uint32 {
+	if x != nil && x.Uid != nil {
+		return *x.Uid
+	}
+	return 0
+}
+
+func (x *JobCreateRequest) GetGid() uint32 {
+	if x != nil && x.Gid != nil {
+		return *x.Gid
+	}
+	return 0
+}
+
+func (x *JobCreateRequest) GetGroups() []uint32 {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+func (x *JobCreateRequest) GetSeccompProfile() string {
+	if x != nil {
+		return x.SeccompProfile
+	}
+	return ""
+}
+
+func (x *JobCreateRequest) GetAddCapabilities() []string {
+	if x != nil {
+		return x.AddCapabilities
+	}
+	return nil
+}
+
+func (x *JobCreateRequest) GetDropCapabilities() []string {
+	if x != nil {
+		return x.DropCapabilities
+	}
+	return nil
+}
+
+func (x *JobCreateRequest) GetNoNewPrivs() bool {
+	if x != nil && x.NoNewPrivs != nil {
+		return *x.NoNewPrivs
+	}
+	return false
+}
+
+func (x *JobCreateRequest) GetRlimits() map[string]*Rlimit {
+	if x != nil {
+		return x.Rlimits
+	}
+	return nil
+}
+
+func (x *JobCreateRequest) GetNetworkMode() string {
+	if x != nil {
+		return x.NetworkMode
+	}
+	return ""
+}
+
+func (x *JobCreateRequest) GetPortMappings() []*PortMapping {
+	if x != nil {
+		return x.PortMappings
+	}
+	return nil
+}
+
+func (x *JobCreateRequest) GetNetworkIngressBytesPerSecond() int64 {
+	if x != nil {
+		return x.NetworkIngressBytesPerSecond
+	}
+	return 0
+}
+
+func (x *JobCreateRequest) GetNetworkEgressBytesPerSecond() int64 {
+	if x != nil {
+		return x.NetworkEgressBytesPerSecond
+	}
+	return 0
+}
+
+func (x *JobCreateRequest) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *JobCreateRequest) GetDomainName() string {
+	if x != nil {
+		return x.DomainName
+	}
+	return ""
+}
+
+func (x *JobCreateRequest) GetShmSizeBytes() int64 {
+	if x != nil {
+		return x.ShmSizeBytes
+	}
+	return 0
+}
+
+func (x *JobCreateRequest) GetDelegateCGroup() bool {
+	if x != nil {
+		return x.DelegateCGroup
+	}
+	return false
+}
+
+type PortMapping struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Protocol is "tcp" or "udp", "tcp" if empty
+	Protocol      string `protobuf:"bytes,1,opt,name=Protocol,proto3" json:"Protocol,omitempty"`
+	HostPort      uint32 `protobuf:"varint,2,opt,name=HostPort,proto3" json:"HostPort,omitempty"`
+	ContainerPort uint32 `protobuf:"varint,3,opt,name=ContainerPort,proto3" json:"ContainerPort,omitempty"`
+}
+
+func (x *PortMapping) Reset() {
+	*x = PortMapping{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_proto_jobWorker_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PortMapping) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PortMapping) ProtoMessage() {}
+
+func (x *PortMapping) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_jobWorker_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PortMapping.ProtoReflect.Descriptor instead.
+func (*PortMapping) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PortMapping) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *PortMapping) GetHostPort() uint32 {
+	if x != nil {
+		return x.HostPort
+	}
+	return 0
+}
+
+func (x *PortMapping) GetContainerPort() uint32 {
+	if x != nil {
+		return x.ContainerPort
+	}
+	return 0
+}
+
+type Rlimit struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Soft and Hard are the values of the limit, 18446744073709551615 (RLIM_INFINITY) is unlimited
+	Soft uint64 `protobuf:"varint,1,opt,name=Soft,proto3" json:"Soft,omitempty"`
+	Hard uint64 `protobuf:"varint,2,opt,name=Hard,proto3" json:"Hard,omitempty"`
+}
+
+func (x *Rlimit) Reset() {
+	*x = Rlimit{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_proto_jobWorker_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Rlimit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rlimit) ProtoMessage() {}
+
+func (x *Rlimit) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_jobWorker_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rlimit.ProtoReflect.Descriptor instead.
+func (*Rlimit) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Rlimit) GetSoft() uint64 {
+	if x != nil {
+		return x.Soft
+	}
+	return 0
+}
+
+func (x *Rlimit) GetHard() uint64 {
+	if x != nil {
+		return x.Hard
+	}
+	return 0
+}
+
+type Volume struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Type is "bind", "tmpfs" or "ro" to remount Target read-only
+	Type     string `protobuf:"bytes,1,opt,name=Type,proto3" json:"Type,omitempty"`
+	Source   string `protobuf:"bytes,2,opt,name=Source,proto3" json:"Source,omitempty"`
+	Target   string `protobuf:"bytes,3,opt,name=Target,proto3" json:"Target,omitempty"`
+	ReadOnly bool   `protobuf:"varint,4,opt,name=ReadOnly,proto3" json:"ReadOnly,omitempty"`
+	// SizeBytes limits the size of tmpfs
+	SizeBytes int64 `protobuf:"varint,5,opt,name=SizeBytes,proto3" json:"SizeBytes,omitempty"`
+}
+
+func (x *Volume) Reset() {
+	*x = Volume{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_proto_jobWorker_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Volume) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Volume) ProtoMessage() {}
+
+func (x *Volume) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_jobWorker_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Volume.ProtoReflect.Descriptor instead.
+func (*Volume) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Volume) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Volume) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Volume) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *Volume) GetReadOnly() bool {
+	if x != nil {
+		return x.ReadOnly
+	}
+	return false
+}
+
+func (x *Volume) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+type PressureTrigger struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Resource is "cpu", "memory" or "io"
+	Resource string `protobuf:"bytes,1,opt,name=Resource,proto3" json:"Resource,omitempty"`
+	// Full selects the time all tasks were stalled instead of the time at least one task was stalled
+	Full        bool  `protobuf:"varint,2,opt,name=Full,proto3" json:"Full,omitempty"`
+	ThresholdMs int64 `protobuf:"varint,3,opt,name=ThresholdMs,proto3" json:"ThresholdMs,omitempty"`
+	// WindowMs must be between 500ms and 10s
+	WindowMs int64 `protobuf:"varint,4,opt,name=WindowMs,proto3" json:"WindowMs,omitempty"`
+}
+
+func (x *PressureTrigger) Reset() {
+	*x = PressureTrigger{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_proto_jobWorker_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PressureTrigger) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PressureTrigger) ProtoMessage() {}
+
+func (x *PressureTrigger) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_jobWorker_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PressureTrigger.ProtoReflect.Descriptor instead.
+func (*PressureTrigger) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PressureTrigger) GetResource() string {
+	if x != nil {
+		return x.Resource
+	}
+	return ""
+}
+
+func (x *PressureTrigger) GetFull() bool {
+	if x != nil {
+		return x.Full
+	}
+	return false
+}
+
+func (x *PressureTrigger) GetThresholdMs() int64 {
+	if x != nil {
+		return x.ThresholdMs
+	}
+	return 0
+}
+
+func (x *PressureTrigger) GetWindowMs() int64 {
+	if x != nil {
+		return x.WindowMs
+	}
+	return 0
+}
+
 type JobRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Id string `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Id string `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`
+}
+
+func (x *JobRequest) Reset() {
+	*x = JobRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_proto_jobWorker_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *JobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobRequest) ProtoMessage() {}
+
+func (x *JobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_jobWorker_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobRequest.ProtoReflect.Descriptor instead.
+func (*JobRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *JobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type StopRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	// GracePeriodMs overrides the job's StopGracePeriodMs between SIGTERM and SIGKILL, if greater than 0,
+	// negative values are rejected with INVALID_ARGUMENT
+	GracePeriodMs int64 `protobuf:"varint,2,opt,name=GracePeriodMs,proto3" json:"GracePeriodMs,omitempty"`
+}
+
+func (x *StopRequest) Reset() {
+	*x = StopRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_proto_jobWorker_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopRequest) ProtoMessage() {}
+
+func (x *StopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_jobWorker_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopRequest.ProtoReflect.Descriptor instead.
+func (*StopRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StopRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *StopRequest) GetGracePeriodMs() int64 {
+	if x != nil {
+		return x.GracePeriodMs
+	}
+	return 0
+}
+
+// responses
+type JobResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`
+}
+
+func (x *JobResponse) Reset() {
+	*x = JobResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_proto_jobWorker_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *JobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobResponse) ProtoMessage() {}
+
+func (x *JobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_jobWorker_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobResponse.ProtoReflect.Descriptor instead.
+func (*JobResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *JobResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type JobStatusResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status           Status `protobuf:"varint,1,opt,name=status,proto3,enum=proto.Status" json:"status,omitempty"`
+	ExitCode         int32  `protobuf:"varint,2,opt,name=exitCode,proto3" json:"exitCode,omitempty"`
+	ExitReason       string `protobuf:"bytes,3,opt,name=exitReason,proto3" json:"exitReason,omitempty"`
+	OomKilled        bool   `protobuf:"varint,4,opt,name=oomKilled,proto3" json:"oomKilled,omitempty"`
+	PidsCurrent      int64  `protobuf:"varint,5,opt,name=pidsCurrent,proto3" json:"pidsCurrent,omitempty"`
+	PidsPeak         int64  `protobuf:"varint,6,opt,name=pidsPeak,proto3" json:"pidsPeak,omitempty"`
+	PidsLimitReached bool   `protobuf:"varint,7,opt,name=pidsLimitReached,proto3" json:"pidsLimitReached,omitempty"`
+	CpuUsageUsec     int64  `protobuf:"varint,8,opt,name=cpuUsageUsec,proto3" json:"cpuUsageUsec,omitempty"`
+	MemoryPeakBytes  int64  `protobuf:"varint,9,opt,name=memoryPeakBytes,proto3" json:"memoryPeakBytes,omitempty"`
+	// cpuLimitExceeded is true if the command was killed by the kernel after exceeding its RLIMIT_CPU
+	CpuLimitExceeded bool `protobuf:"varint,10,opt,name=cpuLimitExceeded,proto3" json:"cpuLimitExceeded,omitempty"`
+	// ipAddress is the address of the job on the server's bridge, empty unless the job's NetworkMode is "bridge"
+	IpAddress string `protobuf:"bytes,11,opt,name=ipAddress,proto3" json:"ipAddress,omitempty"`
+}
+
+func (x *JobStatusResponse) Reset() {
+	*x = JobStatusResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_proto_jobWorker_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *JobStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobStatusResponse) ProtoMessage() {}
+
+func (x *JobStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_jobWorker_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobStatusResponse.ProtoReflect.Descriptor instead.
+func (*JobStatusResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *JobStatusResponse) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_UNSPECIFIED
+}
+
+func (x *JobStatusResponse) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *JobStatusResponse) GetExitReason() string {
+	if x != nil {
+		return x.ExitReason
+	}
+	return ""
+}
+
+func (x *JobStatusResponse) GetOomKilled() bool {
+	if x != nil {
+		return x.OomKilled
+	}
+	return false
+}
+
+func (x *JobStatusResponse) GetPidsCurrent() int64 {
+	if x != nil {
+		return x.PidsCurrent
+	}
+	return 0
+}
+
+func (x *JobStatusResponse) GetPidsPeak() int64 {
+	if x != nil {
+		return x.PidsPeak
+	}
+	return 0
+}
+
+func (x *JobStatusResponse) GetPidsLimitReached() bool {
+	if x != nil {
+		return x.PidsLimitReached
+	}
+	return false
+}
+
+func (x *JobStatusResponse) GetCpuUsageUsec() int64 {
+	if x != nil {
+		return x.CpuUsageUsec
+	}
+	return 0
+}
+
+func (x *JobStatusResponse) GetMemoryPeakBytes() int64 {
+	if x != nil {
+		return x.MemoryPeakBytes
+	}
+	return 0
+}
+
+func (x *JobStatusResponse) GetCpuLimitExceeded() bool {
+	if x != nil {
+		return x.CpuLimitExceeded
+	}
+	return false
+}
+
+func (x *JobStatusResponse) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+type CpuUsage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	UsageUsec     int64 `protobuf:"varint,1,opt,name=usageUsec,proto3" json:"usageUsec,omitempty"`
+	UserUsec      int64 `protobuf:"varint,2,opt,name=userUsec,proto3" json:"userUsec,omitempty"`
+	SystemUsec    int64 `protobuf:"varint,3,opt,name=systemUsec,proto3" json:"systemUsec,omitempty"`
+	NrPeriods     int64 `protobuf:"varint,4,opt,name=nrPeriods,proto3" json:"nrPeriods,omitempty"`
+	NrThrottled   int64 `protobuf:"varint,5,opt,name=nrThrottled,proto3" json:"nrThrottled,omitempty"`
+	ThrottledUsec int64 `protobuf:"varint,6,opt,name=throttledUsec,proto3" json:"throttledUsec,omitempty"`
+}
+
+func (x *CpuUsage) Reset() {
+	*x = CpuUsage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_proto_jobWorker_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CpuUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CpuUsage) ProtoMessage() {}
+
+func (x *CpuUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_jobWorker_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CpuUsage.ProtoReflect.Descriptor instead.
+func (*CpuUsage) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CpuUsage) GetUsageUsec() int64 {
+	if x != nil {
+		return x.UsageUsec
+	}
+	return 0
+}
+
+func (x *CpuUsage) GetUserUsec() int64 {
+	if x != nil {
+		return x.UserUsec
+	}
+	return 0
+}
+
+func (x *CpuUsage) GetSystemUsec() int64 {
+	if x != nil {
+		return x.SystemUsec
+	}
+	return 0
+}
+
+func (x *CpuUsage) GetNrPeriods() int64 {
+	if x != nil {
+		return x.NrPeriods
+	}
+	return 0
+}
+
+func (x *CpuUsage) GetNrThrottled() int64 {
+	if x != nil {
+		return x.NrThrottled
+	}
+	return 0
+}
+
+func (x *CpuUsage) GetThrottledUsec() int64 {
+	if x != nil {
+		return x.ThrottledUsec
+	}
+	return 0
+}
+
+type MemoryUsage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CurrentBytes int64 `protobuf:"varint,1,opt,name=currentBytes,proto3" json:"currentBytes,omitempty"`
+	PeakBytes    int64 `protobuf:"varint,2,opt,name=peakBytes,proto3" json:"peakBytes,omitempty"`
+	// stat is the breakdown of memory usage from memory.stat, such as "anon" or "file"
+	Stat map[string]int64 `protobuf:"bytes,3,rep,name=stat,proto3" json:"stat,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+}
+
+func (x *MemoryUsage) Reset() {
+	*x = MemoryUsage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_proto_jobWorker_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MemoryUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MemoryUsage) ProtoMessage() {}
+
+func (x *MemoryUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_jobWorker_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MemoryUsage.ProtoReflect.Descriptor instead.
+func (*MemoryUsage) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *MemoryUsage) GetCurrentBytes() int64 {
+	if x != nil {
+		return x.CurrentBytes
+	}
+	return 0
+}
+
+func (x *MemoryUsage) GetPeakBytes() int64 {
+	if x != nil {
+		return x.PeakBytes
+	}
+	return 0
+}
+
+func (x *MemoryUsage) GetStat() map[string]int64 {
+	if x != nil {
+		return x.Stat
+	}
+	return nil
+}
+
+type IoUsage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// device is "major:minor" of the block device
+	Device     string `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	ReadBytes  int64  `protobuf:"varint,2,opt,name=readBytes,proto3" json:"readBytes,omitempty"`
+	WriteBytes int64  `protobuf:"varint,3,opt,name=writeBytes,proto3" json:"writeBytes,omitempty"`
+	ReadIos    int64  `protobuf:"varint,4,opt,name=readIos,proto3" json:"readIos,omitempty"`
+	WriteIos   int64  `protobuf:"varint,5,opt,name=writeIos,proto3" json:"writeIos,omitempty"`
+}
+
+func (x *IoUsage) Reset() {
+	*x = IoUsage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_proto_jobWorker_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IoUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IoUsage) ProtoMessage() {}
+
+func (x *IoUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_jobWorker_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IoUsage.ProtoReflect.Descriptor instead.
+func (*IoUsage) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *IoUsage) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *IoUsage) GetReadBytes() int64 {
+	if x != nil {
+		return x.ReadBytes
+	}
+	return 0
+}
+
+func (x *IoUsage) GetWriteBytes() int64 {
+	if x != nil {
+		return x.WriteBytes
+	}
+	return 0
+}
+
+func (x *IoUsage) GetReadIos() int64 {
+	if x != nil {
+		return x.ReadIos
+	}
+	return 0
+}
+
+func (x *IoUsage) GetWriteIos() int64 {
+	if x != nil {
+		return x.WriteIos
+	}
+	return 0
+}
+
+// NetworkUsage is the traffic of the job's interface from the job's point of view, zero unless NetworkMode is "bridge"
+type NetworkUsage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RxBytes   int64 `protobuf:"varint,1,opt,name=rxBytes,proto3" json:"rxBytes,omitempty"`
+	RxPackets int64 `protobuf:"varint,2,opt,name=rxPackets,proto3" json:"rxPackets,omitempty"`
+	TxBytes   int64 `protobuf:"varint,3,opt,name=txBytes,proto3" json:"txBytes,omitempty"`
+	TxPackets int64 `protobuf:"varint,4,opt,name=txPackets,proto3" json:"txPackets,omitempty"`
+	// rxDropped and txDropped are the packets dropped by the bandwidth limits
+	RxDropped int64 `protobuf:"varint,5,opt,name=rxDropped,proto3" json:"rxDropped,omitempty"`
+	TxDropped int64 `protobuf:"varint,6,opt,name=txDropped,proto3" json:"txDropped,omitempty"`
+}
+
+func (x *NetworkUsage) Reset() {
+	*x = NetworkUsage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_proto_jobWorker_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NetworkUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NetworkUsage) ProtoMessage() {}
+
+func (x *NetworkUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_jobWorker_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NetworkUsage.ProtoReflect.Descriptor instead.
+func (*NetworkUsage) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *NetworkUsage) GetRxBytes() int64 {
+	if x != nil {
+		return x.RxBytes
+	}
+	return 0
+}
+
+func (x *NetworkUsage) GetRxPackets() int64 {
+	if x != nil {
+		return x.RxPackets
+	}
+	return 0
+}
+
+func (x *NetworkUsage) GetTxBytes() int64 {
+	if x != nil {
+		return x.TxBytes
+	}
+	return 0
+}
+
+func (x *NetworkUsage) GetTxPackets() int64 {
+	if x != nil {
+		return x.TxPackets
+	}
+	return 0
+}
+
+func (x *NetworkUsage) GetRxDropped() int64 {
+	if x != nil {
+		return x.RxDropped
+	}
+	return 0
+}
+
+func (x *NetworkUsage) GetTxDropped() int64 {
+	if x != nil {
+		return x.TxDropped
+	}
+	return 0
+}
+
+type UsageResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status         Status        `protobuf:"varint,1,opt,name=status,proto3,enum=proto.Status" json:"status,omitempty"`
+	Cpu            *CpuUsage     `protobuf:"bytes,2,opt,name=cpu,proto3" json:"cpu,omitempty"`
+	Memory         *MemoryUsage  `protobuf:"bytes,3,opt,name=memory,proto3" json:"memory,omitempty"`
+	Io             []*IoUsage    `protobuf:"bytes,4,rep,name=io,proto3" json:"io,omitempty"`
+	PidsCurrent    int64         `protobuf:"varint,5,opt,name=pidsCurrent,proto3" json:"pidsCurrent,omitempty"`
+	PidsPeak       int64         `protobuf:"varint,6,opt,name=pidsPeak,proto3" json:"pidsPeak,omitempty"`
+	CpuPressure    *Pressure     `protobuf:"bytes,7,opt,name=cpuPressure,proto3" json:"cpuPressure,omitempty"`
+	MemoryPressure *Pressure     `protobuf:"bytes,8,opt,name=memoryPressure,proto3" json:"memoryPressure,omitempty"`
+	IoPressure     *Pressure     `protobuf:"bytes,9,opt,name=ioPressure,proto3" json:"ioPressure,omitempty"`
+	Network        *NetworkUsage `protobuf:"bytes,10,opt,name=network,proto3" json:"network,omitempty"`
 }
 
-func (x *JobRequest) Reset() {
-	*x = JobRequest{}
+func (x *UsageResponse) Reset() {
+	*x = UsageResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_proto_jobWorker_proto_msgTypes[1]
+		mi := &file_pkg_proto_jobWorker_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *JobRequest) String() string {
+func (x *UsageResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*JobRequest) ProtoMessage() {}
+func (*UsageResponse) ProtoMessage() {}
 
-func (x *JobRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_jobWorker_proto_msgTypes[1]
+func (x *UsageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_jobWorker_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -193,44 +1416,110 @@ func (x *JobRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use JobRequest.ProtoReflect.Descriptor instead.
-func (*JobRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use UsageResponse.ProtoReflect.Descriptor instead.
+func (*UsageResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *JobRequest) GetId() string {
+func (x *UsageResponse) GetStatus() Status {
 	if x != nil {
-		return x.Id
+		return x.Status
 	}
-	return ""
+	return Status_UNSPECIFIED
 }
 
-// responses
-type JobResponse struct {
+func (x *UsageResponse) GetCpu() *CpuUsage {
+	if x != nil {
+		return x.Cpu
+	}
+	return nil
+}
+
+func (x *UsageResponse) GetMemory() *MemoryUsage {
+	if x != nil {
+		return x.Memory
+	}
+	return nil
+}
+
+func (x *UsageResponse) GetIo() []*IoUsage {
+	if x != nil {
+		return x.Io
+	}
+	return nil
+}
+
+func (x *UsageResponse) GetPidsCurrent() int64 {
+	if x != nil {
+		return x.PidsCurrent
+	}
+	return 0
+}
+
+func (x *UsageResponse) GetPidsPeak() int64 {
+	if x != nil {
+		return x.PidsPeak
+	}
+	return 0
+}
+
+func (x *UsageResponse) GetCpuPressure() *Pressure {
+	if x != nil {
+		return x.CpuPressure
+	}
+	return nil
+}
+
+func (x *UsageResponse) GetMemoryPressure() *Pressure {
+	if x != nil {
+		return x.MemoryPressure
+	}
+	return nil
+}
+
+func (x *UsageResponse) GetIoPressure() *Pressure {
+	if x != nil {
+		return x.IoPressure
+	}
+	return nil
+}
+
+func (x *UsageResponse) GetNetwork() *NetworkUsage {
+	if x != nil {
+		return x.Network
+	}
+	return nil
+}
+
+// PressureStats is the share of time in percent tasks were stalled over the last 10, 60 and 300 seconds
+type PressureStats struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Id string `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Avg10     float64 `protobuf:"fixed64,1,opt,name=avg10,proto3" json:"avg10,omitempty"`
+	Avg60     float64 `protobuf:"fixed64,2,opt,name=avg60,proto3" json:"avg60,omitempty"`
+	Avg300    float64 `protobuf:"fixed64,3,opt,name=avg300,proto3" json:"avg300,omitempty"`
+	TotalUsec int64   `protobuf:"varint,4,opt,name=totalUsec,proto3" json:"totalUsec,omitempty"`
 }
 
-func (x *JobResponse) Reset() {
-	*x = JobResponse{}
+func (x *PressureStats) Reset() {
+	*x = PressureStats{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_proto_jobWorker_proto_msgTypes[2]
+		mi := &file_pkg_proto_jobWorker_proto_msgTypes[14]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *JobResponse) String() string {
+func (x *PressureStats) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*JobResponse) ProtoMessage() {}
+func (*PressureStats) ProtoMessage() {}
 
-func (x *JobResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_jobWorker_proto_msgTypes[2]
+func (x *PressureStats) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_jobWorker_proto_msgTypes[14]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -241,45 +1530,65 @@ func (x *JobResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use JobResponse.ProtoReflect.Descriptor instead.
-func (*JobResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use PressureStats.ProtoReflect.Descriptor instead.
+func (*PressureStats) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *JobResponse) GetId() string {
+func (x *PressureStats) GetAvg10() float64 {
 	if x != nil {
-		return x.Id
+		return x.Avg10
 	}
-	return ""
+	return 0
 }
 
-type JobStatusResponse struct {
+func (x *PressureStats) GetAvg60() float64 {
+	if x != nil {
+		return x.Avg60
+	}
+	return 0
+}
+
+func (x *PressureStats) GetAvg300() float64 {
+	if x != nil {
+		return x.Avg300
+	}
+	return 0
+}
+
+func (x *PressureStats) GetTotalUsec() int64 {
+	if x != nil {
+		return x.TotalUsec
+	}
+	return 0
+}
+
+type Pressure struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Status     Status `protobuf:"varint,1,opt,name=status,proto3,enum=proto.Status" json:"status,omitempty"`
-	ExitCode   int32  `protobuf:"varint,2,opt,name=exitCode,proto3" json:"exitCode,omitempty"`
-	ExitReason string `protobuf:"bytes,3,opt,name=exitReason,proto3" json:"exitReason,omitempty"`
+	Some *PressureStats `protobuf:"bytes,1,opt,name=some,proto3" json:"some,omitempty"`
+	Full *PressureStats `protobuf:"bytes,2,opt,name=full,proto3" json:"full,omitempty"`
 }
 
-func (x *JobStatusResponse) Reset() {
-	*x = JobStatusResponse{}
+func (x *Pressure) Reset() {
+	*x = Pressure{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_proto_jobWorker_proto_msgTypes[3]
+		mi := &file_pkg_proto_jobWorker_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *JobStatusResponse) String() string {
+func (x *Pressure) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*JobStatusResponse) ProtoMessage() {}
+func (*Pressure) ProtoMessage() {}
 
-func (x *JobStatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_jobWorker_proto_msgTypes[3]
+func (x *Pressure) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_jobWorker_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -290,30 +1599,23 @@ func (x *JobStatusResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use JobStatusResponse.ProtoReflect.Descriptor instead.
-func (*JobStatusResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{3}
-}
-
-func (x *JobStatusResponse) GetStatus() Status {
-	if x != nil {
-		return x.Status
-	}
-	return Status_UNSPECIFIED
+// Deprecated: Use Pressure.ProtoReflect.Descriptor instead.
+func (*Pressure) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *JobStatusResponse) GetExitCode() int32 {
+func (x *Pressure) GetSome() *PressureStats {
 	if x != nil {
-		return x.ExitCode
+		return x.Some
 	}
-	return 0
+	return nil
 }
 
-func (x *JobStatusResponse) GetExitReason() string {
+func (x *Pressure) GetFull() *PressureStats {
 	if x != nil {
-		return x.ExitReason
+		return x.Full
 	}
-	return ""
+	return nil
 }
 
 type OutputResponse struct {
@@ -327,7 +1629,7 @@ type OutputResponse struct {
 func (x *OutputResponse) Reset() {
 	*x = OutputResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_proto_jobWorker_proto_msgTypes[4]
+		mi := &file_pkg_proto_jobWorker_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -340,7 +1642,7 @@ func (x *OutputResponse) String() string {
 func (*OutputResponse) ProtoMessage() {}
 
 func (x *OutputResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_jobWorker_proto_msgTypes[4]
+	mi := &file_pkg_proto_jobWorker_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -353,7 +1655,7 @@ func (x *OutputResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OutputResponse.ProtoReflect.Descriptor instead.
 func (*OutputResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{4}
+	return file_pkg_proto_jobWorker_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *OutputResponse) GetContent() []byte {
@@ -368,7 +1670,7 @@ var File_pkg_proto_jobWorker_proto protoreflect.FileDescriptor
 var file_pkg_proto_jobWorker_proto_rawDesc = []byte{
 	0x0a, 0x19, 0x70, 0x6b, 0x67, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x6a, 0x6f, 0x62, 0x57,
 	0x6f, 0x72, 0x6b, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x22, 0x9a, 0x01, 0x0a, 0x10, 0x4a, 0x6f, 0x62, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65,
+	0x74, 0x6f, 0x22, 0x90, 0x0e, 0x0a, 0x10, 0x4a, 0x6f, 0x62, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65,
 	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x43, 0x50, 0x55, 0x18, 0x01,
 	0x20, 0x01, 0x28, 0x01, 0x52, 0x03, 0x43, 0x50, 0x55, 0x12, 0x1a, 0x0a, 0x08, 0x4d, 0x65, 0x6d,
 	0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x4d, 0x65, 0x6d,
@@ -377,46 +1679,297 @@ var file_pkg_proto_jobWorker_proto_rawDesc = []byte{
 	0x10, 0x49, 0x6f, 0x42, 0x79, 0x74, 0x65, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e,
 	0x64, 0x12, 0x18, 0x0a, 0x07, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x04, 0x20, 0x01,
 	0x28, 0x09, 0x52, 0x07, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x41,
-	0x72, 0x67, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x41, 0x72, 0x67, 0x73, 0x22,
-	0x1c, 0x0a, 0x0a, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a,
-	0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x49, 0x64, 0x22, 0x1d, 0x0a,
-	0x0b, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x0e, 0x0a, 0x02,
-	0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x49, 0x64, 0x22, 0x76, 0x0a, 0x11,
-	0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x25, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0e, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
-	0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x65, 0x78, 0x69, 0x74,
-	0x43, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x65, 0x78, 0x69, 0x74,
-	0x43, 0x6f, 0x64, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x65, 0x78, 0x69, 0x74, 0x52, 0x65, 0x61, 0x73,
-	0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x65, 0x78, 0x69, 0x74, 0x52, 0x65,
-	0x61, 0x73, 0x6f, 0x6e, 0x22, 0x2a, 0x0a, 0x0e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e,
-	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74,
-	0x2a, 0x63, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x4e,
-	0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0f, 0x0a, 0x0b, 0x4e,
-	0x4f, 0x54, 0x5f, 0x53, 0x54, 0x41, 0x52, 0x54, 0x45, 0x44, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07,
-	0x52, 0x55, 0x4e, 0x4e, 0x49, 0x4e, 0x47, 0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07, 0x53, 0x54, 0x4f,
-	0x50, 0x50, 0x45, 0x44, 0x10, 0x03, 0x12, 0x0e, 0x0a, 0x0a, 0x54, 0x45, 0x52, 0x4d, 0x49, 0x4e,
-	0x41, 0x54, 0x45, 0x44, 0x10, 0x04, 0x12, 0x0d, 0x0a, 0x09, 0x43, 0x4f, 0x4d, 0x50, 0x4c, 0x45,
-	0x54, 0x45, 0x44, 0x10, 0x05, 0x32, 0xeb, 0x01, 0x0a, 0x09, 0x4a, 0x6f, 0x62, 0x57, 0x6f, 0x72,
-	0x6b, 0x65, 0x72, 0x12, 0x36, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x17, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4a, 0x6f, 0x62, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4a, 0x6f,
-	0x62, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x37, 0x0a, 0x06, 0x53,
-	0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x11, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4a, 0x6f,
-	0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x2e, 0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x22, 0x00, 0x12, 0x36, 0x0a, 0x06, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x11,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x30, 0x01, 0x12, 0x35, 0x0a, 0x04,
-	0x53, 0x74, 0x6f, 0x70, 0x12, 0x11, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4a, 0x6f, 0x62,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
-	0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x22, 0x00, 0x42, 0x32, 0x5a, 0x30, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
-	0x6d, 0x2f, 0x50, 0x2d, 0x41, 0x2d, 0x52, 0x2d, 0x55, 0x2d, 0x53, 0x2f, 0x47, 0x6f, 0x2d, 0x4a,
-	0x6f, 0x62, 0x2d, 0x57, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x2d, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
-	0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x72, 0x67, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x41, 0x72, 0x67, 0x73, 0x12,
+	0x2c, 0x0a, 0x11, 0x53, 0x74, 0x6f, 0x70, 0x47, 0x72, 0x61, 0x63, 0x65, 0x50, 0x65, 0x72, 0x69,
+	0x6f, 0x64, 0x4d, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x11, 0x53, 0x74, 0x6f, 0x70,
+	0x47, 0x72, 0x61, 0x63, 0x65, 0x50, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x4d, 0x73, 0x12, 0x2e, 0x0a,
+	0x12, 0x52, 0x65, 0x61, 0x64, 0x42, 0x79, 0x74, 0x65, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63,
+	0x6f, 0x6e, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x12, 0x52, 0x65, 0x61, 0x64, 0x42,
+	0x79, 0x74, 0x65, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x12, 0x30, 0x0a,
+	0x13, 0x57, 0x72, 0x69, 0x74, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65,
+	0x63, 0x6f, 0x6e, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x13, 0x57, 0x72, 0x69, 0x74,
+	0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x12,
+	0x28, 0x0a, 0x0f, 0x52, 0x65, 0x61, 0x64, 0x49, 0x6f, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f,
+	0x6e, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x52, 0x65, 0x61, 0x64, 0x49, 0x6f,
+	0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x12, 0x2a, 0x0a, 0x10, 0x57, 0x72, 0x69,
+	0x74, 0x65, 0x49, 0x6f, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x18, 0x0a, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x10, 0x57, 0x72, 0x69, 0x74, 0x65, 0x49, 0x6f, 0x50, 0x65, 0x72, 0x53,
+	0x65, 0x63, 0x6f, 0x6e, 0x64, 0x12, 0x22, 0x0a, 0x0c, 0x49, 0x6f, 0x44, 0x65, 0x76, 0x69, 0x63,
+	0x65, 0x50, 0x61, 0x74, 0x68, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x49, 0x6f, 0x44,
+	0x65, 0x76, 0x69, 0x63, 0x65, 0x50, 0x61, 0x74, 0x68, 0x12, 0x20, 0x0a, 0x0b, 0x4d, 0x65, 0x6d,
+	0x4d, 0x61, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b,
+	0x4d, 0x65, 0x6d, 0x4d, 0x61, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x53,
+	0x77, 0x61, 0x70, 0x4d, 0x61, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x0d, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0c, 0x53, 0x77, 0x61, 0x70, 0x4d, 0x61, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12,
+	0x1c, 0x0a, 0x09, 0x43, 0x70, 0x75, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x0e, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x09, 0x43, 0x70, 0x75, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x1e, 0x0a,
+	0x0a, 0x43, 0x70, 0x75, 0x73, 0x65, 0x74, 0x43, 0x70, 0x75, 0x73, 0x18, 0x0f, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0a, 0x43, 0x70, 0x75, 0x73, 0x65, 0x74, 0x43, 0x70, 0x75, 0x73, 0x12, 0x1e, 0x0a,
+	0x0a, 0x43, 0x70, 0x75, 0x73, 0x65, 0x74, 0x4d, 0x65, 0x6d, 0x73, 0x18, 0x10, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0a, 0x43, 0x70, 0x75, 0x73, 0x65, 0x74, 0x4d, 0x65, 0x6d, 0x73, 0x12, 0x22, 0x0a,
+	0x0c, 0x4d, 0x61, 0x78, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x65, 0x73, 0x18, 0x11, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x0c, 0x4d, 0x61, 0x78, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x65,
+	0x73, 0x12, 0x42, 0x0a, 0x10, 0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x54, 0x72, 0x69,
+	0x67, 0x67, 0x65, 0x72, 0x73, 0x18, 0x12, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x54, 0x72, 0x69, 0x67,
+	0x67, 0x65, 0x72, 0x52, 0x10, 0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x54, 0x72, 0x69,
+	0x67, 0x67, 0x65, 0x72, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x52, 0x6f, 0x6f, 0x74, 0x46, 0x53, 0x18,
+	0x13, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x52, 0x6f, 0x6f, 0x74, 0x46, 0x53, 0x12, 0x24, 0x0a,
+	0x0d, 0x52, 0x6f, 0x6f, 0x74, 0x46, 0x53, 0x4f, 0x76, 0x65, 0x72, 0x6c, 0x61, 0x79, 0x18, 0x14,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x52, 0x6f, 0x6f, 0x74, 0x46, 0x53, 0x4f, 0x76, 0x65, 0x72,
+	0x6c, 0x61, 0x79, 0x12, 0x2c, 0x0a, 0x11, 0x4b, 0x65, 0x65, 0x70, 0x52, 0x6f, 0x6f, 0x74, 0x46,
+	0x53, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x18, 0x15, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11,
+	0x4b, 0x65, 0x65, 0x70, 0x52, 0x6f, 0x6f, 0x74, 0x46, 0x53, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65,
+	0x73, 0x12, 0x27, 0x0a, 0x07, 0x56, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x73, 0x18, 0x16, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x56, 0x6f, 0x6c, 0x75, 0x6d,
+	0x65, 0x52, 0x07, 0x56, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x49, 0x6d,
+	0x61, 0x67, 0x65, 0x18, 0x17, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x49, 0x6d, 0x61, 0x67, 0x65,
+	0x12, 0x32, 0x0a, 0x03, 0x45, 0x6e, 0x76, 0x18, 0x18, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4a, 0x6f, 0x62, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x45, 0x6e, 0x76, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52,
+	0x03, 0x45, 0x6e, 0x76, 0x12, 0x1f, 0x0a, 0x08, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x45, 0x6e, 0x76,
+	0x18, 0x19, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x08, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x45,
+	0x6e, 0x76, 0x88, 0x01, 0x01, 0x12, 0x1e, 0x0a, 0x0a, 0x57, 0x6f, 0x72, 0x6b, 0x69, 0x6e, 0x67,
+	0x44, 0x69, 0x72, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x57, 0x6f, 0x72, 0x6b, 0x69,
+	0x6e, 0x67, 0x44, 0x69, 0x72, 0x12, 0x15, 0x0a, 0x03, 0x55, 0x69, 0x64, 0x18, 0x1b, 0x20, 0x01,
+	0x28, 0x0d, 0x48, 0x01, 0x52, 0x03, 0x55, 0x69, 0x64, 0x88, 0x01, 0x01, 0x12, 0x15, 0x0a, 0x03,
+	0x47, 0x69, 0x64, 0x18, 0x1c, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x02, 0x52, 0x03, 0x47, 0x69, 0x64,
+	0x88, 0x01, 0x01, 0x12, 0x16, 0x0a, 0x06, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x73, 0x18, 0x1d, 0x20,
+	0x03, 0x28, 0x0d, 0x52, 0x06, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x73, 0x12, 0x26, 0x0a, 0x0e, 0x53,
+	0x65, 0x63, 0x63, 0x6f, 0x6d, 0x70, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x1e, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0e, 0x53, 0x65, 0x63, 0x63, 0x6f, 0x6d, 0x70, 0x50, 0x72, 0x6f, 0x66,
+	0x69, 0x6c, 0x65, 0x12, 0x28, 0x0a, 0x0f, 0x41, 0x64, 0x64, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69,
+	0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x1f, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0f, 0x41, 0x64,
+	0x64, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x12, 0x2a, 0x0a,
+	0x10, 0x44, 0x72, 0x6f, 0x70, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65,
+	0x73, 0x18, 0x20, 0x20, 0x03, 0x28, 0x09, 0x52, 0x10, 0x44, 0x72, 0x6f, 0x70, 0x43, 0x61, 0x70,
+	0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x12, 0x23, 0x0a, 0x0a, 0x4e, 0x6f, 0x4e,
+	0x65, 0x77, 0x50, 0x72, 0x69, 0x76, 0x73, 0x18, 0x21, 0x20, 0x01, 0x28, 0x08, 0x48, 0x03, 0x52,
+	0x0a, 0x4e, 0x6f, 0x4e, 0x65, 0x77, 0x50, 0x72, 0x69, 0x76, 0x73, 0x88, 0x01, 0x01, 0x12, 0x3e,
+	0x0a, 0x07, 0x52, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x18, 0x22, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x24, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4a, 0x6f, 0x62, 0x43, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x52, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x52, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x12, 0x20,
+	0x0a, 0x0b, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4d, 0x6f, 0x64, 0x65, 0x18, 0x23, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0b, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4d, 0x6f, 0x64, 0x65,
+	0x12, 0x36, 0x0a, 0x0c, 0x50, 0x6f, 0x72, 0x74, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x73,
+	0x18, 0x24, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50,
+	0x6f, 0x72, 0x74, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x52, 0x0c, 0x50, 0x6f, 0x72, 0x74,
+	0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x42, 0x0a, 0x1c, 0x4e, 0x65, 0x74, 0x77,
+	0x6f, 0x72, 0x6b, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x73, 0x73, 0x42, 0x79, 0x74, 0x65, 0x73, 0x50,
+	0x65, 0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x18, 0x25, 0x20, 0x01, 0x28, 0x03, 0x52, 0x1c,
+	0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x73, 0x73, 0x42, 0x79,
+	0x74, 0x65, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x12, 0x40, 0x0a, 0x1b,
+	0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x45, 0x67, 0x72, 0x65, 0x73, 0x73, 0x42, 0x79, 0x74,
+	0x65, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x18, 0x26, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x1b, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x45, 0x67, 0x72, 0x65, 0x73, 0x73,
+	0x42, 0x79, 0x74, 0x65, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x12, 0x1a,
+	0x0a, 0x08, 0x48, 0x6f, 0x73, 0x74, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x27, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x48, 0x6f, 0x73, 0x74, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x44, 0x6f,
+	0x6d, 0x61, 0x69, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x28, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
+	0x44, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x53, 0x68,
+	0x6d, 0x53, 0x69, 0x7a, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x29, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0c, 0x53, 0x68, 0x6d, 0x53, 0x69, 0x7a, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x26,
+	0x0a, 0x0e, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x65, 0x43, 0x47, 0x72, 0x6f, 0x75, 0x70,
+	0x18, 0x2a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x65,
+	0x43, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x1a, 0x36, 0x0a, 0x08, 0x45, 0x6e, 0x76, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x49,
+	0x0a, 0x0c, 0x52, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10,
+	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79,
+	0x12, 0x23, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x43, 0x6c,
+	0x65, 0x61, 0x72, 0x45, 0x6e, 0x76, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x55, 0x69, 0x64, 0x42, 0x06,
+	0x0a, 0x04, 0x5f, 0x47, 0x69, 0x64, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x4e, 0x6f, 0x4e, 0x65, 0x77,
+	0x50, 0x72, 0x69, 0x76, 0x73, 0x22, 0x6b, 0x0a, 0x0b, 0x50, 0x6f, 0x72, 0x74, 0x4d, 0x61, 0x70,
+	0x70, 0x69, 0x6e, 0x67, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x12, 0x1a, 0x0a, 0x08, 0x48, 0x6f, 0x73, 0x74, 0x50, 0x6f, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x08, 0x48, 0x6f, 0x73, 0x74, 0x50, 0x6f, 0x72, 0x74, 0x12, 0x24, 0x0a, 0x0d,
+	0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x50, 0x6f, 0x72, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x0d, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x50, 0x6f,
+	0x72, 0x74, 0x22, 0x30, 0x0a, 0x06, 0x52, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x53, 0x6f, 0x66, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x53, 0x6f, 0x66, 0x74,
+	0x12, 0x12, 0x0a, 0x04, 0x48, 0x61, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04,
+	0x48, 0x61, 0x72, 0x64, 0x22, 0x86, 0x01, 0x0a, 0x06, 0x56, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x12,
+	0x12, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x54,
+	0x79, 0x70, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x54,
+	0x61, 0x72, 0x67, 0x65, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x54, 0x61, 0x72,
+	0x67, 0x65, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x52, 0x65, 0x61, 0x64, 0x4f, 0x6e, 0x6c, 0x79, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x52, 0x65, 0x61, 0x64, 0x4f, 0x6e, 0x6c, 0x79, 0x12,
+	0x1c, 0x0a, 0x09, 0x53, 0x69, 0x7a, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x09, 0x53, 0x69, 0x7a, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x22, 0x7f, 0x0a,
+	0x0f, 0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x54, 0x72, 0x69, 0x67, 0x67, 0x65, 0x72,
+	0x12, 0x1a, 0x0a, 0x08, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x12, 0x0a, 0x04,
+	0x46, 0x75, 0x6c, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x46, 0x75, 0x6c, 0x6c,
+	0x12, 0x20, 0x0a, 0x0b, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x4d, 0x73, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64,
+	0x4d, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x4d, 0x73, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x4d, 0x73, 0x22, 0x1c,
+	0x0a, 0x0a, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02,
+	0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x49, 0x64, 0x22, 0x43, 0x0a, 0x0b,
+	0x53, 0x74, 0x6f, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x49, 0x64, 0x12, 0x24, 0x0a, 0x0d, 0x47,
+	0x72, 0x61, 0x63, 0x65, 0x50, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x4d, 0x73, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0d, 0x47, 0x72, 0x61, 0x63, 0x65, 0x50, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x4d,
+	0x73, 0x22, 0x1d, 0x0a, 0x0b, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x49, 0x64,
+	0x22, 0x96, 0x03, 0x0a, 0x11, 0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x25, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1a, 0x0a,
+	0x08, 0x65, 0x78, 0x69, 0x74, 0x43, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x08, 0x65, 0x78, 0x69, 0x74, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x65, 0x78, 0x69,
+	0x74, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x65,
+	0x78, 0x69, 0x74, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x6f, 0x6f, 0x6d,
+	0x4b, 0x69, 0x6c, 0x6c, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x6f, 0x6f,
+	0x6d, 0x4b, 0x69, 0x6c, 0x6c, 0x65, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x70, 0x69, 0x64, 0x73, 0x43,
+	0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x70, 0x69,
+	0x64, 0x73, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x69, 0x64,
+	0x73, 0x50, 0x65, 0x61, 0x6b, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x70, 0x69, 0x64,
+	0x73, 0x50, 0x65, 0x61, 0x6b, 0x12, 0x2a, 0x0a, 0x10, 0x70, 0x69, 0x64, 0x73, 0x4c, 0x69, 0x6d,
+	0x69, 0x74, 0x52, 0x65, 0x61, 0x63, 0x68, 0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x10, 0x70, 0x69, 0x64, 0x73, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x52, 0x65, 0x61, 0x63, 0x68, 0x65,
+	0x64, 0x12, 0x22, 0x0a, 0x0c, 0x63, 0x70, 0x75, 0x55, 0x73, 0x61, 0x67, 0x65, 0x55, 0x73, 0x65,
+	0x63, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x63, 0x70, 0x75, 0x55, 0x73, 0x61, 0x67,
+	0x65, 0x55, 0x73, 0x65, 0x63, 0x12, 0x28, 0x0a, 0x0f, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x50,
+	0x65, 0x61, 0x6b, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f,
+	0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x50, 0x65, 0x61, 0x6b, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12,
+	0x2a, 0x0a, 0x10, 0x63, 0x70, 0x75, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x45, 0x78, 0x63, 0x65, 0x65,
+	0x64, 0x65, 0x64, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x63, 0x70, 0x75, 0x4c, 0x69,
+	0x6d, 0x69, 0x74, 0x45, 0x78, 0x63, 0x65, 0x65, 0x64, 0x65, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x69,
+	0x70, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x69, 0x70, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0xca, 0x01, 0x0a, 0x08, 0x43, 0x70,
+	0x75, 0x55, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x75, 0x73, 0x61, 0x67, 0x65, 0x55,
+	0x73, 0x65, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x75, 0x73, 0x61, 0x67, 0x65,
+	0x55, 0x73, 0x65, 0x63, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x55, 0x73, 0x65, 0x63,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x55, 0x73, 0x65, 0x63,
+	0x12, 0x1e, 0x0a, 0x0a, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x55, 0x73, 0x65, 0x63, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x55, 0x73, 0x65, 0x63,
+	0x12, 0x1c, 0x0a, 0x09, 0x6e, 0x72, 0x50, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x73, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x09, 0x6e, 0x72, 0x50, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x73, 0x12, 0x20,
+	0x0a, 0x0b, 0x6e, 0x72, 0x54, 0x68, 0x72, 0x6f, 0x74, 0x74, 0x6c, 0x65, 0x64, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x0b, 0x6e, 0x72, 0x54, 0x68, 0x72, 0x6f, 0x74, 0x74, 0x6c, 0x65, 0x64,
+	0x12, 0x24, 0x0a, 0x0d, 0x74, 0x68, 0x72, 0x6f, 0x74, 0x74, 0x6c, 0x65, 0x64, 0x55, 0x73, 0x65,
+	0x63, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x74, 0x68, 0x72, 0x6f, 0x74, 0x74, 0x6c,
+	0x65, 0x64, 0x55, 0x73, 0x65, 0x63, 0x22, 0xba, 0x01, 0x0a, 0x0b, 0x4d, 0x65, 0x6d, 0x6f, 0x72,
+	0x79, 0x55, 0x73, 0x61, 0x67, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e,
+	0x74, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x63, 0x75,
+	0x72, 0x72, 0x65, 0x6e, 0x74, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x70, 0x65,
+	0x61, 0x6b, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x70,
+	0x65, 0x61, 0x6b, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x30, 0x0a, 0x04, 0x73, 0x74, 0x61, 0x74,
+	0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4d,
+	0x65, 0x6d, 0x6f, 0x72, 0x79, 0x55, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x52, 0x04, 0x73, 0x74, 0x61, 0x74, 0x1a, 0x37, 0x0a, 0x09, 0x53, 0x74,
+	0x61, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
+	0x02, 0x38, 0x01, 0x22, 0x95, 0x01, 0x0a, 0x07, 0x49, 0x6f, 0x55, 0x73, 0x61, 0x67, 0x65, 0x12,
+	0x16, 0x0a, 0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x61, 0x64, 0x42,
+	0x79, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x72, 0x65, 0x61, 0x64,
+	0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x77, 0x72, 0x69, 0x74, 0x65, 0x42, 0x79,
+	0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x77, 0x72, 0x69, 0x74, 0x65,
+	0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x61, 0x64, 0x49, 0x6f, 0x73,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x72, 0x65, 0x61, 0x64, 0x49, 0x6f, 0x73, 0x12,
+	0x1a, 0x0a, 0x08, 0x77, 0x72, 0x69, 0x74, 0x65, 0x49, 0x6f, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x08, 0x77, 0x72, 0x69, 0x74, 0x65, 0x49, 0x6f, 0x73, 0x22, 0xba, 0x01, 0x0a, 0x0c,
+	0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x55, 0x73, 0x61, 0x67, 0x65, 0x12, 0x18, 0x0a, 0x07,
+	0x72, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x72,
+	0x78, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x78, 0x50, 0x61, 0x63, 0x6b,
+	0x65, 0x74, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x72, 0x78, 0x50, 0x61, 0x63,
+	0x6b, 0x65, 0x74, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x74, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x74, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x1c,
+	0x0a, 0x09, 0x74, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x09, 0x74, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x1c, 0x0a, 0x09,
+	0x72, 0x78, 0x44, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x09, 0x72, 0x78, 0x44, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x78,
+	0x44, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74,
+	0x78, 0x44, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x22, 0xaf, 0x03, 0x0a, 0x0d, 0x55, 0x73, 0x61,
+	0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x25, 0x0a, 0x06, 0x73, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x12, 0x21, 0x0a, 0x03, 0x63, 0x70, 0x75, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x70, 0x75, 0x55, 0x73, 0x61, 0x67, 0x65, 0x52,
+	0x03, 0x63, 0x70, 0x75, 0x12, 0x2a, 0x0a, 0x06, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4d, 0x65, 0x6d,
+	0x6f, 0x72, 0x79, 0x55, 0x73, 0x61, 0x67, 0x65, 0x52, 0x06, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79,
+	0x12, 0x1e, 0x0a, 0x02, 0x69, 0x6f, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x49, 0x6f, 0x55, 0x73, 0x61, 0x67, 0x65, 0x52, 0x02, 0x69, 0x6f,
+	0x12, 0x20, 0x0a, 0x0b, 0x70, 0x69, 0x64, 0x73, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x70, 0x69, 0x64, 0x73, 0x43, 0x75, 0x72, 0x72, 0x65,
+	0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x69, 0x64, 0x73, 0x50, 0x65, 0x61, 0x6b, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x70, 0x69, 0x64, 0x73, 0x50, 0x65, 0x61, 0x6b, 0x12, 0x31,
+	0x0a, 0x0b, 0x63, 0x70, 0x75, 0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x72, 0x65, 0x73,
+	0x73, 0x75, 0x72, 0x65, 0x52, 0x0b, 0x63, 0x70, 0x75, 0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72,
+	0x65, 0x12, 0x37, 0x0a, 0x0e, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x50, 0x72, 0x65, 0x73, 0x73,
+	0x75, 0x72, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2e, 0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x52, 0x0e, 0x6d, 0x65, 0x6d, 0x6f,
+	0x72, 0x79, 0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x12, 0x2f, 0x0a, 0x0a, 0x69, 0x6f,
+	0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x52,
+	0x0a, 0x69, 0x6f, 0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x12, 0x2d, 0x0a, 0x07, 0x6e,
+	0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x55, 0x73, 0x61, 0x67,
+	0x65, 0x52, 0x07, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x22, 0x71, 0x0a, 0x0d, 0x50, 0x72,
+	0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x61,
+	0x76, 0x67, 0x31, 0x30, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x61, 0x76, 0x67, 0x31,
+	0x30, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x76, 0x67, 0x36, 0x30, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x05, 0x61, 0x76, 0x67, 0x36, 0x30, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x76, 0x67, 0x33, 0x30,
+	0x30, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x61, 0x76, 0x67, 0x33, 0x30, 0x30, 0x12,
+	0x1c, 0x0a, 0x09, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x55, 0x73, 0x65, 0x63, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x09, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x55, 0x73, 0x65, 0x63, 0x22, 0x5e, 0x0a,
+	0x08, 0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x12, 0x28, 0x0a, 0x04, 0x73, 0x6f, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x04, 0x73,
+	0x6f, 0x6d, 0x65, 0x12, 0x28, 0x0a, 0x04, 0x66, 0x75, 0x6c, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x72, 0x65, 0x73, 0x73, 0x75,
+	0x72, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x04, 0x66, 0x75, 0x6c, 0x6c, 0x22, 0x2a, 0x0a,
+	0x0e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x2a, 0x71, 0x0a, 0x06, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49,
+	0x45, 0x44, 0x10, 0x00, 0x12, 0x0f, 0x0a, 0x0b, 0x4e, 0x4f, 0x54, 0x5f, 0x53, 0x54, 0x41, 0x52,
+	0x54, 0x45, 0x44, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x52, 0x55, 0x4e, 0x4e, 0x49, 0x4e, 0x47,
+	0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07, 0x53, 0x54, 0x4f, 0x50, 0x50, 0x45, 0x44, 0x10, 0x03, 0x12,
+	0x0e, 0x0a, 0x0a, 0x54, 0x45, 0x52, 0x4d, 0x49, 0x4e, 0x41, 0x54, 0x45, 0x44, 0x10, 0x04, 0x12,
+	0x0d, 0x0a, 0x09, 0x43, 0x4f, 0x4d, 0x50, 0x4c, 0x45, 0x54, 0x45, 0x44, 0x10, 0x05, 0x12, 0x0c,
+	0x0a, 0x08, 0x53, 0x54, 0x4f, 0x50, 0x50, 0x49, 0x4e, 0x47, 0x10, 0x06, 0x32, 0xa5, 0x03, 0x0a,
+	0x09, 0x4a, 0x6f, 0x62, 0x57, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x12, 0x36, 0x0a, 0x05, 0x53, 0x74,
+	0x61, 0x72, 0x74, 0x12, 0x17, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4a, 0x6f, 0x62, 0x43,
+	0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0x00, 0x12, 0x37, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x11, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x18, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x36, 0x0a, 0x06, 0x53,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x11, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4a, 0x6f,
+	0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
+	0x00, 0x30, 0x01, 0x12, 0x36, 0x0a, 0x04, 0x53, 0x74, 0x6f, 0x70, 0x12, 0x12, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x74, 0x6f, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x18, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x32, 0x0a, 0x05, 0x55,
+	0x73, 0x61, 0x67, 0x65, 0x12, 0x11, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4a, 0x6f, 0x62,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x55, 0x73, 0x61, 0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12,
+	0x43, 0x0a, 0x13, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x6f, 0x6f, 0x74, 0x46, 0x53, 0x43,
+	0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x12, 0x11, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4a,
+	0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0x00, 0x30, 0x01, 0x12, 0x3e, 0x0a, 0x13, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x52, 0x6f,
+	0x6f, 0x74, 0x46, 0x53, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x12, 0x11, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2e, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x22, 0x00, 0x42, 0x32, 0x5a, 0x30, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
+	0x6f, 0x6d, 0x2f, 0x50, 0x2d, 0x41, 0x2d, 0x52, 0x2d, 0x55, 0x2d, 0x53, 0x2f, 0x47, 0x6f, 0x2d,
+	0x4a, 0x6f, 0x62, 0x2d, 0x57, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x2d, 0x53, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -432,30 +1985,68 @@ func file_pkg_proto_jobWorker_proto_rawDescGZIP() []byte {
 }
 
 var file_pkg_proto_jobWorker_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_pkg_proto_jobWorker_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_pkg_proto_jobWorker_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
 var file_pkg_proto_jobWorker_proto_goTypes = []any{
 	(Status)(0),               // 0: proto.Status
 	(*JobCreateRequest)(nil),  // 1: proto.JobCreateRequest
-	(*JobRequest)(nil),        // 2: proto.JobRequest
-	(*JobResponse)(nil),       // 3: proto.JobResponse
-	(*JobStatusResponse)(nil), // 4: proto.JobStatusResponse
-	(*OutputResponse)(nil),    // 5: proto.OutputResponse
+	(*PortMapping)(nil),       // 2: proto.PortMapping
+	(*Rlimit)(nil),            // 3: proto.Rlimit
+	(*Volume)(nil),            // 4: proto.Volume
+	(*PressureTrigger)(nil),   // 5: proto.PressureTrigger
+	(*JobRequest)(nil),        // 6: proto.JobRequest
+	(*StopRequest)(nil),       // 7: proto.StopRequest
+	(*JobResponse)(nil),       // 8: proto.JobResponse
+	(*JobStatusResponse)(nil), // 9: proto.JobStatusResponse
+	(*CpuUsage)(nil),          // 10: proto.CpuUsage
+	(*MemoryUsage)(nil),       // 11: proto.MemoryUsage
+	(*IoUsage)(nil),           // 12: proto.IoUsage
+	(*NetworkUsage)(nil),      // 13: proto.NetworkUsage
+	(*UsageResponse)(nil),     // 14: proto.UsageResponse
+	(*PressureStats)(nil),     // 15: proto.PressureStats
+	(*Pressure)(nil),          // 16: proto.Pressure
+	(*OutputResponse)(nil),    // 17: proto.OutputResponse
+	nil,                       // 18: proto.JobCreateRequest.EnvEntry
+	nil,                       // 19: proto.JobCreateRequest.RlimitsEntry
+	nil,                       // 20: proto.MemoryUsage.StatEntry
 }
 var file_pkg_proto_jobWorker_proto_depIdxs = []int32{
-	0, // 0: proto.JobStatusResponse.status:type_name -> proto.Status
-	1, // 1: proto.JobWorker.Start:input_type -> proto.JobCreateRequest
-	2, // 2: proto.JobWorker.Status:input_type -> proto.JobRequest
-	2, // 3: proto.JobWorker.Stream:input_type -> proto.JobRequest
-	2, // 4: proto.JobWorker.Stop:input_type -> proto.JobRequest
-	3, // 5: proto.JobWorker.Start:output_type -> proto.JobResponse
-	4, // 6: proto.JobWorker.Status:output_type -> proto.JobStatusResponse
-	5, // 7: proto.JobWorker.Stream:output_type -> proto.OutputResponse
-	4, // 8: proto.JobWorker.Stop:output_type -> proto.JobStatusResponse
-	5, // [5:9] is the sub-list for method output_type
-	1, // [1:5] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	5,  // 0: proto.JobCreateRequest.PressureTriggers:type_name -> proto.PressureTrigger
+	4,  // 1: proto.JobCreateRequest.Volumes:type_name -> proto.Volume
+	18, // 2: proto.JobCreateRequest.Env:type_name -> proto.JobCreateRequest.EnvEntry
+	19, // 3: proto.JobCreateRequest.Rlimits:type_name -> proto.JobCreateRequest.RlimitsEntry
+	2,  // 4: proto.JobCreateRequest.PortMappings:type_name -> proto.PortMapping
+	0,  // 5: proto.JobStatusResponse.status:type_name -> proto.Status
+	20, // 6: proto.MemoryUsage.stat:type_name -> proto.MemoryUsage.StatEntry
+	0,  // 7: proto.UsageResponse.status:type_name -> proto.Status
+	10, // 8: proto.UsageResponse.cpu:type_name -> proto.CpuUsage
+	11, // 9: proto.UsageResponse.memory:type_name -> proto.MemoryUsage
+	12, // 10: proto.UsageResponse.io:type_name -> proto.IoUsage
+	16, // 11: proto.UsageResponse.cpuPressure:type_name -> proto.Pressure
+	16, // 12: proto.UsageResponse.memoryPressure:type_name -> proto.Pressure
+	16, // 13: proto.UsageResponse.ioPressure:type_name -> proto.Pressure
+	13, // 14: proto.UsageResponse.network:type_name -> proto.NetworkUsage
+	15, // 15: proto.Pressure.some:type_name -> proto.PressureStats
+	15, // 16: proto.Pressure.full:type_name -> proto.PressureStats
+	3,  // 17: proto.JobCreateRequest.RlimitsEntry.value:type_name -> proto.Rlimit
+	1,  // 18: proto.JobWorker.Start:input_type -> proto.JobCreateRequest
+	6,  // 19: proto.JobWorker.Status:input_type -> proto.JobRequest
+	6,  // 20: proto.JobWorker.Stream:input_type -> proto.JobRequest
+	7,  // 21: proto.JobWorker.Stop:input_type -> proto.StopRequest
+	6,  // 22: proto.JobWorker.Usage:input_type -> proto.JobRequest
+	6,  // 23: proto.JobWorker.ExportRootFSChanges:input_type -> proto.JobRequest
+	6,  // 24: proto.JobWorker.RemoveRootFSChanges:input_type -> proto.JobRequest
+	8,  // 25: proto.JobWorker.Start:output_type -> proto.JobResponse
+	9,  // 26: proto.JobWorker.Status:output_type -> proto.JobStatusResponse
+	17, // 27: proto.JobWorker.Stream:output_type -> proto.OutputResponse
+	9,  // 28: proto.JobWorker.Stop:output_type -> proto.JobStatusResponse
+	14, // 29: proto.JobWorker.Usage:output_type -> proto.UsageResponse
+	17, // 30: proto.JobWorker.ExportRootFSChanges:output_type -> proto.OutputResponse
+	8,  // 31: proto.JobWorker.RemoveRootFSChanges:output_type -> proto.JobResponse
+	25, // [25:32] is the sub-list for method output_type
+	18, // [18:25] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
 }
 
 func init() { file_pkg_proto_jobWorker_proto_init() }
@@ -477,7 +2068,7 @@ func file_pkg_proto_jobWorker_proto_init() {
 			}
 		}
 		file_pkg_proto_jobWorker_proto_msgTypes[1].Exporter = func(v any, i int) any {
-			switch v := v.(*JobRequest); i {
+			switch v := v.(*PortMapping); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -489,7 +2080,7 @@ func file_pkg_proto_jobWorker_proto_init() {
 			}
 		}
 		file_pkg_proto_jobWorker_proto_msgTypes[2].Exporter = func(v any, i int) any {
-			switch v := v.(*JobResponse); i {
+			switch v := v.(*Rlimit); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -501,7 +2092,7 @@ func file_pkg_proto_jobWorker_proto_init() {
 			}
 		}
 		file_pkg_proto_jobWorker_proto_msgTypes[3].Exporter = func(v any, i int) any {
-			switch v := v.(*JobStatusResponse); i {
+			switch v := v.(*Volume); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -513,6 +2104,150 @@ func file_pkg_proto_jobWorker_proto_init() {
 			}
 		}
 		file_pkg_proto_jobWorker_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*PressureTrigger); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_proto_jobWorker_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*JobRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_proto_jobWorker_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*StopRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_proto_jobWorker_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*JobResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_proto_jobWorker_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*JobStatusResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_proto_jobWorker_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*CpuUsage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_proto_jobWorker_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*MemoryUsage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_proto_jobWorker_proto_msgTypes[11].Exporter = func(v any, i int) any {
+			switch v := v.(*IoUsage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_proto_jobWorker_proto_msgTypes[12].Exporter = func(v any, i int) any {
+			switch v := v.(*NetworkUsage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_proto_jobWorker_proto_msgTypes[13].Exporter = func(v any, i int) any {
+			switch v := v.(*UsageResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_proto_jobWorker_proto_msgTypes[14].Exporter = func(v any, i int) any {
+			switch v := v.(*PressureStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_proto_jobWorker_proto_msgTypes[15].Exporter = func(v any, i int) any {
+			switch v := v.(*Pressure); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_proto_jobWorker_proto_msgTypes[16].Exporter = func(v any, i int) any {
 			switch v := v.(*OutputResponse); i {
 			case 0:
 				return &v.state
@@ -525,13 +2260,14 @@ func file_pkg_proto_jobWorker_proto_init() {
 			}
 		}
 	}
+	file_pkg_proto_jobWorker_proto_msgTypes[0].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_pkg_proto_jobWorker_proto_rawDesc,
 			NumEnums:      1,
-			NumMessages:   5,
+			NumMessages:   20,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,10 +19,13 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	JobWorker_Start_FullMethodName  = "/proto.JobWorker/Start"
-	JobWorker_Status_FullMethodName = "/proto.JobWorker/Status"
-	JobWorker_Stream_FullMethodName = "/proto.JobWorker/Stream"
-	JobWorker_Stop_FullMethodName   = "/proto.JobWorker/Stop"
+	JobWorker_Start_FullMethodName               = "/proto.JobWorker/Start"
+	JobWorker_Status_FullMethodName              = "/proto.JobWorker/Status"
+	JobWorker_Stream_FullMethodName              = "/proto.JobWorker/Stream"
+	JobWorker_Stop_FullMethodName                = "/proto.JobWorker/Stop"
+	JobWorker_Usage_FullMethodName               = "/proto.JobWorker/Usage"
+	JobWorker_ExportRootFSChanges_FullMethodName = "/proto.JobWorker/ExportRootFSChanges"
+	JobWorker_RemoveRootFSChanges_FullMethodName = "/proto.JobWorker/RemoveRootFSChanges"
 )
 
 // JobWorkerClient is the client API for JobWorker service.
@@ -32,7 +35,10 @@ type JobWorkerClient interface {
 	Start(ctx context.Context, in *JobCreateRequest, opts ...grpc.CallOption) (*JobResponse, error)
 	Status(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*JobStatusResponse, error)
 	Stream(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OutputResponse], error)
-	Stop(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*JobStatusResponse, error)
+	Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*JobStatusResponse, error)
+	Usage(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*UsageResponse, error)
+	ExportRootFSChanges(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OutputResponse], error)
+	RemoveRootFSChanges(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*JobResponse, error)
 }
 
 type jobWorkerClient struct {
@@ -82,7 +88,7 @@ func (c *jobWorkerClient) Stream(ctx context.Context, in *JobRequest, opts ...gr
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type JobWorker_StreamClient = grpc.ServerStreamingClient[OutputResponse]
 
-func (c *jobWorkerClient) Stop(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*JobStatusResponse, error) {
+func (c *jobWorkerClient) Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*JobStatusResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(JobStatusResponse)
 	err := c.cc.Invoke(ctx, JobWorker_Stop_FullMethodName, in, out, cOpts...)
@@ -92,6 +98,45 @@ func (c *jobWorkerClient) Stop(ctx context.Context, in *JobRequest, opts ...grpc
 	return out, nil
 }
 
+func (c *jobWorkerClient) Usage(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*UsageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UsageResponse)
+	err := c.cc.Invoke(ctx, JobWorker_Usage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobWorkerClient) ExportRootFSChanges(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OutputResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &JobWorker_ServiceDesc.Streams[1], JobWorker_ExportRootFSChanges_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[JobRequest, OutputResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JobWorker_ExportRootFSChangesClient = grpc.ServerStreamingClient[OutputResponse]
+
+func (c *jobWorkerClient) RemoveRootFSChanges(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*JobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JobResponse)
+	err := c.cc.Invoke(ctx, JobWorker_RemoveRootFSChanges_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // JobWorkerServer is the server API for JobWorker service.
 // All implementations should embed UnimplementedJobWorkerServer
 // for forward compatibility.
@@ -99,7 +144,10 @@ type JobWorkerServer interface {
 	Start(context.Context, *JobCreateRequest) (*JobResponse, error)
 	Status(context.Context, *JobRequest) (*JobStatusResponse, error)
 	Stream(*JobRequest, grpc.ServerStreamingServer[OutputResponse]) error
-	Stop(context.Context, *JobRequest) (*JobStatusResponse, error)
+	Stop(context.Context, *StopRequest) (*JobStatusResponse, error)
+	Usage(context.Context, *JobRequest) (*UsageResponse, error)
+	ExportRootFSChanges(*JobRequest, grpc.ServerStreamingServer[OutputResponse]) error
+	RemoveRootFSChanges(context.Context, *JobRequest) (*JobResponse, error)
 }
 
 // UnimplementedJobWorkerServer should be embedded to have
@@ -118,9 +166,18 @@ func (UnimplementedJobWorkerServer) Status(context.Context, *JobRequest) (*JobSt
 func (UnimplementedJobWorkerServer) Stream(*JobRequest, grpc.ServerStreamingServer[OutputResponse]) error {
 	return status.Errorf(codes.Unimplemented, "method Stream not implemented")
 }
-func (UnimplementedJobWorkerServer) Stop(context.Context, *JobRequest) (*JobStatusResponse, error) {
+func (UnimplementedJobWorkerServer) Stop(context.Context, *StopRequest) (*JobStatusResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Stop not implemented")
 }
+func (UnimplementedJobWorkerServer) Usage(context.Context, *JobRequest) (*UsageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Usage not implemented")
+}
+func (UnimplementedJobWorkerServer) ExportRootFSChanges(*JobRequest, grpc.ServerStreamingServer[OutputResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportRootFSChanges not implemented")
+}
+func (UnimplementedJobWorkerServer) RemoveRootFSChanges(context.Context, *JobRequest) (*JobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveRootFSChanges not implemented")
+}
 func (UnimplementedJobWorkerServer) testEmbeddedByValue() {}
 
 // UnsafeJobWorkerServer may be embedded to opt out of forward compatibility for this service.
@@ -189,7 +246,7 @@ func _JobWorker_Stream_Handler(srv interface{}, stream grpc.ServerStream) error
 type JobWorker_StreamServer = grpc.ServerStreamingServer[OutputResponse]
 
 func _JobWorker_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(JobRequest)
+	in := new(StopRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -201,7 +258,54 @@ func _JobWorker_Stop_Handler(srv interface{}, ctx context.Context, dec func(inte
 		FullMethod: JobWorker_Stop_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(JobWorkerServer).Stop(ctx, req.(*JobRequest))
+		return srv.(JobWorkerServer).Stop(ctx, req.(*StopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobWorker_Usage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobWorkerServer).Usage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JobWorker_Usage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobWorkerServer).Usage(ctx, req.(*JobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobWorker_ExportRootFSChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(JobRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(JobWorkerServer).ExportRootFSChanges(m, &grpc.GenericServerStream[JobRequest, OutputResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JobWorker_ExportRootFSChangesServer = grpc.ServerStreamingServer[OutputResponse]
+
+func _JobWorker_RemoveRootFSChanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobWorkerServer).RemoveRootFSChanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JobWorker_RemoveRootFSChanges_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobWorkerServer).RemoveRootFSChanges(ctx, req.(*JobRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -225,6 +329,14 @@ var JobWorker_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Stop",
 			Handler:    _JobWorker_Stop_Handler,
 		},
+		{
+			MethodName: "Usage",
+			Handler:    _JobWorker_Usage_Handler,
+		},
+		{
+			MethodName: "RemoveRootFSChanges",
+			Handler:    _JobWorker_RemoveRootFSChanges_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -232,6 +344,11 @@ var JobWorker_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _JobWorker_Stream_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "ExportRootFSChanges",
+			Handler:       _JobWorker_ExportRootFSChanges_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "pkg/proto/jobWorker.proto",
 }
@@ -5,16 +5,23 @@ import (
 	"errors"
 	"fmt"
 	"github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker"
+	ns "github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker/namespaces"
 	"github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/proto"
 	"github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/tls"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"io"
+	"math"
 	"sync"
+	"time"
 )
 
 var (
-	ErrJobNotFound   = errors.New("job not found")
-	ErrNotAuthorized = errors.New("user is not authorized to access job")
+	ErrJobNotFound        = errors.New("job not found")
+	ErrNotAuthorized      = errors.New("user is not authorized to access job")
+	ErrInvalidPort        = errors.New("port must be between 1 and 65535")
+	ErrInvalidGracePeriod = errors.New("grace period must not be negative")
 )
 
 type userJob struct {
@@ -24,12 +31,14 @@ type userJob struct {
 
 type JobWorkerServer struct {
 	userJobs map[string]userJob
+	policy   *Policy
 	mutex    sync.RWMutex
 }
 
-func NewJobWorkerServer() *JobWorkerServer {
+func NewJobWorkerServer(policy *Policy) *JobWorkerServer {
 	return &JobWorkerServer{
 		userJobs: map[string]userJob{},
+		policy:   policy,
 	}
 }
 
@@ -40,19 +49,122 @@ func (s *JobWorkerServer) Start(ctx context.Context, request *proto.JobCreateReq
 		return nil, fmt.Errorf("failed to get user from certificate: %w", err)
 	}
 
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
 	config := jobWorker.JobConfig{
-		CPU:              request.CPU,
-		IOBytesPerSecond: request.IoBytesPerSecond,
-		MemBytes:         request.MemBytes,
-		Command:          request.GetCommand(),
-		Arguments:        request.GetArgs(),
+		CPU:                 request.CPU,
+		IOBytesPerSecond:    request.IoBytesPerSecond,
+		MemBytes:            request.MemBytes,
+		Command:             request.GetCommand(),
+		Arguments:           request.GetArgs(),
+		StopGracePeriod:     time.Duration(request.GetStopGracePeriodMs()) * time.Millisecond,
+		ReadBytesPerSecond:  request.GetReadBytesPerSecond(),
+		WriteBytesPerSecond: request.GetWriteBytesPerSecond(),
+		ReadIOPerSecond:     request.GetReadIoPerSecond(),
+		WriteIOPerSecond:    request.GetWriteIoPerSecond(),
+		IODevicePath:        request.GetIoDevicePath(),
+		MemMaxBytes:         request.GetMemMaxBytes(),
+		SwapMaxBytes:        request.GetSwapMaxBytes(),
+		CPUWeight:           request.GetCpuWeight(),
+		CPUSetCPUs:          request.GetCpusetCpus(),
+		CPUSetMems:          request.GetCpusetMems(),
+		MaxProcesses:        request.GetMaxProcesses(),
+		RootFS:              request.GetRootFS(),
+		RootFSOverlay:       request.GetRootFSOverlay(),
+		KeepRootFSChanges:   request.GetKeepRootFSChanges(),
+		Image:               request.GetImage(),
+		Env:                 request.GetEnv(),
+		ClearEnv:            request.ClearEnv,
+		WorkingDir:          request.GetWorkingDir(),
+		SeccompProfile:      request.GetSeccompProfile(),
+		AddCapabilities:     request.GetAddCapabilities(),
+		DropCapabilities:    request.GetDropCapabilities(),
+		NoNewPrivs:          request.NoNewPrivs,
+		NetworkMode:         request.GetNetworkMode(),
+
+		NetworkIngressBytesPerSecond: request.GetNetworkIngressBytesPerSecond(),
+		NetworkEgressBytesPerSecond:  request.GetNetworkEgressBytesPerSecond(),
+		Hostname:                     request.GetHostname(),
+		DomainName:                   request.GetDomainName(),
+		ShmSizeBytes:                 request.GetShmSizeBytes(),
+		DelegateCGroup:               request.GetDelegateCGroup(),
+	}
+
+	if err = s.policy.checkEnv(&config); err != nil {
+		return nil, err
+	}
+
+	if err = s.policy.checkRootFS(&config); err != nil {
+		return nil, err
+	}
+
+	if err = s.policy.checkCredential(user, &config, request.Uid, request.Gid, request.GetGroups()); err != nil {
+		return nil, err
+	}
+
+	if err = s.policy.checkCapabilities(user, &config); err != nil {
+		return nil, err
+	}
+
+	if err = s.policy.checkSeccompProfile(&config); err != nil {
+		return nil, err
+	}
+
+	for name, rlimit := range request.GetRlimits() {
+		if config.Rlimits == nil {
+			config.Rlimits = map[string]ns.Rlimit{}
+		}
+		config.Rlimits[name] = ns.Rlimit{Soft: rlimit.GetSoft(), Hard: rlimit.GetHard()}
+	}
+	if err = s.policy.checkRlimits(&config); err != nil {
+		return nil, err
+	}
+
+	for _, trigger := range request.GetPressureTriggers() {
+		config.PressureTriggers = append(config.PressureTriggers, ns.PressureTrigger{
+			Resource:  trigger.GetResource(),
+			Full:      trigger.GetFull(),
+			Threshold: time.Duration(trigger.GetThresholdMs()) * time.Millisecond,
+			Window:    time.Duration(trigger.GetWindowMs()) * time.Millisecond,
+		})
+	}
+
+	for _, mapping := range request.GetPortMappings() {
+		if mapping.GetHostPort() > math.MaxUint16 || mapping.GetContainerPort() > math.MaxUint16 {
+			return nil, ErrInvalidPort
+		}
+		config.PortMappings = append(config.PortMappings, ns.PortMapping{
+			Protocol:      mapping.GetProtocol(),
+			HostPort:      uint16(mapping.GetHostPort()),
+			ContainerPort: uint16(mapping.GetContainerPort()),
+		})
+	}
+	if err = s.policy.checkPortMappings(user, &config); err != nil {
+		return nil, err
+	}
+
+	for _, volume := range request.GetVolumes() {
+		jobVolume := ns.Volume{
+			Type:      volume.GetType(),
+			Source:    volume.GetSource(),
+			Target:    volume.GetTarget(),
+			ReadOnly:  volume.GetReadOnly(),
+			SizeBytes: volume.GetSizeBytes(),
+		}
+		if err = s.policy.checkVolume(&jobVolume); err != nil {
+			return nil, err
+		}
+		config.Volumes = append(config.Volumes, jobVolume)
 	}
 
 	newJob := jobWorker.NewJob(&config)
 
+	// unpacking tarballs and images takes long, so it is done before taking the lock all requests of all users need
+	if err = newJob.PrepareRootFS(); err != nil {
+		return nil, fmt.Errorf("error starting job: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	s.userJobs[newJob.UUID.String()] = userJob{
 		user: user,
 		job:  newJob,
@@ -87,22 +199,16 @@ func (s *JobWorkerServer) Status(ctx context.Context, request *proto.JobRequest)
 		return nil, ErrNotAuthorized
 	}
 
-	jobStatus := job.job.Status()
-
-	return &proto.JobStatusResponse{
-		Status:     convertJobStateToStatus(jobStatus.State),
-		ExitCode:   int32(jobStatus.ExitCode),
-		ExitReason: jobStatus.ExitReason,
-	}, nil
+	return convertJobStatus(job.job.Status()), nil
 }
 
 func (s *JobWorkerServer) Stream(request *proto.JobRequest, stream grpc.ServerStreamingServer[proto.OutputResponse]) error {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
-
 	jobID := request.GetId()
 
+	// the output is streamed until the job exits, so the job is streamed without holding the lock all requests need
+	s.mutex.RLock()
 	job, ok := s.userJobs[jobID]
+	s.mutex.RUnlock()
 	if !ok {
 		return ErrJobNotFound
 	}
@@ -142,7 +248,82 @@ func (s *JobWorkerServer) Stream(request *proto.JobRequest, stream grpc.ServerSt
 	return nil
 }
 
-func (s *JobWorkerServer) Stop(ctx context.Context, request *proto.JobRequest) (*proto.JobStatusResponse, error) {
+// ExportRootFSChanges streams the files a completed job has changed in its root filesystem as a tar layer.
+func (s *JobWorkerServer) ExportRootFSChanges(request *proto.JobRequest, stream grpc.ServerStreamingServer[proto.OutputResponse]) error {
+	jobID := request.GetId()
+
+	// exporting large changes takes long, so the job is streamed without holding the lock all requests of all users need
+	s.mutex.RLock()
+	job, ok := s.userJobs[jobID]
+	s.mutex.RUnlock()
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	user, err := tls.GetUserFromContext(stream.Context())
+	if err != nil {
+		return fmt.Errorf("failed to get user from certificate: %w", err)
+	}
+
+	if user != job.user {
+		// TODO: In production to prevent analyze security vulnerabilities
+		// 		 better to returning Not Found instead of Permission Denied to hide job existence
+		return ErrNotAuthorized
+	}
+
+	if err = job.job.ExportRootFSChanges(&streamWriter{stream: stream}); err != nil {
+		return fmt.Errorf("error exporting rootfs changes: %w", err)
+	}
+	return nil
+}
+
+// RemoveRootFSChanges removes the changes a completed job has kept for export, before they expire.
+func (s *JobWorkerServer) RemoveRootFSChanges(ctx context.Context, request *proto.JobRequest) (*proto.JobResponse, error) {
+	jobID := request.GetId()
+
+	s.mutex.RLock()
+	job, ok := s.userJobs[jobID]
+	s.mutex.RUnlock()
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	user, err := tls.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from certificate: %w", err)
+	}
+
+	if user != job.user {
+		// TODO: In production to prevent analyze security vulnerabilities
+		// 		 better to returning Not Found instead of Permission Denied to hide job existence
+		return nil, ErrNotAuthorized
+	}
+
+	if err = job.job.RemoveRootFSChanges(); err != nil {
+		return nil, fmt.Errorf("error removing rootfs changes: %w", err)
+	}
+	return &proto.JobResponse{Id: jobID}, nil
+}
+
+// streamWriter sends everything written to it as OutputResponse
+type streamWriter struct {
+	stream grpc.ServerStreamingServer[proto.OutputResponse]
+}
+
+func (writer *streamWriter) Write(content []byte) (int, error) {
+	if err := writer.stream.Send(&proto.OutputResponse{Content: content}); err != nil {
+		return 0, err
+	}
+	return len(content), nil
+}
+
+// Stop sends SIGTERM to the job and returns the job's status without waiting for the job to exit.
+// A negative grace period is rejected with codes.InvalidArgument.
+func (s *JobWorkerServer) Stop(ctx context.Context, request *proto.StopRequest) (*proto.JobStatusResponse, error) {
+	if request.GetGracePeriodMs() < 0 {
+		return nil, status.Error(codes.InvalidArgument, ErrInvalidGracePeriod.Error())
+	}
+
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
@@ -164,18 +345,126 @@ func (s *JobWorkerServer) Stop(ctx context.Context, request *proto.JobRequest) (
 		return nil, ErrNotAuthorized
 	}
 
-	if err := job.job.Stop(); err != nil {
+	if request.GetGracePeriodMs() > 0 {
+		err = job.job.StopWithGracePeriod(time.Duration(request.GetGracePeriodMs()) * time.Millisecond)
+	} else {
+		err = job.job.Stop()
+	}
+	if err != nil {
 		return nil, fmt.Errorf("error stopping job: %w", err)
 	}
 
+	return convertJobStatus(job.job.Status()), nil
+}
+
+// Usage returns resource usage of the job, live while the job is running or the final usage once it has completed.
+func (s *JobWorkerServer) Usage(ctx context.Context, request *proto.JobRequest) (*proto.UsageResponse, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	jobID := request.GetId()
+
+	job, ok := s.userJobs[jobID]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	user, err := tls.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from certificate: %w", err)
+	}
+
+	if user != job.user {
+		// TODO: In production to prevent analyze security vulnerabilities
+		// 		 better to returning Not Found instead of Permission Denied to hide job existence
+		return nil, ErrNotAuthorized
+	}
+
+	// read status first, so that the usage of a job completed in between is the final one
 	jobStatus := job.job.Status()
 
-	return &proto.JobStatusResponse{
-		Status:     convertJobStateToStatus(jobStatus.State),
-		ExitCode:   int32(jobStatus.ExitCode),
-		ExitReason: jobStatus.ExitReason,
-	}, nil
+	usage, err := job.job.Usage()
+	if err != nil {
+		return nil, fmt.Errorf("error reading job usage: %w", err)
+	}
 
+	return convertUsage(jobStatus, usage), nil
+}
+
+func convertUsage(jobStatus *jobWorker.JobStatus, usage *ns.Usage) *proto.UsageResponse {
+	response := &proto.UsageResponse{
+		Status: convertJobStateToStatus(jobStatus.State),
+		Cpu: &proto.CpuUsage{
+			UsageUsec:     usage.CPU.UsageUsec,
+			UserUsec:      usage.CPU.UserUsec,
+			SystemUsec:    usage.CPU.SystemUsec,
+			NrPeriods:     usage.CPU.NrPeriods,
+			NrThrottled:   usage.CPU.NrThrottled,
+			ThrottledUsec: usage.CPU.ThrottledUsec,
+		},
+		Memory: &proto.MemoryUsage{
+			CurrentBytes: usage.Memory.Current,
+			PeakBytes:    usage.Memory.Peak,
+			Stat:         usage.Memory.Stat,
+		},
+		PidsCurrent:    usage.Pids.Current,
+		PidsPeak:       usage.Pids.Peak,
+		CpuPressure:    convertPressure(usage.CPUPressure),
+		MemoryPressure: convertPressure(usage.MemoryPressure),
+		IoPressure:     convertPressure(usage.IOPressure),
+		Network: &proto.NetworkUsage{
+			RxBytes:   usage.Network.RxBytes,
+			RxPackets: usage.Network.RxPackets,
+			TxBytes:   usage.Network.TxBytes,
+			TxPackets: usage.Network.TxPackets,
+			RxDropped: usage.Network.RxDropped,
+			TxDropped: usage.Network.TxDropped,
+		},
+	}
+
+	for _, ioStats := range usage.IO {
+		response.Io = append(response.Io, &proto.IoUsage{
+			Device:     ioStats.Device,
+			ReadBytes:  ioStats.ReadBytes,
+			WriteBytes: ioStats.WriteBytes,
+			ReadIos:    ioStats.ReadIOs,
+			WriteIos:   ioStats.WriteIOs,
+		})
+	}
+
+	return response
+}
+
+func convertPressure(pressure ns.Pressure) *proto.Pressure {
+	convertStats := func(stats ns.PressureStats) *proto.PressureStats {
+		return &proto.PressureStats{
+			Avg10:     stats.Avg10,
+			Avg60:     stats.Avg60,
+			Avg300:    stats.Avg300,
+			TotalUsec: stats.TotalUsec,
+		}
+	}
+
+	return &proto.Pressure{
+		Some: convertStats(pressure.Some),
+		Full: convertStats(pressure.Full),
+	}
+}
+
+func convertJobStatus(jobStatus *jobWorker.JobStatus) *proto.JobStatusResponse {
+	return &proto.JobStatusResponse{
+		Status:           convertJobStateToStatus(jobStatus.State),
+		ExitCode:         int32(jobStatus.ExitCode),
+		ExitReason:       jobStatus.ExitReason,
+		OomKilled:        jobStatus.OOMKilled,
+		CpuLimitExceeded: jobStatus.CPULimitExceeded,
+		IpAddress:        jobStatus.IPAddress,
+		PidsCurrent:      jobStatus.PidsCurrent,
+		PidsPeak:         jobStatus.PidsPeak,
+		PidsLimitReached: jobStatus.PidsLimitReached,
+		CpuUsageUsec:     jobStatus.CPUTime.Microseconds(),
+		MemoryPeakBytes:  jobStatus.MemoryPeakBytes,
+	}
 }
 
 func convertJobStateToStatus(state jobWorker.State) proto.Status {
@@ -184,6 +473,8 @@ func convertJobStateToStatus(state jobWorker.State) proto.Status {
 		return proto.Status_NOT_STARTED
 	case jobWorker.JobStatusRunning:
 		return proto.Status_RUNNING
+	case jobWorker.JobStatusStopping:
+		return proto.Status_STOPPING
 	case jobWorker.JobStatusCompleted:
 		return proto.Status_COMPLETED
 	case jobWorker.JobStatusTerminated:
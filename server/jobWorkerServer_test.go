@@ -0,0 +1,19 @@
+package main
+
+import (
+	"context"
+	"github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func Test_JobWorkerServer_Stop_negative_grace_period_expected_InvalidArgument(t *testing.T) {
+	t.Parallel()
+
+	server := NewJobWorkerServer(&Policy{})
+	_, err := server.Stop(context.Background(), &proto.StopRequest{Id: "job", GracePeriodMs: -1})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected code %s, got %v", codes.InvalidArgument, err)
+	}
+}
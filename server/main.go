@@ -6,6 +6,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker"
 	"github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/proto"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -19,6 +20,9 @@ var (
 )
 
 func main() {
+	// jobs are started by running the server binary again as init shim inside the job's namespaces
+	jobWorker.Init()
+
 	port := flag.Int("port", 8080, "the server port")
 
 	pwd, err := os.Getwd()
@@ -34,6 +38,19 @@ func main() {
 	pemClientCACertificate := flag.String("client-ca-cert", pathCACert, "the client CA certificate")
 	pemServerCertificate := flag.String("server-cert", pathServerCert, "the server certificate")
 	pemServerPrivateKey := flag.String("server-key", pathServerPrivateKey, "the server private key")
+	policyPath := flag.String("policy", "", "the JSON file of the policy enforced on all jobs, such as allowed mount sources")
+	flag.StringVar(&jobWorker.StateDir, "state-dir", jobWorker.StateDir, "the directory jobs' state such as unpacked root filesystems is kept in")
+	flag.DurationVar(&jobWorker.RootFSChangesTTL, "changes-ttl", jobWorker.RootFSChangesTTL, "how long the root filesystem changes of completed jobs with keep-changes are kept for export")
+	flag.IntVar(&jobWorker.MaxUnusedLayers, "max-unused-layers", jobWorker.MaxUnusedLayers, "the number of unpacked tarballs, images and image archives no job uses kept in the state directory for later jobs")
+	flag.StringVar(&jobWorker.ImageDir, "image-dir", jobWorker.ImageDir, "the directory of OCI image layouts and docker save tarballs jobs can run in")
+	flag.StringVar(&jobWorker.SeccompProfileDir, "seccomp-dir", jobWorker.SeccompProfileDir, "the directory of custom seccomp profiles in Docker's JSON format")
+	flag.StringVar(&jobWorker.BridgeName, "bridge-name", jobWorker.BridgeName, "the bridge jobs with network mode bridge are connected to, created if it doesn't exist")
+	flag.StringVar(&jobWorker.BridgeSubnet, "bridge-subnet", jobWorker.BridgeSubnet, "the IPv4 subnet of the bridge addresses of jobs are allocated from")
+	flag.BoolVar(&jobWorker.BridgeNAT, "bridge-nat", jobWorker.BridgeNAT, "masquerade traffic of jobs on the bridge leaving the server, so that jobs can reach other networks")
+	flag.StringVar(&jobWorker.CGroupParent, "cgroup-parent", jobWorker.CGroupParent, "the cgroup all jobs' cgroups are created in, created if it doesn't exist")
+	flag.Float64Var(&jobWorker.CGroupCPU, "cgroup-cpu", jobWorker.CGroupCPU, "the number of CPU cores all jobs can use together, unlimited if 0")
+	flag.Int64Var(&jobWorker.CGroupMemMaxBytes, "cgroup-memory-max", jobWorker.CGroupMemMaxBytes, "the hard limit of memory of all jobs together in bytes, unlimited if 0")
+	flag.Int64Var(&jobWorker.CGroupMaxProcesses, "cgroup-max-processes", jobWorker.CGroupMaxProcesses, "the maximum number of processes and threads of all jobs together, unlimited if 0")
 
 	flag.Parse()
 	log.Printf("start server on port: %d", *port)
@@ -43,8 +60,21 @@ func main() {
 		log.Fatalf("failed to load TLS credentials: %v", err)
 	}
 
+	policy, err := LoadPolicy(*policyPath)
+	if err != nil {
+		log.Fatalf("failed to load policy: %v", err)
+	}
+	policy.listenPort = uint16(*port)
+	if err = checkDefaultID(); err != nil {
+		log.Fatalf("failed to load policy: %v", err)
+	}
+
+	if err = jobWorker.SetupCGroupParent(); err != nil {
+		log.Fatalf("failed to set up cgroup parent: %v", err)
+	}
+
 	serviceRegistrar := grpc.NewServer(grpc.Creds(tlsCredentials))
-	server := NewJobWorkerServer()
+	server := NewJobWorkerServer(policy)
 	proto.RegisterJobWorkerServer(serviceRegistrar, server)
 
 	address := fmt.Sprintf(":%d", *port)
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker"
+	ns "github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker/namespaces"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrMountNotAllowed      = errors.New("volume source is not allowed by the server policy")
+	ErrRootFSNotAllowed     = errors.New("rootfs is not allowed by the server policy")
+	ErrInheritEnvNotAllowed = errors.New("inheriting the server's environment is not allowed by the server policy")
+	ErrIDNotAllowed         = errors.New("UID or GID is not allowed for the user by the server policy")
+	ErrCapabilityNotAllowed = errors.New("capability is not allowed for the user by the server policy")
+	ErrNewPrivsNotAllowed   = errors.New("disabling NoNewPrivs is not allowed by the server policy")
+	ErrSeccompNotAllowed    = errors.New("seccomp profile is not allowed by the server policy")
+	ErrRlimitNotAllowed     = errors.New("rlimit is above the maximum allowed by the server policy")
+	ErrPortNotAllowed       = errors.New("host port is not allowed for the user by the server policy")
+)
+
+// Policy is the admin-configured policy the server enforces on jobs of all users, loaded from a JSON file such as:
+//
+//	{
+//	  "allowedMountSources": ["/data", "/scratch"],
+//	  "allowedRootFS": ["/srv/rootfs"],
+//	  "allowInheritEnv": false,
+//	  "allowNewPrivs": false,
+//	  "allowedSeccompProfiles": ["strict"],
+//	  "defaultRlimits": {"RLIMIT_NOFILE": {"soft": 1024, "hard": 4096}, "RLIMIT_CORE": {"soft": 0, "hard": 0}},
+//	  "maxRlimits": {"RLIMIT_NOFILE": 65536, "RLIMIT_CPU": 3600},
+//	  "users": {"client-1": {"uids": [1000], "gids": [1000, 100], "capabilities": ["CAP_NET_BIND_SERVICE"],
+//	    "hostPorts": {"min": 8000, "max": 8999}}}
+//	}
+type Policy struct {
+	// AllowedMountSources are host directories jobs can bind mount, including everything below them.
+	// Jobs can't bind mount any host path, if empty.
+	AllowedMountSources []string `json:"allowedMountSources"`
+	// AllowedRootFS are host directories and tarballs jobs can use as RootFS, including everything below them.
+	// Jobs can only run on the host's root filesystem or an image, if empty.
+	AllowedRootFS []string `json:"allowedRootFS"`
+	// AllowInheritEnv allows jobs to clear ClearEnv and inherit the server's environment, secrets included.
+	AllowInheritEnv bool `json:"allowInheritEnv"`
+	// AllowNewPrivs allows jobs to clear NoNewPrivs, so that setuid binaries can raise privileges in the job.
+	AllowNewPrivs bool `json:"allowNewPrivs"`
+	// AllowedSeccompProfiles are the seccomp profiles jobs can select besides the default profile, such as custom
+	// profiles of the server's seccomp directory. Jobs can only disable the filter, if it includes "permissive".
+	AllowedSeccompProfiles []string `json:"allowedSeccompProfiles"`
+	// DefaultRlimits are the resource limits of jobs not setting them, the server's own limits by default.
+	DefaultRlimits map[string]ns.Rlimit `json:"defaultRlimits"`
+	// MaxRlimits are the highest soft and hard values jobs can set resource limits to, jobs not setting
+	// a limit with a maximum and without a default run with the maximum.
+	MaxRlimits map[string]uint64 `json:"maxRlimits"`
+	// Users maps users of client certificates to the IDs and capabilities of their jobs in the jobs' user namespaces.
+	// Jobs of users not in Users run as nobody (65534) of their user namespace without capabilities.
+	Users map[string]UserPolicy `json:"users"`
+
+	// listenPort is the port of the server, which is never forwarded to a job
+	listenPort uint16
+}
+
+// PortRange is a range of ports from Min to Max including both
+type PortRange struct {
+	Min uint16 `json:"min"`
+	Max uint16 `json:"max"`
+}
+
+// defaultID is the UID and GID of jobs of users without UIDs or GIDs, nobody is mapped to an unprivileged
+// subordinate ID of the server's user, whereas root of a job's user namespace is the server's user itself
+const defaultID = 65534
+
+// checkDefaultID returns an error, if jobs can't run as defaultID, because the subordinate ID ranges of the server's
+// user don't include it
+func checkDefaultID() error {
+	if err := jobWorker.CheckIDMapped(defaultID, defaultID); err != nil {
+		return fmt.Errorf("jobs of users without UIDs or GIDs run as %d, which must be in the server user's ranges "+
+			"of %s and %s: %w", defaultID, jobWorker.SubUIDFile, jobWorker.SubGIDFile, err)
+	}
+	return nil
+}
+
+// defaultHostPorts are the host ports jobs of users without HostPorts can forward, privileged ports are excluded
+var defaultHostPorts = PortRange{Min: 1024, Max: 65535}
+
+// UserPolicy is what the jobs of a user are allowed to run as
+type UserPolicy struct {
+	// UIDs and GIDs, also used as supplementary groups, jobs can run as, the first ones are used if a job
+	// doesn't set them (nobody, 65534, of the job's user namespace if empty). Root of the job's user namespace is
+	// the server's user on the host, so it must be listed explicitly.
+	UIDs []uint32 `json:"uids"`
+	GIDs []uint32 `json:"gids"`
+	// Capabilities jobs can add, such as CAP_NET_BIND_SERVICE or ALL.
+	Capabilities []string `json:"capabilities"`
+	// HostPorts are the host ports jobs can forward to their ports, the server's port is never allowed
+	// (1024 to 65535 if not set).
+	HostPorts *PortRange `json:"hostPorts"`
+}
+
+// getUserPolicy returns the policy of user, jobs of users without a policy run as nobody without capabilities
+func (policy *Policy) getUserPolicy(user string) UserPolicy {
+	userPolicy := policy.Users[user]
+	if len(userPolicy.UIDs) == 0 {
+		userPolicy.UIDs = []uint32{defaultID}
+	}
+	if len(userPolicy.GIDs) == 0 {
+		userPolicy.GIDs = []uint32{defaultID}
+	}
+	if userPolicy.HostPorts == nil {
+		userPolicy.HostPorts = &defaultHostPorts
+	}
+	return userPolicy
+}
+
+// LoadPolicy reads the policy from a JSON file, an empty path returns the default policy
+func LoadPolicy(path string) (*Policy, error) {
+	policy := &Policy{}
+	if path == "" {
+		return policy, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	if err = json.Unmarshal(content, policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+
+	for user, userPolicy := range policy.Users {
+		if _, err = ns.GetCapabilities(userPolicy.Capabilities, nil); err != nil {
+			return nil, fmt.Errorf("failed to parse policy of user %s: %w", user, err)
+		}
+		if userPolicy.HostPorts != nil && (userPolicy.HostPorts.Min == 0 || userPolicy.HostPorts.Min > userPolicy.HostPorts.Max) {
+			return nil, fmt.Errorf("failed to parse policy of user %s: host ports must be a range from min to max", user)
+		}
+	}
+
+	if policy.DefaultRlimits, err = ns.NormalizeRlimits(policy.DefaultRlimits); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	maxRlimits := map[string]uint64{}
+	for name, value := range policy.MaxRlimits {
+		if name, err = ns.NormalizeRlimit(name); err != nil {
+			return nil, fmt.Errorf("failed to parse policy: %w", err)
+		}
+		maxRlimits[name] = value
+	}
+	policy.MaxRlimits = maxRlimits
+
+	if err = resolveAllowedPaths(policy.AllowedMountSources); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: allowed mount source %w", err)
+	}
+	if err = resolveAllowedPaths(policy.AllowedRootFS); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: allowed rootfs %w", err)
+	}
+	return policy, nil
+}
+
+// resolveAllowedPaths resolves symlinks of allowed paths in place the same way as the paths of jobs are resolved,
+// so that they can be compared
+func resolveAllowedPaths(paths []string) error {
+	for i, path := range paths {
+		if !filepath.IsAbs(path) {
+			return fmt.Errorf("%s must be an absolute path", path)
+		}
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			paths[i] = resolved
+		}
+	}
+	return nil
+}
+
+// getAllowedPath returns path with symlinks resolved, so that the job uses the checked path, and false, if the
+// resolved path doesn't exist or is not below any of allowed
+func getAllowedPath(path string, allowed []string) (string, bool) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", false
+	}
+
+	for _, allowedPath := range allowed {
+		if resolved == allowedPath || strings.HasPrefix(resolved, strings.TrimSuffix(allowedPath, "/")+"/") {
+			return resolved, true
+		}
+	}
+	return "", false
+}
+
+// checkVolume resolves symlinks of a bind mount source, so that the job mounts the checked path, and returns
+// ErrMountNotAllowed, if the resolved source is not below any of AllowedMountSources
+func (policy *Policy) checkVolume(volume *ns.Volume) error {
+	if volume.Type != ns.VolumeBind {
+		return nil
+	}
+	if !filepath.IsAbs(volume.Source) {
+		return ns.ErrInvalidVolume
+	}
+
+	source, ok := getAllowedPath(volume.Source, policy.AllowedMountSources)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrMountNotAllowed, volume.Source)
+	}
+	volume.Source = source
+	return nil
+}
+
+// checkRootFS resolves symlinks of RootFS, so that the job uses the checked directory or tarball, and returns
+// ErrRootFSNotAllowed, if the resolved RootFS is not below any of AllowedRootFS
+func (policy *Policy) checkRootFS(config *jobWorker.JobConfig) error {
+	if config.RootFS == "" {
+		return nil
+	}
+	if !filepath.IsAbs(config.RootFS) {
+		return jobWorker.ErrInvalidRootFS
+	}
+
+	rootfs, ok := getAllowedPath(config.RootFS, policy.AllowedRootFS)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrRootFSNotAllowed, config.RootFS)
+	}
+	config.RootFS = rootfs
+	return nil
+}
+
+// checkEnv returns ErrInheritEnvNotAllowed, if the job inherits the server's environment and the policy doesn't allow it
+func (policy *Policy) checkEnv(config *jobWorker.JobConfig) error {
+	if config.ClearEnv != nil && !*config.ClearEnv && !policy.AllowInheritEnv {
+		return ErrInheritEnvNotAllowed
+	}
+	return nil
+}
+
+// checkCredential sets UID, GID and Groups of the job to the given IDs or the first IDs allowed for the user,
+// if not given, and returns ErrIDNotAllowed, if any of them isn't allowed for the user
+func (policy *Policy) checkCredential(user string, config *jobWorker.JobConfig, uid *uint32, gid *uint32, groups []uint32) error {
+	ids := policy.getUserPolicy(user)
+
+	config.UID, config.GID, config.Groups = ids.UIDs[0], ids.GIDs[0], groups
+	if uid != nil {
+		config.UID = *uid
+	}
+	if gid != nil {
+		config.GID = *gid
+	}
+
+	if !slices.Contains(ids.UIDs, config.UID) {
+		return fmt.Errorf("%w: UID %d", ErrIDNotAllowed, config.UID)
+	}
+	for _, id := range append([]uint32{config.GID}, config.Groups...) {
+		if !slices.Contains(ids.GIDs, id) {
+			return fmt.Errorf("%w: GID %d", ErrIDNotAllowed, id)
+		}
+	}
+	return nil
+}
+
+// checkCapabilities returns ErrCapabilityNotAllowed, if the job adds a capability not allowed for the user,
+// and ErrNewPrivsNotAllowed, if the job clears NoNewPrivs and the policy doesn't allow it
+func (policy *Policy) checkCapabilities(user string, config *jobWorker.JobConfig) error {
+	if config.NoNewPrivs != nil && !*config.NoNewPrivs && !policy.AllowNewPrivs {
+		return ErrNewPrivsNotAllowed
+	}
+
+	capabilities, err := ns.GetCapabilities(config.AddCapabilities, config.DropCapabilities)
+	if err != nil {
+		return err
+	}
+	allowed, _ := ns.GetCapabilities(policy.getUserPolicy(user).Capabilities, nil)
+	for _, capability := range capabilities {
+		if !slices.Contains(allowed, capability) {
+			return fmt.Errorf("%w: %s", ErrCapabilityNotAllowed, capability)
+		}
+	}
+	return nil
+}
+
+// checkSeccompProfile returns ErrSeccompNotAllowed, if the job selects a seccomp profile other than the default one,
+// which is not in AllowedSeccompProfiles
+func (policy *Policy) checkSeccompProfile(config *jobWorker.JobConfig) error {
+	profile := config.SeccompProfile
+	if profile == "" || profile == jobWorker.SeccompProfileDefault || slices.Contains(policy.AllowedSeccompProfiles, profile) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrSeccompNotAllowed, profile)
+}
+
+// checkRlimits sets the resource limits the job doesn't set to the defaults of the policy or their maximum
+// and returns ErrRlimitNotAllowed, if any resource limit of the job is above its maximum
+func (policy *Policy) checkRlimits(config *jobWorker.JobConfig) error {
+	rlimits, err := ns.NormalizeRlimits(config.Rlimits)
+	if err != nil {
+		return err
+	}
+	if rlimits == nil {
+		rlimits = map[string]ns.Rlimit{}
+	}
+
+	for name, rlimit := range policy.DefaultRlimits {
+		if _, ok := rlimits[name]; !ok {
+			rlimits[name] = rlimit
+		}
+	}
+	for name, value := range policy.MaxRlimits {
+		rlimit, ok := rlimits[name]
+		if !ok {
+			rlimits[name] = ns.Rlimit{Soft: value, Hard: value}
+			continue
+		}
+		if rlimit.Soft > value || rlimit.Hard > value {
+			return fmt.Errorf("%w: %s %d", ErrRlimitNotAllowed, name, value)
+		}
+	}
+
+	if len(rlimits) > 0 {
+		config.Rlimits = rlimits
+	}
+	return nil
+}
+
+// checkPortMappings returns ErrPortNotAllowed, if the job forwards a host port outside of the HostPorts of the user
+// or the server's own port. Ports used by services of the host or other jobs are rejected by Start of the job,
+// which reserves the host ports by listening on them.
+func (policy *Policy) checkPortMappings(user string, config *jobWorker.JobConfig) error {
+	hostPorts := policy.getUserPolicy(user).HostPorts
+	for _, mapping := range config.PortMappings {
+		if mapping.HostPort < hostPorts.Min || mapping.HostPort > hostPorts.Max || mapping.HostPort == policy.listenPort {
+			return fmt.Errorf("%w: %d", ErrPortNotAllowed, mapping.HostPort)
+		}
+	}
+	return nil
+}
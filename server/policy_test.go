@@ -0,0 +1,344 @@
+package main
+
+import (
+	"errors"
+	"github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker"
+	ns "github.com/P-A-R-U-S/Go-Job-Worker-Service/pkg/jobWorker/namespaces"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func Test_Policy_checkVolume(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, dir := range []string{"data/set", "database", "etc"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatalf("could not create %s: %v", dir, err)
+		}
+	}
+	// a symlink inside an allowed directory must not give access outside of it
+	if err := os.Symlink(filepath.Join(root, "etc"), filepath.Join(root, "data/etc")); err != nil {
+		t.Fatalf("could not create symlink: %v", err)
+	}
+
+	policyFile := filepath.Join(root, "policy.json")
+	if err := os.WriteFile(policyFile, []byte(`{"allowedMountSources": ["`+root+`/data"]}`), 0o644); err != nil {
+		t.Fatalf("could not write policy: %v", err)
+	}
+	policy, err := LoadPolicy(policyFile)
+	if err != nil {
+		t.Fatalf("could not load policy: %v", err)
+	}
+
+	testCases := []struct {
+		volume    ns.Volume
+		isAllowed bool
+	}{
+		{volume: ns.Volume{Type: ns.VolumeBind, Source: root + "/data", Target: "/data"}, isAllowed: true},
+		{volume: ns.Volume{Type: ns.VolumeBind, Source: root + "/data/set", Target: "/data"}, isAllowed: true},
+		{volume: ns.Volume{Type: ns.VolumeTmpfs, Target: "/etc"}, isAllowed: true},
+		{volume: ns.Volume{Type: ns.VolumeBind, Source: root + "/database", Target: "/data"}},
+		{volume: ns.Volume{Type: ns.VolumeBind, Source: root + "/data/../etc", Target: "/data"}},
+		{volume: ns.Volume{Type: ns.VolumeBind, Source: root + "/data/etc", Target: "/data"}},
+		{volume: ns.Volume{Type: ns.VolumeBind, Source: root + "/data/missing", Target: "/data"}},
+	}
+
+	for _, testCase := range testCases {
+		err := policy.checkVolume(&testCase.volume)
+		if testCase.isAllowed && err != nil {
+			t.Errorf("volume:%s, expected to be allowed, got %v", &testCase.volume, err)
+		}
+		if !testCase.isAllowed && !errors.Is(err, ErrMountNotAllowed) {
+			t.Errorf("volume:%s, expected error(ErrMountNotAllowed), got %v", &testCase.volume, err)
+		}
+	}
+}
+
+func Test_Policy_checkRootFS(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, dir := range []string{"rootfs/alpine", "etc"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatalf("could not create %s: %v", dir, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "rootfs/alpine.tar"), nil, 0o644); err != nil {
+		t.Fatalf("could not create tarball: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "etc"), filepath.Join(root, "rootfs/etc")); err != nil {
+		t.Fatalf("could not create symlink: %v", err)
+	}
+
+	policy := &Policy{AllowedRootFS: []string{root + "/rootfs"}}
+	testCases := []struct {
+		rootfs    string
+		isAllowed bool
+	}{
+		{rootfs: "", isAllowed: true},
+		{rootfs: root + "/rootfs/alpine", isAllowed: true},
+		{rootfs: root + "/rootfs/alpine.tar", isAllowed: true},
+		{rootfs: root + "/etc"},
+		{rootfs: root + "/rootfs/etc"},
+		{rootfs: root + "/rootfs/../etc"},
+		{rootfs: "/"},
+	}
+
+	for _, testCase := range testCases {
+		config := jobWorker.JobConfig{RootFS: testCase.rootfs}
+		err := policy.checkRootFS(&config)
+		if testCase.isAllowed && err != nil {
+			t.Errorf("rootfs:%s, expected to be allowed, got %v", testCase.rootfs, err)
+		}
+		if !testCase.isAllowed && !errors.Is(err, ErrRootFSNotAllowed) {
+			t.Errorf("rootfs:%s, expected error(ErrRootFSNotAllowed), got %v", testCase.rootfs, err)
+		}
+	}
+
+	// the default policy doesn't allow any rootfs of the host
+	config := jobWorker.JobConfig{RootFS: root + "/rootfs/alpine"}
+	if err := (&Policy{}).checkRootFS(&config); !errors.Is(err, ErrRootFSNotAllowed) {
+		t.Errorf("expected error(ErrRootFSNotAllowed), got %v", err)
+	}
+}
+
+func Test_LoadPolicy_default_expected_no_mounts_allowed(t *testing.T) {
+	t.Parallel()
+
+	policy, err := LoadPolicy("")
+	if err != nil {
+		t.Fatalf("could not load policy: %v", err)
+	}
+
+	volume := ns.Volume{Type: ns.VolumeBind, Source: "/", Target: "/host"}
+	if err = policy.checkVolume(&volume); !errors.Is(err, ErrMountNotAllowed) {
+		t.Errorf("expected error(ErrMountNotAllowed), got %v", err)
+	}
+}
+
+func Test_Policy_checkEnv(t *testing.T) {
+	t.Parallel()
+
+	clearEnv, inheritEnv := true, false
+	testCases := []struct {
+		policy      Policy
+		clearEnv    *bool
+		expectedErr error
+	}{
+		{policy: Policy{}},
+		{policy: Policy{}, clearEnv: &clearEnv},
+		{policy: Policy{}, clearEnv: &inheritEnv, expectedErr: ErrInheritEnvNotAllowed},
+		{policy: Policy{AllowInheritEnv: true}, clearEnv: &inheritEnv},
+	}
+
+	for _, testCase := range testCases {
+		if err := testCase.policy.checkEnv(&jobWorker.JobConfig{ClearEnv: testCase.clearEnv}); !errors.Is(err, testCase.expectedErr) {
+			t.Errorf("policy:%+v, expected error %v, got %v", testCase.policy, testCase.expectedErr, err)
+		}
+	}
+}
+
+func Test_Policy_checkCredential(t *testing.T) {
+	t.Parallel()
+
+	policy := &Policy{Users: map[string]UserPolicy{"client-1": {UIDs: []uint32{1000, 1001}, GIDs: []uint32{1000, 100}}}}
+	uid, otherUid, root := uint32(1001), uint32(2000), uint32(0)
+
+	testCases := []struct {
+		user        string
+		uid         *uint32
+		gid         *uint32
+		groups      []uint32
+		expectedUID uint32
+		expectedGID uint32
+		expectedErr error
+	}{
+		{user: "client-1", expectedUID: 1000, expectedGID: 1000},
+		{user: "client-1", uid: &uid, groups: []uint32{100}, expectedUID: 1001, expectedGID: 1000},
+		{user: "client-1", uid: &otherUid, expectedErr: ErrIDNotAllowed},
+		{user: "client-1", uid: &root, expectedErr: ErrIDNotAllowed},
+		{user: "client-1", groups: []uint32{0}, expectedErr: ErrIDNotAllowed},
+		// users not in the policy run jobs as nobody of the job's user namespace, never as the server's user
+		{user: "client-2", expectedUID: 65534, expectedGID: 65534},
+		{user: "client-2", uid: &root, expectedErr: ErrIDNotAllowed},
+		{user: "client-2", gid: &root, expectedErr: ErrIDNotAllowed},
+		{user: "client-2", uid: &uid, expectedErr: ErrIDNotAllowed},
+	}
+
+	for _, testCase := range testCases {
+		config := jobWorker.JobConfig{}
+		err := policy.checkCredential(testCase.user, &config, testCase.uid, testCase.gid, testCase.groups)
+		if !errors.Is(err, testCase.expectedErr) {
+			t.Errorf("user:%s, expected error %v, got %v", testCase.user, testCase.expectedErr, err)
+			continue
+		}
+		if err == nil && (config.UID != testCase.expectedUID || config.GID != testCase.expectedGID) {
+			t.Errorf("user:%s, expected %d:%d, got %d:%d", testCase.user, testCase.expectedUID, testCase.expectedGID, config.UID, config.GID)
+		}
+	}
+}
+
+func Test_Policy_checkCapabilities(t *testing.T) {
+	t.Parallel()
+
+	policy := &Policy{Users: map[string]UserPolicy{
+		"client-1": {Capabilities: []string{"NET_BIND_SERVICE", "CAP_KILL"}},
+		"admin":    {Capabilities: []string{"ALL"}},
+	}}
+	newPrivs := false
+
+	testCases := []struct {
+		user        string
+		config      jobWorker.JobConfig
+		expectedErr error
+	}{
+		{user: "client-1", config: jobWorker.JobConfig{AddCapabilities: []string{"CAP_NET_BIND_SERVICE"}}},
+		{user: "client-1", config: jobWorker.JobConfig{AddCapabilities: []string{"ALL"}, DropCapabilities: []string{"ALL"}}},
+		{user: "client-1", config: jobWorker.JobConfig{AddCapabilities: []string{"CAP_SYS_ADMIN"}}, expectedErr: ErrCapabilityNotAllowed},
+		{user: "client-1", config: jobWorker.JobConfig{AddCapabilities: []string{"ALL"}}, expectedErr: ErrCapabilityNotAllowed},
+		{user: "client-1", config: jobWorker.JobConfig{NoNewPrivs: &newPrivs}, expectedErr: ErrNewPrivsNotAllowed},
+		{user: "admin", config: jobWorker.JobConfig{AddCapabilities: []string{"ALL"}}},
+		{user: "client-2", config: jobWorker.JobConfig{}},
+		{user: "client-2", config: jobWorker.JobConfig{AddCapabilities: []string{"CAP_KILL"}}, expectedErr: ErrCapabilityNotAllowed},
+	}
+
+	for _, testCase := range testCases {
+		if err := policy.checkCapabilities(testCase.user, &testCase.config); !errors.Is(err, testCase.expectedErr) {
+			t.Errorf("user:%s config:%+v, expected error %v, got %v", testCase.user, testCase.config, testCase.expectedErr, err)
+		}
+	}
+}
+
+func Test_Policy_checkSeccompProfile(t *testing.T) {
+	t.Parallel()
+
+	policy := &Policy{AllowedSeccompProfiles: []string{"strict"}}
+
+	testCases := []struct {
+		profile     string
+		expectedErr error
+	}{
+		{profile: ""},
+		{profile: jobWorker.SeccompProfileDefault},
+		{profile: "strict"},
+		{profile: jobWorker.SeccompProfilePermissive, expectedErr: ErrSeccompNotAllowed},
+		{profile: "custom", expectedErr: ErrSeccompNotAllowed},
+	}
+
+	for _, testCase := range testCases {
+		config := jobWorker.JobConfig{SeccompProfile: testCase.profile}
+		if err := policy.checkSeccompProfile(&config); !errors.Is(err, testCase.expectedErr) {
+			t.Errorf("profile:%s, expected error %v, got %v", testCase.profile, testCase.expectedErr, err)
+		}
+	}
+}
+
+func Test_Policy_checkRlimits(t *testing.T) {
+	t.Parallel()
+
+	policy := &Policy{
+		DefaultRlimits: map[string]ns.Rlimit{"RLIMIT_NOFILE": {Soft: 1024, Hard: 4096}, "RLIMIT_CORE": {}},
+		MaxRlimits:     map[string]uint64{"RLIMIT_NOFILE": 65536, "RLIMIT_CPU": 60},
+	}
+
+	testCases := []struct {
+		rlimits         map[string]ns.Rlimit
+		expectedRlimits map[string]ns.Rlimit
+		expectedErr     error
+	}{
+		{
+			expectedRlimits: map[string]ns.Rlimit{
+				"RLIMIT_NOFILE": {Soft: 1024, Hard: 4096}, "RLIMIT_CORE": {}, "RLIMIT_CPU": {Soft: 60, Hard: 60},
+			},
+		},
+		{
+			rlimits: map[string]ns.Rlimit{"nofile": {Soft: 65536, Hard: 65536}, "CPU": {Soft: 10, Hard: 20}, "STACK": {Soft: 1 << 23, Hard: 1 << 23}},
+			expectedRlimits: map[string]ns.Rlimit{
+				"RLIMIT_NOFILE": {Soft: 65536, Hard: 65536}, "RLIMIT_CORE": {}, "RLIMIT_CPU": {Soft: 10, Hard: 20},
+				"RLIMIT_STACK": {Soft: 1 << 23, Hard: 1 << 23},
+			},
+		},
+		{rlimits: map[string]ns.Rlimit{"RLIMIT_CPU": {Soft: 10, Hard: ns.RlimitInfinity}}, expectedErr: ErrRlimitNotAllowed},
+		{rlimits: map[string]ns.Rlimit{"RLIMIT_NOFILE": {Soft: 1 << 20, Hard: 1 << 20}}, expectedErr: ErrRlimitNotAllowed},
+		{rlimits: map[string]ns.Rlimit{"RLIMIT_FLY": {}}, expectedErr: ns.ErrInvalidRlimit},
+	}
+
+	for _, testCase := range testCases {
+		config := jobWorker.JobConfig{Rlimits: testCase.rlimits}
+		err := policy.checkRlimits(&config)
+		if !errors.Is(err, testCase.expectedErr) {
+			t.Errorf("rlimits:%v, expected error %v, got %v", testCase.rlimits, testCase.expectedErr, err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(config.Rlimits, testCase.expectedRlimits) {
+			t.Errorf("rlimits:%v, expected %v, got %v", testCase.rlimits, testCase.expectedRlimits, config.Rlimits)
+		}
+	}
+}
+
+func Test_Policy_checkPortMappings(t *testing.T) {
+	t.Parallel()
+
+	policy := &Policy{
+		Users:      map[string]UserPolicy{"client-1": {HostPorts: &PortRange{Min: 80, Max: 8080}}},
+		listenPort: 8080,
+	}
+
+	testCases := []struct {
+		user      string
+		hostPort  uint16
+		isAllowed bool
+	}{
+		{user: "client-1", hostPort: 80, isAllowed: true},
+		{user: "client-1", hostPort: 8000, isAllowed: true},
+		{user: "client-1", hostPort: 79},
+		{user: "client-1", hostPort: 8081},
+		// the server's own port is never forwarded
+		{user: "client-1", hostPort: 8080},
+		// users without host ports can forward unprivileged ports only
+		{user: "client-2", hostPort: 1024, isAllowed: true},
+		{user: "client-2", hostPort: 65535, isAllowed: true},
+		{user: "client-2", hostPort: 22},
+		{user: "client-2", hostPort: 443},
+		{user: "client-2", hostPort: 8080},
+	}
+
+	for _, testCase := range testCases {
+		config := jobWorker.JobConfig{PortMappings: []ns.PortMapping{{Protocol: "tcp", HostPort: testCase.hostPort, ContainerPort: 80}}}
+		err := policy.checkPortMappings(testCase.user, &config)
+		if testCase.isAllowed && err != nil {
+			t.Errorf("user:%s, port:%d, expected to be allowed, got %v", testCase.user, testCase.hostPort, err)
+		}
+		if !testCase.isAllowed && !errors.Is(err, ErrPortNotAllowed) {
+			t.Errorf("user:%s, port:%d, expected error(ErrPortNotAllowed), got %v", testCase.user, testCase.hostPort, err)
+		}
+	}
+}
+
+func Test_checkDefaultID_expected_ErrIDNotMapped_without_subordinate_IDs(t *testing.T) {
+	// not parallel, because the test replaces SubUIDFile and SubGIDFile
+	defer func(subUIDFile, subGIDFile string) {
+		jobWorker.SubUIDFile, jobWorker.SubGIDFile = subUIDFile, subGIDFile
+	}(jobWorker.SubUIDFile, jobWorker.SubGIDFile)
+
+	dir := t.TempDir()
+	jobWorker.SubUIDFile, jobWorker.SubGIDFile = filepath.Join(dir, "subuid"), filepath.Join(dir, "subgid")
+	if err := checkDefaultID(); !errors.Is(err, jobWorker.ErrIDNotMapped) {
+		t.Errorf("expected error %v without subordinate IDs, got %v", jobWorker.ErrIDNotMapped, err)
+	}
+
+	// IDs from 1 to 65536 are mapped
+	subIDs := []byte(strconv.Itoa(os.Getuid()) + ":100000:65536\n")
+	for _, path := range []string{jobWorker.SubUIDFile, jobWorker.SubGIDFile} {
+		if err := os.WriteFile(path, subIDs, 0o644); err != nil {
+			t.Fatalf("could not write %s: %v", path, err)
+		}
+	}
+	if err := checkDefaultID(); err != nil {
+		t.Errorf("expected no error with subordinate IDs, got %v", err)
+	}
+}